@@ -0,0 +1,152 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// describeUserAgent turns a User-Agent header into a short "Browser on OS"
+// label for the sessions page.
+func describeUserAgent(agent string) string {
+	if agent == "" {
+		return "Unknown device"
+	}
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(agent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(agent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(agent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(agent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(agent, "Safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(agent, "curl/"):
+		browser = "curl"
+	}
+	system := ""
+	switch {
+	case strings.Contains(agent, "iPhone"), strings.Contains(agent, "iPad"):
+		system = "iOS"
+	case strings.Contains(agent, "Android"):
+		system = "Android"
+	case strings.Contains(agent, "Windows"):
+		system = "Windows"
+	case strings.Contains(agent, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(agent, "Linux"):
+		system = "Linux"
+	}
+	if system == "" {
+		return browser
+	}
+	return browser + " on " + system
+}
+
+// sessionRow is one signed-in browser on the sessions page.
+type sessionRow struct {
+	ID         pgtype.UUID
+	Device     string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  pgtype.Timestamptz
+	LastSeenAt pgtype.Timestamptz
+	Current    bool // The browser viewing the page
+}
+
+func (app *App) getSessionsHandler(c *gin.Context) {
+	userID, ok := app.signedInUserID(c)
+	if !ok {
+		return
+	}
+
+	userSessions, err := app.db.ListUserSessions(c.Request.Context(), db.ListUserSessionsParams{
+		UserID:    userID,
+		SeenAfter: app.sessionStore.idleCutoff(),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Sessions GET: DB error for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load sessions.")
+		return
+	}
+	current := app.sessionStore.currentTokenHash(c.Request)
+
+	rows := make([]sessionRow, 0, len(userSessions))
+	for _, s := range userSessions {
+		rows = append(rows, sessionRow{
+			ID:         s.ID,
+			Device:     describeUserAgent(s.UserAgent),
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IpAddress,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    bytes.Equal(s.TokenHash, current),
+		})
+	}
+
+	app.render(c, http.StatusOK, "sessions.html", "Active Sessions", gin.H{
+		"IdleTimeout": app.sessionStore.idleTimeout.String(),
+		"Sessions":    rows,
+		"CSRF":        csrfToken(c),
+	})
+}
+
+func (app *App) postRevokeSessionHandler(c *gin.Context) {
+	userID, ok := app.signedInUserID(c)
+	if !ok {
+		return
+	}
+	sessionUUID, err := uuid.Parse(c.Param("sessionID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid session ID.")
+		return
+	}
+
+	_, err = app.db.DeleteUserSession(c.Request.Context(), db.DeleteUserSessionParams{
+		ID:     pgtype.UUID{Bytes: sessionUUID, Valid: true},
+		UserID: userID,
+	})
+	if err != nil {
+		fmt.Printf("Revoke Session: DB error for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to sign out session.")
+		return
+	}
+	// Revoking this browser's own session signs it out; the sessions page
+	// then sends it home.
+	c.Redirect(http.StatusSeeOther, "/account/sessions")
+}
+
+func (app *App) postRevokeOtherSessionsHandler(c *gin.Context) {
+	userID, ok := app.signedInUserID(c)
+	if !ok {
+		return
+	}
+	current := app.sessionStore.currentTokenHash(c.Request)
+	if current == nil {
+		c.Redirect(http.StatusSeeOther, "/account/sessions")
+		return
+	}
+
+	removed, err := app.db.DeleteOtherUserSessions(c.Request.Context(), db.DeleteOtherUserSessionsParams{
+		UserID:    userID,
+		TokenHash: current,
+	})
+	if err != nil {
+		fmt.Printf("Revoke Other Sessions: DB error for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to sign out other sessions.")
+		return
+	}
+	fmt.Printf("User %s signed out %d other sessions.\n", userID.String(), removed)
+	c.Redirect(http.StatusSeeOther, "/account/sessions")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var adminAssignableRoles = []string{RoleApplicant, RoleRecruiter, RoleAdmin}
+
+// adminUserRow is a user on the admin users page. Admins cannot change their
+// own account there.
+type adminUserRow struct {
+	db.ListUsersForAdminRow
+	Self bool
+}
+
+func (app *App) getAdminUsersHandler(c *gin.Context) {
+	admin, ok := app.requireRole(c, RoleAdmin)
+	if !ok {
+		return
+	}
+
+	users, err := app.db.ListUsersForAdmin(c.Request.Context())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Admin Users GET: DB error listing users: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to load users.")
+		return
+	}
+
+	rows := make([]adminUserRow, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, adminUserRow{ListUsersForAdminRow: user, Self: user.ID.Bytes == admin.ID.Bytes})
+	}
+
+	app.render(c, http.StatusOK, "admin_users.html", "Users", gin.H{
+		"Users": rows,
+		"Roles": adminAssignableRoles,
+		"Error": c.Query("error"),
+		"CSRF":  csrfToken(c),
+	})
+}
+
+// adminTargetUser parses :userID and refuses changes to the admin's own
+// account, so an admin cannot lock themselves out.
+func adminTargetUser(c *gin.Context, admin db.GetUserRow) (pgtype.UUID, bool) {
+	userUUID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid user ID.")
+		return pgtype.UUID{}, false
+	}
+	if userUUID == uuid.UUID(admin.ID.Bytes) {
+		c.Redirect(http.StatusSeeOther, "/admin/users?error="+url.QueryEscape("You cannot change your own account here."))
+		return pgtype.UUID{}, false
+	}
+	return pgtype.UUID{Bytes: userUUID, Valid: true}, true
+}
+
+func (app *App) postAdminUserRoleHandler(c *gin.Context) {
+	admin, ok := app.requireRole(c, RoleAdmin)
+	if !ok {
+		return
+	}
+	userID, ok := adminTargetUser(c, admin)
+	if !ok {
+		return
+	}
+	role := c.PostForm("role")
+	valid := false
+	for _, r := range adminAssignableRoles {
+		valid = valid || r == role
+	}
+	if !valid {
+		c.Redirect(http.StatusSeeOther, "/admin/users?error="+url.QueryEscape("Unknown role."))
+		return
+	}
+
+	ctx := c.Request.Context()
+	changed, err := app.db.SetUserRole(ctx, db.SetUserRoleParams{ID: userID, Role: role})
+	if err != nil {
+		fmt.Printf("Admin Users: Failed to set role of %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to change role.")
+		return
+	}
+	if changed > 0 {
+		// Sessions remember the role they signed in with and stop working once
+		// it changes; deleting them also clears them from the sessions page.
+		if err := app.db.DeleteAllUserSessions(ctx, userID); err != nil {
+			fmt.Printf("Admin Users: Failed to sign out %s after role change: %v\n", userID.String(), err)
+		}
+		fmt.Printf("Admin %s changed the role of user %s to %s.\n", admin.ID.String(), userID.String(), role)
+	}
+	c.Redirect(http.StatusSeeOther, "/admin/users")
+}
+
+func (app *App) postAdminUserSuspendHandler(c *gin.Context) {
+	admin, ok := app.requireRole(c, RoleAdmin)
+	if !ok {
+		return
+	}
+	userID, ok := adminTargetUser(c, admin)
+	if !ok {
+		return
+	}
+	suspended := c.PostForm("suspended") == "true"
+
+	ctx := c.Request.Context()
+	if _, err := app.db.SetUserSuspended(ctx, db.SetUserSuspendedParams{ID: userID, Suspended: suspended}); err != nil {
+		fmt.Printf("Admin Users: Failed to update suspension of %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to update user.")
+		return
+	}
+	if suspended {
+		if err := app.db.DeleteAllUserSessions(ctx, userID); err != nil {
+			fmt.Printf("Admin Users: Failed to sign out suspended user %s: %v\n", userID.String(), err)
+		}
+		fmt.Printf("Admin %s suspended user %s.\n", admin.ID.String(), userID.String())
+	} else {
+		fmt.Printf("Admin %s reinstated user %s.\n", admin.ID.String(), userID.String())
+	}
+	c.Redirect(http.StatusSeeOther, "/admin/users")
+}
+
+func (app *App) postAdminResetTwoFactorHandler(c *gin.Context) {
+	admin, ok := app.requireRole(c, RoleAdmin)
+	if !ok {
+		return
+	}
+	userID, ok := adminTargetUser(c, admin)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := app.disableTwoFactor(ctx, userID); err != nil {
+		fmt.Printf("Admin Users: Failed to reset two-factor authentication of %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to reset two-factor authentication.")
+		return
+	}
+	if err := app.db.DeleteAllUserSessions(ctx, userID); err != nil {
+		fmt.Printf("Admin Users: Failed to sign out %s after two-factor reset: %v\n", userID.String(), err)
+	}
+	fmt.Printf("Admin %s reset two-factor authentication of user %s.\n", admin.ID.String(), userID.String())
+	c.Redirect(http.StatusSeeOther, "/admin/users")
+}
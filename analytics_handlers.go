@@ -0,0 +1,286 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// analyticsPeriods are the reporting windows offered, in days. 0 means all time.
+var analyticsPeriods = []int{30, 90, 365, 0}
+
+const defaultAnalyticsDays = 90
+
+// analyticsFilter scopes the analytics to a recruiter's postings, optionally a
+// single job, and applications received since a point in time.
+type analyticsFilter struct {
+	RecruiterID pgtype.UUID
+	JobID       pgtype.UUID
+	Days        int
+	Since       pgtype.Timestamptz
+}
+
+type analyticsReport struct {
+	Summary db.GetRecruitingMetricsRow
+	Jobs    []db.ListJobMetricsRow
+	Weeks   []db.ListApplicationsPerWeekRow
+	Sources []db.ListSourceMetricsRow
+}
+
+func parseAnalyticsFilter(c *gin.Context, recruiterID pgtype.UUID) analyticsFilter {
+	filter := analyticsFilter{RecruiterID: recruiterID, Days: defaultAnalyticsDays}
+	if days, err := strconv.Atoi(c.Query("days")); err == nil {
+		for _, period := range analyticsPeriods {
+			if days == period {
+				filter.Days = days
+			}
+		}
+	}
+	if jobUUID, err := uuid.Parse(c.Query("job")); err == nil {
+		filter.JobID = pgtype.UUID{Bytes: jobUUID, Valid: true}
+	}
+
+	since := time.Unix(0, 0)
+	if filter.Days > 0 {
+		since = time.Now().AddDate(0, 0, -filter.Days)
+	}
+	filter.Since = pgtype.Timestamptz{Time: since, Valid: true}
+	return filter
+}
+
+func (app *App) loadAnalytics(ctx context.Context, filter analyticsFilter) (analyticsReport, error) {
+	var report analyticsReport
+	var err error
+
+	report.Summary, err = app.db.GetRecruitingMetrics(ctx, db.GetRecruitingMetricsParams{
+		RecruiterID: filter.RecruiterID,
+		JobID:       filter.JobID,
+		Since:       filter.Since,
+	})
+	if err != nil {
+		return report, fmt.Errorf("summary: %w", err)
+	}
+	report.Jobs, err = app.db.ListJobMetrics(ctx, db.ListJobMetricsParams{
+		RecruiterID: filter.RecruiterID,
+		JobID:       filter.JobID,
+		Since:       filter.Since,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return report, fmt.Errorf("jobs: %w", err)
+	}
+	report.Weeks, err = app.db.ListApplicationsPerWeek(ctx, db.ListApplicationsPerWeekParams{
+		RecruiterID: filter.RecruiterID,
+		JobID:       filter.JobID,
+		Since:       filter.Since,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return report, fmt.Errorf("weeks: %w", err)
+	}
+	report.Sources, err = app.db.ListSourceMetrics(ctx, db.ListSourceMetricsParams{
+		RecruiterID: filter.RecruiterID,
+		JobID:       filter.JobID,
+		Since:       filter.Since,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return report, fmt.Errorf("sources: %w", err)
+	}
+	return report, nil
+}
+
+func percent(part, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+func hoursText(hours float64, count int64) string {
+	if count == 0 {
+		return "-"
+	}
+	if hours >= 48 {
+		return fmt.Sprintf("%.1f days", hours/24)
+	}
+	return fmt.Sprintf("%.1f hours", hours)
+}
+
+func daysText(days float64, count int64) string {
+	if count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f days", days)
+}
+
+// barData is a horizontal bar scaled against max, for the charts.
+type barData struct {
+	Value int64
+	Width int64 // In pixels
+}
+
+func bar(value, max int64) barData {
+	b := barData{Value: value}
+	if max > 0 {
+		b.Width = value * 300 / max
+	}
+	return b
+}
+
+// funnelStages pairs each step of the hiring funnel with its count.
+func funnelStages(summary db.GetRecruitingMetricsRow) []struct {
+	Label string
+	Count int64
+} {
+	return []struct {
+		Label string
+		Count int64
+	}{
+		{applicationStatusLabels[ApplicationStatusSubmitted], summary.Applied},
+		{applicationStatusLabels[ApplicationStatusScreening], summary.Screened},
+		{applicationStatusLabels[ApplicationStatusAccepted], summary.Interviewed},
+		{applicationStatusLabels[ApplicationStatusOffered], summary.Offered},
+		{applicationStatusLabels[ApplicationStatusHired], summary.Hired},
+	}
+}
+
+func (app *App) getAnalyticsHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	filter := parseAnalyticsFilter(c, recruiter.ID)
+
+	report, err := app.loadAnalytics(c.Request.Context(), filter)
+	if err != nil {
+		fmt.Printf("Analytics GET: DB error loading analytics for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load analytics.")
+		return
+	}
+
+	type periodLink struct {
+		Label   string
+		Days    int
+		Current bool
+	}
+	periods := make([]periodLink, 0, len(analyticsPeriods))
+	for _, days := range analyticsPeriods {
+		label := fmt.Sprintf("Last %d days", days)
+		if days == 0 {
+			label = "All time"
+		}
+		periods = append(periods, periodLink{Label: label, Days: days, Current: days == filter.Days})
+	}
+
+	jobID, jobTitle := "", "this job"
+	if filter.JobID.Valid {
+		jobID = uuid.UUID(filter.JobID.Bytes).String()
+		if len(report.Jobs) == 1 {
+			jobTitle = report.Jobs[0].Title
+		}
+	}
+
+	type funnelRow struct {
+		Label        string
+		Count        int64
+		FromPrevious string
+	}
+	stages := funnelStages(report.Summary)
+	funnel := make([]funnelRow, 0, len(stages))
+	for i, stage := range stages {
+		fromPrevious := "-"
+		if i > 0 {
+			fromPrevious = percent(stage.Count, stages[i-1].Count)
+		}
+		funnel = append(funnel, funnelRow{Label: stage.Label, Count: stage.Count, FromPrevious: fromPrevious})
+	}
+
+	var maxWeek int64
+	for _, week := range report.Weeks {
+		maxWeek = max(maxWeek, week.Applied)
+	}
+
+	app.render(c, http.StatusOK, "analytics.html", "Recruiting Analytics", gin.H{
+		"Report":   report,
+		"Periods":  periods,
+		"Days":     filter.Days,
+		"JobID":    jobID,
+		"JobTitle": jobTitle,
+		"Funnel":   funnel,
+		"MaxWeek":  maxWeek,
+	})
+}
+
+// getAnalyticsExportHandler downloads the same report as CSV, one section per
+// table.
+func (app *App) getAnalyticsExportHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	filter := parseAnalyticsFilter(c, recruiter.ID)
+
+	report, err := app.loadAnalytics(c.Request.Context(), filter)
+	if err != nil {
+		fmt.Printf("Analytics Export: DB error loading analytics for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load analytics.")
+		return
+	}
+	summary := report.Summary
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	count := func(v int64) string { return strconv.FormatInt(v, 10) }
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="analytics-%s.csv"`, time.Now().Format("2006-01-02")))
+	w := csv.NewWriter(c.Writer)
+
+	w.Write([]string{"Summary"})
+	w.Write([]string{"Metric", "Value"})
+	w.Write([]string{"Period (days, 0 = all time)", strconv.Itoa(filter.Days)})
+	for _, stage := range funnelStages(summary) {
+		w.Write([]string{"Reached " + stage.Label, count(stage.Count)})
+	}
+	w.Write([]string{"Rejected", count(summary.Rejected)})
+	w.Write([]string{"Withdrawn", count(summary.Withdrawn)})
+	w.Write([]string{"Offers Declined", count(summary.Declined)})
+	w.Write([]string{"Avg. First Response (hours)", float(summary.AvgFirstResponseHours)})
+	w.Write([]string{"Avg. Time to Hire (days)", float(summary.AvgTimeToHireDays)})
+	w.Write(nil)
+
+	w.Write([]string{"Applications Per Week"})
+	w.Write([]string{"Week Of", "Applications"})
+	for _, week := range report.Weeks {
+		w.Write([]string{week.Week.Time.Format("2006-01-02"), count(week.Applied)})
+	}
+	w.Write(nil)
+
+	w.Write([]string{"Sources"})
+	w.Write([]string{"Source", "Applications", "Interviewed", "Hired"})
+	for _, source := range report.Sources {
+		w.Write([]string{source.Source, count(source.Applied), count(source.Interviewed), count(source.Hired)})
+	}
+	w.Write(nil)
+
+	w.Write([]string{"Jobs"})
+	w.Write([]string{"Job ID", "Title", "Status", "Applications", "Interviewed", "Offered", "Hired", "Rejected", "Avg. First Response (hours)", "Avg. Time to Hire (days)"})
+	for _, job := range report.Jobs {
+		w.Write([]string{
+			uuid.UUID(job.ID.Bytes).String(), job.Title, job.Status,
+			count(job.Applied), count(job.Interviewed), count(job.Offered), count(job.Hired), count(job.Rejected),
+			float(job.AvgFirstResponseHours), float(job.AvgTimeToHireDays),
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Printf("Analytics Export: Failed to write CSV: %v\n", err)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The JSON API mirrors what recruiters can see and do in the browser. All
+// routes run behind apiKeyMiddleware and only reach the key owner's data.
+
+type apiJob struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Status      string     `json:"status"`
+	URL         string     `json:"url,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	Description string     `json:"description,omitempty"`
+	SalaryMin   *string    `json:"salary_min"`
+	SalaryMax   *string    `json:"salary_max"`
+	Headcount   int32      `json:"headcount,omitempty"`
+	PublishAt   *time.Time `json:"publish_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type apiApplication struct {
+	ID           string           `json:"id"`
+	JobPostingID string           `json:"job_posting_id"`
+	JobTitle     string           `json:"job_title,omitempty"`
+	Status       string           `json:"status"`
+	AppliedAt    time.Time        `json:"applied_at"`
+	Applicant    webhookApplicant `json:"applicant"`
+	CoverLetter  string           `json:"cover_letter,omitempty"`
+}
+
+type apiStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func apiRecruiterID(c *gin.Context) pgtype.UUID {
+	userID, _ := c.Get("userID")
+	recruiterID, _ := userID.(pgtype.UUID)
+	return recruiterID
+}
+
+func apiError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+// apiLoadOwnedJob is loadOwnedJob for the JSON API.
+func (app *App) apiLoadOwnedJob(c *gin.Context) (db.GetJobPostingByIDRow, bool) {
+	jobUUID, err := uuid.Parse(c.Param("jobID"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid job ID")
+		return db.GetJobPostingByIDRow{}, false
+	}
+	job, err := app.db.GetJobPostingByID(c.Request.Context(), pgtype.UUID{Bytes: jobUUID, Valid: true})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("API: DB error fetching job %s: %v\n", jobUUID.String(), err)
+			apiError(c, http.StatusInternalServerError, "failed to load job")
+			return db.GetJobPostingByIDRow{}, false
+		}
+		apiError(c, http.StatusNotFound, "job not found")
+		return db.GetJobPostingByIDRow{}, false
+	}
+	// Someone else's job is reported as missing rather than forbidden, so IDs
+	// cannot be probed.
+	if job.RecruiterID.Bytes != apiRecruiterID(c).Bytes {
+		apiError(c, http.StatusNotFound, "job not found")
+		return db.GetJobPostingByIDRow{}, false
+	}
+	return job, true
+}
+
+func (app *App) apiLoadOwnedApplication(c *gin.Context) (db.GetApplicationByIDRow, bool) {
+	applicationUUID, err := uuid.Parse(c.Param("applicationID"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid application ID")
+		return db.GetApplicationByIDRow{}, false
+	}
+	application, err := app.db.GetApplicationByID(c.Request.Context(), pgtype.UUID{Bytes: applicationUUID, Valid: true})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("API: DB error fetching application %s: %v\n", applicationUUID.String(), err)
+			apiError(c, http.StatusInternalServerError, "failed to load application")
+			return db.GetApplicationByIDRow{}, false
+		}
+		apiError(c, http.StatusNotFound, "application not found")
+		return db.GetApplicationByIDRow{}, false
+	}
+	if application.RecruiterID.Bytes != apiRecruiterID(c).Bytes {
+		apiError(c, http.StatusNotFound, "application not found")
+		return db.GetApplicationByIDRow{}, false
+	}
+	return application, true
+}
+
+func toAPIApplication(application db.GetApplicationByIDRow) apiApplication {
+	return apiApplication{
+		ID:           uuid.UUID(application.ID.Bytes).String(),
+		JobPostingID: uuid.UUID(application.JobPostingID.Bytes).String(),
+		JobTitle:     application.JobTitle,
+		Status:       application.Status,
+		AppliedAt:    application.AppliedAt.Time.UTC(),
+		Applicant: webhookApplicant{
+			ID:    uuid.UUID(application.UserID.Bytes).String(),
+			Name:  application.ApplicantName,
+			Email: application.ApplicantEmail,
+		},
+		CoverLetter: application.CoverLetter.String,
+	}
+}
+
+func (app *App) apiListJobsHandler(c *gin.Context) {
+	jobs, err := app.db.ListJobPostingsByRecruiter(c.Request.Context(), apiRecruiterID(c))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("API Jobs GET: DB error listing jobs: %v\n", err)
+		apiError(c, http.StatusInternalServerError, "failed to load jobs")
+		return
+	}
+
+	items := make([]apiJob, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, apiJob{
+			ID:        uuid.UUID(job.ID.Bytes).String(),
+			Title:     job.Title,
+			Status:    job.Status,
+			SalaryMin: optionalNumeric(job.SalaryMin),
+			SalaryMax: optionalNumeric(job.SalaryMax),
+			PublishAt: optionalTime(job.PublishAt),
+			ExpiresAt: optionalTime(job.ExpiresAt),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": items})
+}
+
+func (app *App) apiGetJobHandler(c *gin.Context) {
+	job, ok := app.apiLoadOwnedJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apiJob{
+		ID:          uuid.UUID(job.ID.Bytes).String(),
+		Title:       job.Title,
+		Status:      job.Status,
+		URL:         app.absoluteURL(c, careersURL(job.Slug)),
+		Location:    jobLocationText(job.Location, job.Remote),
+		Description: job.Description.String,
+		SalaryMin:   optionalNumeric(job.SalaryMin),
+		SalaryMax:   optionalNumeric(job.SalaryMax),
+		Headcount:   job.Headcount,
+		PublishAt:   optionalTime(job.PublishAt),
+		PublishedAt: optionalTime(job.PublishedAt),
+		ExpiresAt:   optionalTime(job.ExpiresAt),
+	})
+}
+
+func (app *App) apiListJobApplicationsHandler(c *gin.Context) {
+	job, ok := app.apiLoadOwnedJob(c)
+	if !ok {
+		return
+	}
+	applications, err := app.db.GetApplicationsForJobPosting(c.Request.Context(), job.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("API Applications GET: DB error for job %s: %v\n", job.ID.String(), err)
+		apiError(c, http.StatusInternalServerError, "failed to load applications")
+		return
+	}
+
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+	items := make([]apiApplication, 0, len(applications))
+	for _, application := range applications {
+		items = append(items, apiApplication{
+			ID:           uuid.UUID(application.ApplicationID.Bytes).String(),
+			JobPostingID: jobIDStr,
+			Status:       application.ApplicationStatus,
+			AppliedAt:    application.AppliedAt.Time.UTC(),
+			Applicant: webhookApplicant{
+				ID:    uuid.UUID(application.UserID.Bytes).String(),
+				Name:  application.UserName,
+				Email: application.UserEmail,
+			},
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"applications": items})
+}
+
+func (app *App) apiGetApplicationHandler(c *gin.Context) {
+	application, ok := app.apiLoadOwnedApplication(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, toAPIApplication(application))
+}
+
+// apiPostApplicationStatusHandler moves an application to another pipeline
+// stage, following the same rules as the pipeline board.
+func (app *App) apiPostApplicationStatusHandler(c *gin.Context) {
+	application, ok := app.apiLoadOwnedApplication(c)
+	if !ok {
+		return
+	}
+	var req apiStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, "body must be JSON with a status field")
+		return
+	}
+
+	recruiterID := apiRecruiterID(c)
+	err := app.moveApplicationToStage(c.Request.Context(), application, req.Status, recruiterID)
+	if errors.Is(err, errInvalidStatusTransition) {
+		apiError(c, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("API Status POST: Failed to move application %s: %v\n", application.ID.String(), err)
+		apiError(c, http.StatusInternalServerError, "failed to update application")
+		return
+	}
+
+	application, err = app.db.GetApplicationByID(c.Request.Context(), application.ID)
+	if err != nil {
+		fmt.Printf("API Status POST: Failed to reload application %s: %v\n", application.ID.String(), err)
+		apiError(c, http.StatusInternalServerError, "failed to load application")
+		return
+	}
+	c.JSON(http.StatusOK, toAPIApplication(application))
+}
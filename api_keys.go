@@ -0,0 +1,219 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	APIScopeJobsRead          = "jobs:read"
+	APIScopeApplicationsRead  = "applications:read"
+	APIScopeApplicationsWrite = "applications:write"
+
+	apiKeyPrefix     = "rgo_"
+	apiKeyScopesKey  = "apiKeyScopes" // Context key for the scopes of the request's API key
+	apiKeyHeaderName = "X-API-Key"
+)
+
+// apiScopes lists the scopes a key can be granted, in display order.
+var apiScopes = []struct{ Name, Description string }{
+	{APIScopeJobsRead, "List and read your job postings"},
+	{APIScopeApplicationsRead, "Read applications to your job postings"},
+	{APIScopeApplicationsWrite, "Move applications between pipeline stages"},
+}
+
+// newAPIKey returns a key of the form rgo_<prefix>_<secret>. The prefix is
+// stored in the clear to find the key; only a hash of the whole key is kept.
+func newAPIKey() (key, prefix string, err error) {
+	b := make([]byte, 36)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(b[:4])
+	return apiKeyPrefix + prefix + "_" + hex.EncodeToString(b[4:]), prefix, nil
+}
+
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// apiKeyFromRequest reads the key from an "Authorization: Bearer" header or
+// the X-API-Key header.
+func apiKeyFromRequest(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if token, found := strings.CutPrefix(auth, "Bearer "); found {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(c.GetHeader(apiKeyHeaderName))
+}
+
+// apiKeyMiddleware authenticates JSON API requests by API key, the way
+// authMiddleware does for browser sessions. It sets "userID" to the recruiter
+// who owns the key so handlers can treat both alike. Keys are scoped to the
+// organization the recruiter belonged to when the key was created, and stop
+// working once the recruiter's organization changes.
+func (app *App) apiKeyMiddleware(c *gin.Context) {
+	key := apiKeyFromRequest(c)
+	rest, found := strings.CutPrefix(key, apiKeyPrefix)
+	prefix, _, hasSecret := strings.Cut(rest, "_")
+	if !found || !hasSecret || prefix == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or malformed API key"})
+		return
+	}
+
+	apiKey, err := app.db.GetActiveAPIKeyByPrefix(c.Request.Context(), prefix)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("API Auth: DB error looking up key %s: %v\n", prefix, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+		return
+	}
+	if subtle.ConstantTimeCompare(apiKey.KeyHash, hashAPIKey(key)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+		return
+	}
+
+	if err := app.db.TouchAPIKey(c.Request.Context(), apiKey.ID); err != nil {
+		fmt.Printf("API Auth: Failed to record use of key %s: %v\n", prefix, err)
+	}
+
+	c.Set("userID", apiKey.RecruiterID)
+	c.Set(apiKeyScopesKey, apiKey.Scopes)
+	c.Next()
+}
+
+// requireAPIScope rejects requests whose API key was not granted scope.
+func requireAPIScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, _ := c.Get(apiKeyScopesKey)
+		granted, _ := scopes.([]string)
+		for _, s := range granted {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + scope + " scope"})
+	}
+}
+
+func (app *App) getAPIKeysHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	app.renderAPIKeysPage(c, recruiter.ID, "")
+}
+
+// renderAPIKeysPage lists the recruiter's keys. newKey is shown once, right
+// after it is created, since it cannot be recovered later.
+func (app *App) renderAPIKeysPage(c *gin.Context, recruiterID pgtype.UUID, newKey string) {
+	keys, err := app.db.ListAPIKeys(c.Request.Context(), recruiterID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("API Keys GET: DB error listing keys for recruiter %s: %v\n", recruiterID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load API keys.")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	app.render(c, http.StatusOK, "api_keys.html", "API Keys", gin.H{
+		"Keys":      keys,
+		"KeyPrefix": apiKeyPrefix,
+		"NewKey":    newKey,
+		"Scopes":    apiScopes,
+		"Error":     c.Query("error"),
+		"CSRF":      csrfToken(c),
+	})
+}
+
+func (app *App) postAPIKeyHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, "/recruiter/api-keys?error="+url.QueryEscape(message))
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		fail("Give the key a name so you can recognise it later.")
+		return
+	}
+	known := make(map[string]bool, len(apiScopes))
+	for _, scope := range apiScopes {
+		known[scope.Name] = true
+	}
+	var scopes []string
+	for _, scope := range c.PostFormArray("scopes") {
+		if known[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		fail("Choose at least one scope.")
+		return
+	}
+
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		fmt.Printf("API Keys POST: Failed to generate key: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to create API key.")
+		return
+	}
+	_, err = app.db.CreateAPIKey(c.Request.Context(), db.CreateAPIKeyParams{
+		RecruiterID: recruiter.ID,
+		Name:        name,
+		Prefix:      prefix,
+		KeyHash:     hashAPIKey(key),
+		Scopes:      scopes,
+	})
+	if err != nil {
+		fmt.Printf("API Keys POST: DB error creating key for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to create API key.")
+		return
+	}
+
+	fmt.Printf("Recruiter %s created API key %s%s with scopes %v\n", recruiter.ID.String(), apiKeyPrefix, prefix, scopes)
+	app.renderAPIKeysPage(c, recruiter.ID, key)
+}
+
+func (app *App) postRevokeAPIKeyHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	keyUUID, err := uuid.Parse(c.Param("keyID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid API key ID.")
+		return
+	}
+	err = app.db.RevokeAPIKey(c.Request.Context(), db.RevokeAPIKeyParams{
+		ID:          pgtype.UUID{Bytes: keyUUID, Valid: true},
+		RecruiterID: recruiter.ID,
+	})
+	if err != nil {
+		fmt.Printf("API Keys POST: DB error revoking key %s: %v\n", keyUUID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to revoke API key.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/recruiter/api-keys")
+}
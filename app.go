@@ -4,26 +4,45 @@ import (
 	db "Recruitment-GO/internal/db"
 	"encoding/gob"
 
-	"github.com/gin-contrib/sessions"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/markbates/goth"
 )
 
 type App struct {
-	db           *db.Queries
-	sessionStore sessions.Store
+	db            *db.Queries
+	pool          *pgxpool.Pool
+	sessionStore  *pgSessionStore
+	events        *eventBroker
+	site          siteConfig
+	webhooks      *webhookDispatcher
+	authProviders []authProvider
+	saml          *samlKeyPair // nil when SAML single sign-on is not configured
+	rateLimits    *rateLimiter
+
+	requireTwoFactor bool // Recruiters and admins must set up an authenticator to sign in
 }
 
 const (
-	sessionUserKey         = "db_user_id"
-	sessionTempGothUserKey = "temp_goth_user"
+	sessionCookieName          = "mysession"
+	sessionUserKey             = "db_user_id"
+	sessionTempGothUserKey     = "temp_goth_user"
+	sessionReturnToKey         = "return_to"        // Path to send the user back to after login
+	sessionLinkIdentityKey     = "link_identity"    // Set while a signed-in user links another provider
+	sessionPendingIdentityKey  = "pending_identity" // Provider login waiting to be linked to an existing account
+	sessionCSRFKey             = "csrf_token"
+	sessionPendingTwoFactorKey = "pending_2fa" // Login waiting for its authenticator code
+	sessionTOTPSetupKey        = "totp_setup"  // Authenticator key shown but not yet confirmed
 
 	RoleApplicant = "applicant"
 	RoleRecruiter = "recruiter"
+	RoleAdmin     = "admin" // Granted directly in the database; manages SSO and user accounts
 )
 
 func init() {
 	gob.Register(goth.User{})
 	gob.Register(db.User{})
 	gob.Register(pgtype.UUID{})
+	gob.Register(pendingIdentity{})
+	gob.Register(pendingTwoFactor{})
 }
@@ -2,13 +2,14 @@ package main
 
 import (
 	"Recruitment-GO/internal/db"
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"html"
 	"net/http"
-	"net/smtp"
-	"os"
+	"strings"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -17,6 +18,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const (
+	AttachmentKindCoverLetter = "cover_letter"
+	AttachmentKindAttachment  = "attachment"
+
+	ApplicationSourceDirect  = "direct"
+	ApplicationSourceCareers = "careers"
+	ApplicationSourceImport  = "import"
+
+	maxAttachmentsPerApplication = 5
+)
+
+type applicationFile struct {
+	Kind string
+	uploadedFile
+}
+
+// applicationSource normalises the channel an application came through, taken
+// from a source or utm_source link parameter, e.g. "linkedin" or "referral".
+func applicationSource(value, fallback string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(value)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		}
+		if b.Len() == 50 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return fallback
+	}
+	return b.String()
+}
+
+// linkSource reads the source parameter of an incoming job link.
+func linkSource(c *gin.Context) string {
+	if source := c.Query("source"); source != "" {
+		return source
+	}
+	return c.Query("utm_source")
+}
+
 func (app *App) getApplyFormHandler(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -43,8 +86,7 @@ func (app *App) getApplyFormHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleApplicant {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only applicants can apply for jobs</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only applicants can apply for jobs")
 		c.Abort()
 		return
 	}
@@ -52,8 +94,7 @@ func (app *App) getApplyFormHandler(c *gin.Context) {
 	jobIDStr := c.Param("jobID")
 	jobUUID, err := uuid.Parse(jobIDStr)
 	if err != nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Invalid Job ID format</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Invalid Job ID format")
 		return
 	}
 	jobPgID := pgtype.UUID{Bytes: jobUUID, Valid: true}
@@ -61,20 +102,17 @@ func (app *App) getApplyFormHandler(c *gin.Context) {
 	job, err := app.db.GetJobPostingByID(c.Request.Context(), jobPgID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusNotFound, "<html><body>Job posting not found</body></html>")
+			app.renderError(c, http.StatusNotFound, "Job posting not found")
 		} else {
 			fmt.Printf("Apply GET: DB error fetching job %s: %v\n", jobIDStr, err)
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusInternalServerError, "<html><body>Error fetching job data</body></html>")
+			app.renderError(c, http.StatusInternalServerError, "Error fetching job data")
 			c.Abort()
 		}
 
 	}
 
-	if job.Status != "active" {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>This job posting is no longer active.</body></html>")
+	if job.Status != JobStatusPublished {
+		app.renderError(c, http.StatusBadRequest, "This job posting is not accepting applications.")
 		return
 	}
 
@@ -84,57 +122,27 @@ func (app *App) getApplyFormHandler(c *gin.Context) {
 	})
 
 	if checkErr == nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body><h1>Already applied for this job posting</h1></body></html>"+
-			"<p><a href=\"/applicant/dashboard\">Back to Dashboard</a></p>")
+		app.renderErrorBack(c, http.StatusBadRequest, "You have already applied for this job posting.", "/applicant/dashboard", "Back to Dashboard")
 		return
 	} else if !errors.Is(checkErr, sql.ErrNoRows) {
 		fmt.Printf("Apply GET: DB error checking existing application for user %s, job %s: %v\n", pgID.String(), jobPgID.String(), checkErr)
 		return
 	}
-	salaryMinVal, err := job.SalaryMin.Value()
-	if err != nil {
-		salaryMinVal = ""
-	}
-	salaryMaxVal, err := job.SalaryMax.Value()
-	if err != nil {
-		salaryMaxVal = ""
+	questions, err := app.db.ListScreeningQuestionsForJob(c.Request.Context(), jobPgID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Apply GET: DB error fetching screening questions for job %s: %v\n", jobIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Error loading application form")
+		return
 	}
-	applyPageHTML := fmt.Sprintf(`
-		<h2>Apply for Job</h2>
-		<h3>%s</h3>
-		<p><strong>Recruiter:</strong> %s</p>
-		<p><strong>Salary Range:</strong> %v - %v</p>
-		<p><strong>Status:</strong> %s</p>
-		<hr>
-		<p>Click below to submit your application using your stored resume (if available).</p>
-		
-		<form method="POST" action="/jobs/%s/apply">
-			<button type="submit">Confirm Application</button>
-		</form>
-		<br>
-		<p><a href="/jobs">Back to Job List</a></p>
-        <p><a href="/applicant/dashboard">Back to Dashboard</a></p>
-		`,
-		job.Title,
-		job.RecruiterName,
-		salaryMinVal,
-		salaryMaxVal,
-		job.Status,
-		jobIDStr,
-	)
-
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Apply: %s</title></head><body>
-		<nav>...</nav><hr>
-		%s
-		<hr><footer>...</footer></body></html>`,
-		html.EscapeString(job.Title),
-		applyPageHTML,
-	)
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+
+	app.render(c, http.StatusOK, "apply.html", "Apply: "+job.Title, gin.H{
+		"Job":            job,
+		"Details":        app.jobDetails(c.Request.Context(), job),
+		"Questions":      questions,
+		"CSRF":           csrfToken(c),
+		"MaxAttachments": maxAttachmentsPerApplication,
+		"Source":         applicationSource(linkSource(c), ApplicationSourceDirect),
+	})
 }
 
 func (app *App) postApplyHandler(c *gin.Context) {
@@ -165,26 +173,23 @@ func (app *App) postApplyHandler(c *gin.Context) {
 	}
 	userParsedResume, err := app.db.GetParsedResume(c.Request.Context(), pgID)
 	if err != nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>You must upload a resume before applying for jobs.</body></html>")
+		app.renderError(c, http.StatusBadRequest, "You must upload a resume before applying for jobs.")
 		return
 	}
 	if userParsedResume == nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>You must upload a resume before applying for jobs.OR error with resume Please upload again</body></html>")
+		app.renderError(c, http.StatusBadRequest, "You must upload a resume before applying for jobs.OR error with resume Please upload again")
 		return
 	}
 
 	jobIDStr := c.Param("jobID")
 	jobUUID, err := uuid.Parse(jobIDStr)
 	if err != nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Invalid Job ID format</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Invalid Job ID format")
 		return
 	}
 	jobPgID := pgtype.UUID{Bytes: jobUUID, Valid: true}
 
-	_, err = app.db.GetJobPostingByID(c.Request.Context(), jobPgID)
+	job, err := app.db.GetJobPostingByID(c.Request.Context(), jobPgID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.String(http.StatusNotFound, "Job not found.")
@@ -193,36 +198,139 @@ func (app *App) postApplyHandler(c *gin.Context) {
 		}
 		return
 	}
+	if job.Status != JobStatusPublished {
+		app.renderError(c, http.StatusBadRequest, "This job posting is not accepting applications.")
+		return
+	}
+
+	questions, err := app.db.ListScreeningQuestionsForJob(c.Request.Context(), jobPgID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Apply POST: DB error fetching screening questions for job %s: %v\n", jobIDStr, err)
+		c.String(http.StatusInternalServerError, "Error verifying job.")
+		return
+	}
+
+	answers := make([]string, len(questions))
+	var knockoutPrompt string
+	for i, question := range questions {
+		answer, err := normalizeScreeningAnswer(question, c.PostForm("q_"+uuid.UUID(question.ID.Bytes).String()))
+		if err != nil {
+			app.renderErrorBack(c, http.StatusBadRequest, err.Error()+".", "/jobs/"+jobIDStr+"/apply", "Go back")
+			return
+		}
+		answers[i] = answer
+		if knockoutPrompt == "" && isKnockedOut(question, answer) {
+			knockoutPrompt = question.Prompt
+		}
+	}
+
+	coverLetter := strings.TrimSpace(c.PostForm("cover_letter"))
+	var files []applicationFile
+	if form, err := c.MultipartForm(); err == nil {
+		if coverLetterFiles := form.File["cover_letter_file"]; len(coverLetterFiles) > 0 {
+			file, errMsg := readUploadedFile(coverLetterFiles[0])
+			if errMsg != "" {
+				app.renderErrorBack(c, http.StatusBadRequest, "Error: "+errMsg, "/jobs/"+jobIDStr+"/apply", "Go back")
+				return
+			}
+			files = append(files, applicationFile{Kind: AttachmentKindCoverLetter, uploadedFile: file})
+		}
+
+		attachmentFiles := form.File["attachments"]
+		if len(attachmentFiles) > maxAttachmentsPerApplication {
+			app.renderErrorBack(c, http.StatusBadRequest, fmt.Sprintf("Error: Too many attachments (maximum %d).", maxAttachmentsPerApplication), "/jobs/"+jobIDStr+"/apply", "Go back")
+			return
+		}
+		for _, fileHeader := range attachmentFiles {
+			file, errMsg := readUploadedFile(fileHeader)
+			if errMsg != "" {
+				app.renderErrorBack(c, http.StatusBadRequest, "Error: "+errMsg, "/jobs/"+jobIDStr+"/apply", "Go back")
+				return
+			}
+			files = append(files, applicationFile{Kind: AttachmentKindAttachment, uploadedFile: file})
+		}
+	}
 
 	params := db.CreateApplicationParams{
 		UserID:       pgID,
 		JobPostingID: jobPgID,
+		CoverLetter:  pgtype.Text{String: coverLetter, Valid: coverLetter != ""},
+		Source:       applicationSource(c.PostForm("source"), ApplicationSourceDirect),
 	}
 
-	_, err = app.db.CreateApplication(c.Request.Context(), params)
+	application, err := app.db.CreateApplication(c.Request.Context(), params)
 	if err != nil {
-		pgErr := err.(*pgconn.PgError)
-		if pgErr.Code == "23505" {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			fmt.Printf("Apply POST: User %s already applied for job %s (unique violation detected via errors.As)\n", pgID.String(), jobPgID.String())
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusConflict, "<html><body>You have already applied for this job. <a href='/applicant/dashboard'>View Applications</a></body></html>")
+			app.renderErrorBack(c, http.StatusConflict, "You have already applied for this job.", "/applicant/dashboard", "View Applications")
 			return
+		}
+		fmt.Printf("Apply POST: Error creating application for user %s to job %s: %v\n", pgID.String(), jobPgID.String(), err)
+		app.renderError(c, http.StatusInternalServerError, "Failed to submit your application. Please try again.")
+		return
+	}
 
-		} else {
-			fmt.Printf("Apply POST: Error creating application for user %s to job %s: %v\n", pgID.String(), jobPgID.String(), err)
-			c.Header("Content-Type", "text/html; charset=utf-8")
+	fmt.Printf("Successfully created application for user %s to job %s\n", user.Name, jobPgID.String())
+
+	err = app.db.CreateApplicationStatusHistory(c.Request.Context(), db.CreateApplicationStatusHistoryParams{
+		ApplicationID: application.ID,
+		Status:        ApplicationStatusSubmitted,
+		ChangedBy:     pgID,
+	})
+	if err != nil {
+		fmt.Printf("Apply POST: Failed to record status history for application %s: %v\n", application.ID.String(), err)
+	}
+	if err := app.attachResumeSnapshot(c.Request.Context(), application.ID, pgID, jobPgID); err != nil {
+		fmt.Printf("Apply POST: Failed to store resume version for application %s: %v\n", application.ID.String(), err)
+	}
+
+	for _, file := range files {
+		_, err := app.db.CreateApplicationAttachment(c.Request.Context(), db.CreateApplicationAttachmentParams{
+			ApplicationID: application.ID,
+			Kind:          file.Kind,
+			FileName:      file.Name,
+			ContentType:   file.ContentType,
+			Data:          file.Data,
+		})
+		if err != nil {
+			fmt.Printf("Apply POST: Failed to store attachment %s for application %s: %v\n", file.Name, application.ID.String(), err)
 		}
+	}
 
-		fmt.Printf("Successfully created application for user %s to job %s\n", user.Name, jobPgID.String())
+	for i, question := range questions {
+		if answers[i] == "" {
+			continue
+		}
+		err := app.db.CreateScreeningAnswer(c.Request.Context(), db.CreateScreeningAnswerParams{
+			ApplicationID: application.ID,
+			QuestionID:    question.ID,
+			Answer:        answers[i],
+		})
+		if err != nil {
+			fmt.Printf("Apply POST: Failed to store screening answer for application %s: %v\n", application.ID.String(), err)
+		}
+	}
 
-		if saveErr := session.Save(); saveErr != nil {
-			fmt.Printf("Apply POST: Error saving session before redirect: %v\n", saveErr)
-		} else {
-			fmt.Println("Apply POST: Session saved explicitly before redirect.")
+	app.publishApplicationEvent(c.Request.Context(), EventApplicationCreated, application.ID)
+
+	if knockoutPrompt != "" {
+		created, err := app.db.GetApplicationByID(c.Request.Context(), application.ID)
+		if err == nil {
+			err = app.changeApplicationStatus(c.Request.Context(), created, ApplicationStatusRejected, pgtype.UUID{}, "Automatically rejected by knockout question: "+knockoutPrompt)
+		}
+		if err != nil {
+			fmt.Printf("Apply POST: Failed to apply knockout rejection to application %s: %v\n", application.ID.String(), err)
 		}
+	}
 
-		c.Redirect(http.StatusTemporaryRedirect, "/applicant/dashboard")
+	if saveErr := session.Save(); saveErr != nil {
+		fmt.Printf("Apply POST: Error saving session before redirect: %v\n", saveErr)
+	} else {
+		fmt.Println("Apply POST: Session saved explicitly before redirect.")
 	}
+
+	c.Redirect(http.StatusSeeOther, "/applicant/dashboard")
 }
 
 func (app *App) requestInterviewHandler(c *gin.Context) {
@@ -250,8 +358,7 @@ func (app *App) requestInterviewHandler(c *gin.Context) {
 		return
 	}
 	if recruiterUser.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Access denied</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Access denied")
 		c.Abort()
 		return
 	}
@@ -297,59 +404,422 @@ func (app *App) requestInterviewHandler(c *gin.Context) {
 		return
 	}
 
-	currentStatus := application.Status
-	if currentStatus != "submitted" {
-		fmt.Printf("Request Interview POST: Cannot request interview for application %s with status '%s'\n", applicationIDStr, currentStatus)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, fmt.Sprintf("<html><body>Cannot request interview for application with status '%s'. <a href='/recruiter/jobs/%s/applications'>Back</a></body></html>", html.EscapeString(currentStatus), jobIDStr))
+	newStatus := ApplicationStatusAccepted
+	err = app.changeApplicationStatus(c.Request.Context(), application, newStatus, recruiterPgID, "Interview requested")
+	if errors.Is(err, errInvalidStatusTransition) {
+		fmt.Printf("Request Interview POST: Cannot request interview for application %s with status '%s'\n", applicationIDStr, application.Status)
+		app.renderErrorBack(c, http.StatusBadRequest, fmt.Sprintf("Cannot request interview for application with status '%s'.", application.Status), "/recruiter/jobs/"+jobIDStr+"/applications", "Back")
 		return
-	}
-
-	newStatus := "accepted"
-	params := db.UpdateApplicationStatusParams{
-		ID:     appPgID,
-		Status: newStatus,
-	}
-	err = app.db.UpdateApplicationStatus(c.Request.Context(), params)
-	if err != nil {
+	} else if err != nil {
 		fmt.Printf("Request Interview POST: DB error updating status for app %s: %v\n", applicationIDStr, err)
 		c.String(http.StatusInternalServerError, "Failed to update application status.")
 		return
 	}
 	fmt.Printf("Application %s status updated to '%s' by recruiter %s\n", applicationIDStr, newStatus, recruiterPgID.String())
 
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	smtpPassword := os.Getenv("SMTP_PASSWORD")
-	smtpFrom := os.Getenv("SMTP_FROM_EMAIL")
+	details := strings.TrimSpace(c.PostForm("details"))
+	err = app.db.UpsertInterview(c.Request.Context(), db.UpsertInterviewParams{
+		ApplicationID:    appPgID,
+		RequestingUserID: recruiterPgID,
+		ProposedDetails:  pgtype.Text{String: details, Valid: details != ""},
+	})
+	if err != nil {
+		fmt.Printf("Request Interview POST: DB error saving interview for app %s: %v\n", applicationIDStr, err)
+	}
+	app.publishApplicationEvent(c.Request.Context(), EventInterviewUpdated, appPgID)
+
 	applicantEmail := application.ApplicantEmail
-	if smtpHost == "" || smtpPort == "" || smtpUser == "" || smtpPassword == "" || smtpFrom == "" || applicantEmail == "" {
+	subject := "Interview Request for Job Application"
+	body := fmt.Sprintln("Hello,\n\nYou have been invited for an interview for a job you applied for.\nPlease log in to your dashboard to view details.\n\nRegards,\nRecruitment Team")
+	err = sendEmail(applicantEmail, subject, body)
+	if errors.Is(err, errSMTPNotConfigured) || applicantEmail == "" {
 		fmt.Printf("Request Interview POST: Skipping email notification for app %s due to missing SMTP config or applicant email.\n", applicationIDStr)
 		c.String(http.StatusInternalServerError, "SMTP configuration or applicant email is missing.")
 		return
+	} else if err != nil {
+		fmt.Printf("Request Interview POST: FAILED to send email notification to %s for app %s: %v\n", applicantEmail, applicationIDStr, err)
 	} else {
+		fmt.Printf("Request Interview POST: Successfully sent email notification to %s for app %s\n", applicantEmail, applicationIDStr)
+	}
+
+	redirectURL := fmt.Sprintf("/recruiter/jobs/%s/applications", jobIDStr)
+	c.Redirect(http.StatusSeeOther, redirectURL)
+}
+
+// attachResumeSnapshot copies the applicant's current resume into the resumes
+// table and points the application at it, so later uploads don't change the
+// version the recruiter sees.
+func (app *App) attachResumeSnapshot(ctx context.Context, applicationID, userID, jobPostingID pgtype.UUID) error {
+	resumeID, err := app.db.CreateResumeSnapshot(ctx, db.CreateResumeSnapshotParams{
+		JobPostingID: jobPostingID,
+		UserID:       userID,
+	})
+	if err != nil {
+		return err
+	}
+	return app.db.SetApplicationResume(ctx, db.SetApplicationResumeParams{
+		ID:       applicationID,
+		ResumeID: resumeID,
+	})
+}
+
+// loadOwnApplication loads the :applicationID application for the logged-in
+// applicant, writing an error response and returning false otherwise.
+func (app *App) loadOwnApplication(c *gin.Context) (db.GetApplicationByIDRow, pgtype.UUID, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return db.GetApplicationByIDRow{}, pgtype.UUID{}, false
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return db.GetApplicationByIDRow{}, pgtype.UUID{}, false
+	}
+
+	user, err := app.db.GetUser(c.Request.Context(), pgID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Internal Server Error: %v", err)
+		c.Abort()
+		return db.GetApplicationByIDRow{}, pgtype.UUID{}, false
+	}
+	if user.Role != RoleApplicant {
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only applicants can manage their applications")
+		c.Abort()
+		return db.GetApplicationByIDRow{}, pgtype.UUID{}, false
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, pgID)
+	if !ok {
+		return db.GetApplicationByIDRow{}, pgtype.UUID{}, false
+	}
+	if application.UserID.Bytes != pgID.Bytes {
+		app.renderError(c, http.StatusForbidden, "Forbidden: This is not your application")
+		return db.GetApplicationByIDRow{}, pgtype.UUID{}, false
+	}
+
+	return application, pgID, true
+}
 
-		subject := "Interview Request for Job Application"
-		body := fmt.Sprintln("Hello,\n\nYou have been invited for an interview for a job you applied for.\nPlease log in to your dashboard to view details.\n\nRegards,\nRecruitment Team")
-		msg := []byte("To: " + applicantEmail + "\r\n" +
-			"From: " + smtpFrom + "\r\n" +
-			"Subject: " + subject + "\r\n" +
-			"\r\n" +
-			body + "\r\n")
+func (app *App) getApplicantApplicationHandler(c *gin.Context) {
+	application, _, ok := app.loadOwnApplication(c)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
 
-		auth := smtp.PlainAuth("", smtpUser, smtpPassword, smtpHost)
+	job, err := app.db.GetJobPostingByID(c.Request.Context(), application.JobPostingID)
+	if err != nil {
+		fmt.Printf("Application Detail: DB error fetching job for application %s: %v\n", applicationIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Error fetching job data")
+		return
+	}
+	salaryMinVal, err := job.SalaryMin.Value()
+	if err != nil || salaryMinVal == nil {
+		salaryMinVal = ""
+	}
+	salaryMaxVal, err := job.SalaryMax.Value()
+	if err != nil || salaryMaxVal == nil {
+		salaryMaxVal = ""
+	}
 
-		smtpAddr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
-		err := smtp.SendMail(smtpAddr, auth, smtpFrom, []string{applicantEmail}, msg)
+	resume := submittedResume{}
+	if application.ResumeID.Valid {
+		stored, err := app.db.GetResumeByID(c.Request.Context(), application.ResumeID)
 		if err != nil {
+			fmt.Printf("Application Detail: DB error fetching resume for application %s: %v\n", applicationIDStr, err)
+			resume.Error = true
+		} else {
+			var prettyJSON bytes.Buffer
+			if err := json.Indent(&prettyJSON, stored.ParsedResume, "", "    "); err != nil {
+				prettyJSON.Reset()
+			}
+			resume.Attached = true
+			resume.Parsed = prettyJSON.String()
+		}
+	}
+
+	app.render(c, http.StatusOK, "applicant_application.html", "Application: "+job.Title, gin.H{
+		"Job":         job,
+		"Application": application,
+		"SalaryMin":   salaryMinVal,
+		"SalaryMax":   salaryMaxVal,
+		"History":     app.statusHistory(c.Request.Context(), application),
+		"Interview":   app.applicationInterview(c.Request.Context(), application),
+		"Offer":       app.applicantOffer(c.Request.Context(), application),
+		"Materials":   app.applicationMaterials(c.Request.Context(), application),
+		"Resume":      resume,
+		"CanReplace":  application.Status == ApplicationStatusSubmitted,
+		"CanWithdraw": canTransitionApplication(application.Status, ApplicationStatusWithdrawn),
+		"CSRF":        csrfToken(c),
+	})
+}
+
+// submittedResume is the resume snapshot taken when the application was
+// submitted.
+type submittedResume struct {
+	Error    bool
+	Attached bool
+	Parsed   string
+}
+
+func (app *App) postWithdrawApplicationHandler(c *gin.Context) {
+	application, pgID, ok := app.loadOwnApplication(c)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+	reason := strings.TrimSpace(c.PostForm("reason"))
+
+	err := app.changeApplicationStatus(c.Request.Context(), application, ApplicationStatusWithdrawn, pgID, reason)
+	if errors.Is(err, errInvalidStatusTransition) {
+		app.renderErrorBack(c, http.StatusBadRequest, fmt.Sprintf("Cannot withdraw an application with status '%s'.", application.Status), "/applicant/applications/"+applicationIDStr, "Back")
+		return
+	} else if err != nil {
+		fmt.Printf("Withdraw Application POST: DB error updating status for app %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to withdraw application.")
+		return
+	}
+
+	if err := app.db.UpdateInterviewStatusByApplication(c.Request.Context(), db.UpdateInterviewStatusByApplicationParams{
+		ApplicationID: application.ID,
+		Status:        "cancelled",
+	}); err != nil {
+		fmt.Printf("Withdraw Application POST: Failed to cancel interview for app %s: %v\n", applicationIDStr, err)
+	}
+
+	fmt.Printf("Application %s withdrawn by applicant %s\n", applicationIDStr, pgID.String())
+
+	recruiter, err := app.db.GetUser(c.Request.Context(), application.RecruiterID)
+	if err != nil {
+		fmt.Printf("Withdraw Application POST: Failed to load recruiter for app %s: %v\n", applicationIDStr, err)
+	} else {
+		body := fmt.Sprintf("Hello,\n\n%s has withdrawn their application for %s.\n", application.ApplicantName, application.JobTitle)
+		if reason != "" {
+			body += "\nReason given: " + reason + "\n"
+		}
+		body += "\nRegards,\nRecruitment Team"
+		if err := sendEmail(recruiter.Email, "Application withdrawn: "+application.JobTitle, body); err != nil {
+			fmt.Printf("Withdraw Application POST: Skipping email notification for app %s: %v\n", applicationIDStr, err)
+		}
+	}
+
+	c.Redirect(http.StatusSeeOther, "/applicant/applications/"+applicationIDStr)
+}
+
+func (app *App) postRefreshApplicationResumeHandler(c *gin.Context) {
+	application, pgID, ok := app.loadOwnApplication(c)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+
+	if application.Status != ApplicationStatusSubmitted {
+		app.renderError(c, http.StatusBadRequest, "The resume can only be replaced while the application is still submitted.")
+		return
+	}
+
+	if err := app.attachResumeSnapshot(c.Request.Context(), application.ID, pgID, application.JobPostingID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.renderErrorBack(c, http.StatusBadRequest, "You have no resume uploaded.", "/applicant/resume", "Upload one")
+			return
+		}
+		fmt.Printf("Refresh Application Resume POST: DB error for app %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to update the application's resume.")
+		return
+	}
+
+	fmt.Printf("Application %s resume replaced by applicant %s\n", applicationIDStr, pgID.String())
+	c.Redirect(http.StatusSeeOther, "/applicant/applications/"+applicationIDStr)
+}
+
+// getApplicationResumeHandler serves the resume version submitted with the
+// application to either of its participants.
+func (app *App) getApplicationResumeHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, pgID)
+	if !ok {
+		return
+	}
+	if !application.ResumeID.Valid {
+		c.String(http.StatusNotFound, "No resume was submitted with this application.")
+		return
+	}
+
+	resume, err := app.db.GetResumeByID(c.Request.Context(), application.ResumeID)
+	if err != nil {
+		fmt.Printf("Application Resume GET: DB error fetching resume for application %s: %v\n", application.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Error fetching resume.")
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="resume.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", resume.ResumePdf)
+}
+
+// applicationHistory is the application's status changes, oldest first.
+type applicationHistory struct {
+	Error   bool
+	Entries []db.ListApplicationStatusHistoryRow
+}
+
+func (app *App) statusHistory(ctx context.Context, application db.GetApplicationByIDRow) applicationHistory {
+	history, err := app.db.ListApplicationStatusHistory(ctx, application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Application Detail: DB error fetching history for application %s: %v\n", application.ID.String(), err)
+		return applicationHistory{Error: true}
+	}
+	return applicationHistory{Entries: history}
+}
+
+// applicationInterview is the interview requested for an application, if any.
+type applicationInterview struct {
+	Error     bool
+	Found     bool
+	Interview db.GetInterviewByApplicationIDRow
+}
+
+func (app *App) applicationInterview(ctx context.Context, application db.GetApplicationByIDRow) applicationInterview {
+	interview, err := app.db.GetInterviewByApplicationID(ctx, application.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return applicationInterview{}
+	} else if err != nil {
+		fmt.Printf("Application Detail: DB error fetching interview for application %s: %v\n", application.ID.String(), err)
+		return applicationInterview{Error: true}
+	}
+	return applicationInterview{Found: true, Interview: interview}
+}
+
+// attachmentLink is a downloadable file listed with its size.
+type attachmentLink struct {
+	ID       pgtype.UUID
+	FileName string
+	SizeKB   int32
+}
 
-			fmt.Printf("Request Interview POST: FAILED to send email notification to %s for app %s: %v\n", applicantEmail, applicationIDStr, err)
+// applicationMaterials is the cover letter and attachments submitted with the
+// application.
+type applicationMaterials struct {
+	ApplicationID    pgtype.UUID
+	CoverLetter      string
+	CoverLetterFiles []attachmentLink
+	OtherFiles       []attachmentLink
+}
+
+func (app *App) applicationMaterials(ctx context.Context, application db.GetApplicationByIDRow) applicationMaterials {
+	attachments, err := app.db.ListApplicationAttachments(ctx, application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Application Detail: DB error fetching attachments for application %s: %v\n", application.ID.String(), err)
+	}
+
+	materials := applicationMaterials{ApplicationID: application.ID, CoverLetter: application.CoverLetter.String}
+	for _, attachment := range attachments {
+		link := attachmentLink{ID: attachment.ID, FileName: attachment.FileName, SizeKB: (attachment.SizeBytes + 1023) / 1024}
+		if attachment.Kind == AttachmentKindCoverLetter {
+			materials.CoverLetterFiles = append(materials.CoverLetterFiles, link)
 		} else {
-			fmt.Printf("Request Interview POST: Successfully sent email notification to %s for app %s\n", applicantEmail, applicationIDStr)
+			materials.OtherFiles = append(materials.OtherFiles, link)
 		}
 	}
+	return materials
+}
 
-	redirectURL := fmt.Sprintf("/recruiter/jobs/%s/applications", jobIDStr)
-	c.Redirect(http.StatusSeeOther, redirectURL)
+func (app *App) getApplicationAttachmentHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, pgID)
+	if !ok {
+		return
+	}
+
+	attachmentUUID, err := uuid.Parse(c.Param("attachmentID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid attachment ID.")
+		return
+	}
+
+	attachment, err := app.db.GetApplicationAttachment(c.Request.Context(), pgtype.UUID{Bytes: attachmentUUID, Valid: true})
+	if err != nil || attachment.ApplicationID.Bytes != application.ID.Bytes {
+		c.String(http.StatusNotFound, "Attachment not found.")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.FileName))
+	c.Data(http.StatusOK, attachment.ContentType, attachment.Data)
+}
+
+// loadJobApplication loads the :applicationID application of the recruiter's
+// :jobID job posting, writing an error response and returning false otherwise.
+func (app *App) loadJobApplication(c *gin.Context, recruiterID pgtype.UUID) (db.GetJobPostingByIDRow, db.GetApplicationByIDRow, bool) {
+	job, ok := app.loadOwnedJob(c, recruiterID)
+	if !ok {
+		return db.GetJobPostingByIDRow{}, db.GetApplicationByIDRow{}, false
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, recruiterID)
+	if !ok {
+		return db.GetJobPostingByIDRow{}, db.GetApplicationByIDRow{}, false
+	}
+	if application.JobPostingID.Bytes != job.ID.Bytes {
+		c.String(http.StatusBadRequest, "Application/Job mismatch.")
+		return db.GetJobPostingByIDRow{}, db.GetApplicationByIDRow{}, false
+	}
+
+	return job, application, true
+}
+
+func (app *App) getRecruiterApplicationHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, application, ok := app.loadJobApplication(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+
+	answers, err := app.db.ListScreeningAnswersForApplication(c.Request.Context(), application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Recruiter Application Detail: DB error fetching answers for application %s: %v\n", applicationIDStr, err)
+	}
+
+	app.render(c, http.StatusOK, "recruiter_application.html", "Application: "+application.ApplicantName, gin.H{
+		"Job":           job,
+		"Application":   application,
+		"CanInterview":  canTransitionApplication(application.Status, ApplicationStatusAccepted),
+		"CanReject":     canTransitionApplication(application.Status, ApplicationStatusRejected),
+		"EvaluationURL": evaluationURL(job.ID, application.ID),
+		"OfferURL":      offerURL(job.ID, application.ID),
+		"Materials":     app.applicationMaterials(c.Request.Context(), application),
+		"Answers":       answers,
+		"Interview":     app.applicationInterview(c.Request.Context(), application),
+		"History":       app.statusHistory(c.Request.Context(), application),
+		"CSRF":          csrfToken(c),
+	})
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ApplicationStatusSubmitted = "submitted"
+	ApplicationStatusScreening = "screening"
+	ApplicationStatusAccepted  = "accepted" // interview requested
+	ApplicationStatusOffered   = "offered"
+	ApplicationStatusHired     = "hired"
+	ApplicationStatusDeclined  = "declined" // applicant declined the offer
+	ApplicationStatusRejected  = "rejected"
+	ApplicationStatusWithdrawn = "withdrawn"
+)
+
+// pipelineStages is the order of the columns on the recruiter pipeline board.
+var pipelineStages = []string{
+	ApplicationStatusSubmitted,
+	ApplicationStatusScreening,
+	ApplicationStatusAccepted,
+	ApplicationStatusOffered,
+	ApplicationStatusHired,
+	ApplicationStatusDeclined,
+	ApplicationStatusRejected,
+	ApplicationStatusWithdrawn,
+}
+
+var applicationStatusLabels = map[string]string{
+	ApplicationStatusSubmitted: "Applied",
+	ApplicationStatusScreening: "Screening",
+	ApplicationStatusAccepted:  "Interview",
+	ApplicationStatusOffered:   "Offer",
+	ApplicationStatusHired:     "Hired",
+	ApplicationStatusDeclined:  "Offer Declined",
+	ApplicationStatusRejected:  "Rejected",
+	ApplicationStatusWithdrawn: "Withdrawn",
+}
+
+var errInvalidStatusTransition = errors.New("invalid application status transition")
+
+// applicationTransitions lists the statuses an application may move to from
+// each status. Hired, declined, rejected and withdrawn applications are final.
+var applicationTransitions = map[string][]string{
+	ApplicationStatusSubmitted: {ApplicationStatusScreening, ApplicationStatusAccepted, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusScreening: {ApplicationStatusAccepted, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusAccepted:  {ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusOffered:   {ApplicationStatusHired, ApplicationStatusDeclined, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+}
+
+// offerStatuses are only reached through the offer workflow, never by moving
+// an application directly.
+var offerStatuses = map[string]bool{
+	ApplicationStatusOffered:  true,
+	ApplicationStatusHired:    true,
+	ApplicationStatusDeclined: true,
+}
+
+func canTransitionApplication(from, to string) bool {
+	for _, next := range applicationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// changeApplicationStatus moves the application to newStatus if the status
+// rules allow it and nobody changed it meanwhile, records the change in the
+// status history and notifies the dashboards. changedBy may be invalid for
+// changes made by the system.
+func (app *App) changeApplicationStatus(ctx context.Context, application db.GetApplicationByIDRow, newStatus string, changedBy pgtype.UUID, reason string) error {
+	if !canTransitionApplication(application.Status, newStatus) {
+		return fmt.Errorf("%w: %s to %s", errInvalidStatusTransition, application.Status, newStatus)
+	}
+
+	updated, err := app.db.UpdateApplicationStatus(ctx, db.UpdateApplicationStatusParams{
+		ID:         application.ID,
+		Status:     newStatus,
+		FromStatus: application.Status,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: %s changed before it could move to %s", errInvalidStatusTransition, application.Status, newStatus)
+	}
+
+	err = app.db.CreateApplicationStatusHistory(ctx, db.CreateApplicationStatusHistoryParams{
+		ApplicationID: application.ID,
+		Status:        newStatus,
+		ChangedBy:     changedBy,
+		Reason:        pgtype.Text{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		fmt.Printf("Application Status: Failed to record history for application %s: %v\n", application.ID.String(), err)
+	}
+
+	app.publishApplicationEvent(ctx, EventApplicationStatusChanged, application.ID)
+	return nil
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -35,6 +37,13 @@ func (app *App) authMiddleware(c *gin.Context) {
 	userID, ok := rawuserID.(pgtype.UUID)
 	if rawuserID == nil || !ok || !userID.Valid {
 		fmt.Println("Auth Middleware: No valid user ID found in session.")
+		if c.Request.Method == http.MethodGet && strings.Contains(c.GetHeader("Accept"), "text/html") {
+			// Bring the user back to this page once they have logged in.
+			session.Set(sessionReturnToKey, c.Request.URL.RequestURI())
+			if err := session.Save(); err != nil {
+				fmt.Printf("Auth Middleware: Failed to save return path: %v\n", err)
+			}
+		}
 		c.Redirect(http.StatusTemporaryRedirect, "/")
 		c.Abort()
 		return
@@ -44,9 +53,21 @@ func (app *App) authMiddleware(c *gin.Context) {
 	c.Next()
 }
 
+// authProviderParam returns the :provider route parameter if it names a
+// configured provider, and responds 404 otherwise.
+func authProviderParam(c *gin.Context) (string, bool) {
+	provider := c.Param("provider")
+	if _, err := goth.GetProvider(provider); err != nil {
+		c.String(http.StatusNotFound, "Unknown login provider.")
+		c.Abort()
+		return "", false
+	}
+	return provider, true
+}
+
 func (app *App) authProviderHandler(c *gin.Context) {
 	session := sessions.Default(c)
-	if session.Get(sessionUserKey) != nil {
+	if session.Get(sessionUserKey) != nil && session.Get(sessionLinkIdentityKey) == nil {
 		c.Redirect(http.StatusTemporaryRedirect, "/profile")
 		return
 	}
@@ -54,67 +75,172 @@ func (app *App) authProviderHandler(c *gin.Context) {
 		c.Redirect(http.StatusTemporaryRedirect, "/auth/choose-role")
 		return
 	}
-	cp := gothic.GetContextWithProvider(c.Request, "google")
+	provider, ok := authProviderParam(c)
+	if !ok {
+		return
+	}
+	cp := gothic.GetContextWithProvider(c.Request, provider)
 	gothic.BeginAuthHandler(c.Writer, cp)
 }
 
 func (app *App) authCallbackHandler(c *gin.Context) {
 	session := sessions.Default(c)
-	if session.Get(sessionUserKey) != nil {
+	currentUserID, loggedIn := session.Get(sessionUserKey).(pgtype.UUID)
+	linking := session.Get(sessionLinkIdentityKey) != nil
+	if loggedIn && !linking {
 		c.Redirect(http.StatusTemporaryRedirect, "/profile")
 		return
 	}
-	cp := gothic.GetContextWithProvider(c.Request, "google")
+	provider, ok := authProviderParam(c)
+	if !ok {
+		return
+	}
+	cp := gothic.GetContextWithProvider(c.Request, provider)
 	// Complete the authe
 	gothUser, err := gothic.CompleteUserAuth(c.Writer, cp)
 	if err != nil {
 		fmt.Printf("Callback Error: Failed to complete auth: %v\n", err)
-		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"message": "Authentication failed: " + err.Error()})
+		app.renderError(c, http.StatusInternalServerError, "Authentication failed: "+err.Error())
 		c.Abort()
 		return
 	}
 
 	fmt.Printf("Goth User Info received: %+v\n", gothUser)
+	if gothUser.Email == "" {
+		app.renderError(c, http.StatusBadRequest, "Your account did not share an email address. Make your email visible to this app and try again.")
+		c.Abort()
+		return
+	}
+	identity := pendingIdentity{Provider: provider, Subject: gothUser.UserID, Email: gothUser.Email}
+	ctx := c.Request.Context()
+
+	// Linking a provider from the sign-in methods page
+	if linking {
+		session.Delete(sessionLinkIdentityKey)
+		if saveErr := session.Save(); saveErr != nil {
+			fmt.Printf("Callback Error: Failed to save session: %v\n", saveErr)
+		}
+		if !loggedIn {
+			c.Redirect(http.StatusSeeOther, "/auth/login")
+			return
+		}
+		app.linkIdentity(c, currentUserID, identity)
+		return
+	}
 
 	// Check if user in our database
-	dbUser, err := app.db.GetUserByGoogleID(c.Request.Context(), gothUser.UserID)
+	userID, err := app.findUserForIdentity(ctx, identity)
+	if err == nil {
+		fmt.Printf("User %s found in DB (ID: %s). Logging in.\n", gothUser.Email, userID.String())
+		app.completeLogin(c, userID)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Callback Error: Database error checking user: %v\n", err)
+		app.renderError(c, http.StatusInternalServerError, "Database error during login.")
+		c.Abort()
+		return
+	}
 
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			fmt.Printf("User with Google ID %s not found. Redirecting to role selection.\n", gothUser.UserID)
-
-			// Store temporary Goth user info in session
-			session.Set(sessionTempGothUserKey, gothUser)
-			if saveErr := session.Save(); saveErr != nil {
-				fmt.Printf("Callback Error: Failed to save temporary session: %v\n", saveErr)
-				c.HTML(http.StatusInternalServerError, "error.html", gin.H{"message": "Failed to save session state."})
-				c.Abort()
-				return
+	// A recruiter may have imported this person already; let them take
+	// over that record instead of registering a second account. The email
+	// is all that ties them to it, so the provider must have verified it;
+	// otherwise they are asked to link the account below.
+	if providerEmailVerified(gothUser) {
+		claimed, claimErr := app.db.ClaimImportedUser(ctx, db.ClaimImportedUserParams{
+			GoogleID: providerSubject(provider, gothUser.UserID),
+			Email:    gothUser.Email,
+		})
+		if claimErr == nil {
+			fmt.Printf("Imported user %s (ID: %s) signed in for the first time.\n", claimed.Email, claimed.ID.String())
+			if err := app.createIdentity(ctx, claimed.ID, identity); err != nil {
+				fmt.Printf("Callback Error: Failed to record identity for imported user %s: %v\n", claimed.ID.String(), err)
 			}
-			c.Redirect(http.StatusTemporaryRedirect, "/auth/choose-role")
+			app.completeLogin(c, claimed.ID)
 			return
+		}
+	}
 
-		} else {
-			fmt.Printf("Callback Error: Database error checking user: %v\n", err)
-			c.HTML(http.StatusInternalServerError, "error.html", gin.H{"message": "Database error during login."})
+	// Someone already has an account with this email. Linking on the email
+	// alone would let anyone who controls a provider account with that
+	// address take the account over, so they must sign in to it first.
+	if _, err := app.db.GetUserByEmail(ctx, gothUser.Email); err == nil {
+		fmt.Printf("%s login for %s matches an existing account. Asking the user to link it.\n", provider, gothUser.Email)
+		session.Set(sessionPendingIdentityKey, identity)
+		if saveErr := session.Save(); saveErr != nil {
+			fmt.Printf("Callback Error: Failed to save pending identity: %v\n", saveErr)
+			app.renderError(c, http.StatusInternalServerError, "Failed to save session state.")
 			c.Abort()
 			return
 		}
+		c.Redirect(http.StatusSeeOther, "/auth/link-account")
+		return
 	}
 
-	// User Exists  Log them in
-	fmt.Printf("User %s found in DB (ID: %s, Role: %s). Logging in.\n", dbUser.Email, dbUser.ID.String(), dbUser.Role)
-	session.Set(sessionUserKey, dbUser.ID) // Store DB User ID
-	session.Delete(sessionTempGothUserKey) // Clean up temporary data just in case
+	fmt.Printf("User with %s ID %s not found. Redirecting to role selection.\n", provider, gothUser.UserID)
+
+	// Store temporary Goth user info in session
+	session.Set(sessionTempGothUserKey, gothUser)
 	if saveErr := session.Save(); saveErr != nil {
-		fmt.Printf("Callback Error: Failed to save session for existing user: %v\n", saveErr)
-		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"message": "Failed to save session after login."})
+		fmt.Printf("Callback Error: Failed to save temporary session: %v\n", saveErr)
+		app.renderError(c, http.StatusInternalServerError, "Failed to save session state.")
 		c.Abort()
 		return
 	}
+	c.Redirect(http.StatusTemporaryRedirect, "/auth/choose-role")
+}
+
+// completeLogin signs the user in, or holds the login for a second factor
+// when the account has one or must set one up. See signIn for the rest.
+func (app *App) completeLogin(c *gin.Context, userID pgtype.UUID) {
+	suspended, err := app.db.IsUserSuspended(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Printf("Login: Failed to check suspension of user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to log in.")
+		return
+	}
+	if suspended {
+		fmt.Printf("Login: Refused suspended user %s.\n", userID.String())
+		c.Redirect(http.StatusSeeOther, "/auth/login?error="+url.QueryEscape("This account has been suspended."))
+		return
+	}
+
+	twoFactor, err := app.db.GetUserTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Printf("Login: Failed to check two-factor status of user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to log in.")
+		return
+	}
+	if twoFactorRole(twoFactor.Role) && (twoFactor.TotpEnabledAt.Valid || app.requireTwoFactor) {
+		app.startTwoFactor(c, userID, !twoFactor.TotpEnabledAt.Valid)
+		return
+	}
 
-	// Redirect existing user to their profile
-	c.Redirect(http.StatusTemporaryRedirect, "/profile")
+	if returnTo, ok := app.signIn(c, userID); ok {
+		c.Redirect(http.StatusSeeOther, returnTo)
+	}
+}
+
+// signIn sets the session's user, links any provider login that was waiting
+// for them to prove they own the account, and returns where they were headed
+// before logging in. When it returns false the response has been written.
+func (app *App) signIn(c *gin.Context, userID pgtype.UUID) (string, bool) {
+	session := sessions.Default(c)
+	session.Set(sessionUserKey, userID)
+	session.Delete(sessionTempGothUserKey)
+	session.Delete(sessionPendingTwoFactorKey)
+	session.Delete(sessionTOTPSetupKey)
+	// A token seen before login, possibly planted, stops working; the next
+	// page with a form gets a new one.
+	session.Delete(sessionCSRFKey)
+	app.attachPendingIdentity(c, session, userID)
+	returnTo := popReturnTo(session, "/profile")
+	if err := session.Save(); err != nil {
+		fmt.Printf("Login: Failed to save session for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save session after login.")
+		return "", false
+	}
+	return returnTo, true
 }
 
 func (app *App) chooseRoleGetHandler(c *gin.Context) {
@@ -138,34 +264,16 @@ func (app *App) chooseRoleGetHandler(c *gin.Context) {
 		return
 	}
 
-	//role selection form
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, `
-        <!DOCTYPE html>
-        <html>
-        <head><title>Choose Role</title></head>
-        <body>
-            <h1>Choose Your Role</h1>
-            <p>Welcome! To complete registration, please select your role:</p>
-            <form action="/auth/choose-role" method="post">
-                <input type="radio" id="applicant" name="role" value="`+RoleApplicant+`" required>
-                <label for="applicant">Applicant</label><br>
-                <input type="radio" id="recruiter" name="role" value="`+RoleRecruiter+`">
-                <label for="recruiter">Recruiter</label><br><br>
-                <input type="submit" value="Submit Role">
-            </form>
-        </body>
-        </html>
-    `)
+	app.render(c, http.StatusOK, "choose_role.html", "Choose Role", gin.H{"CSRF": csrfToken(c)})
 }
+
 func (app *App) chooseRolePostHandler(c *gin.Context) {
 	session := sessions.Default(c)
 	tempGothUserRaw := session.Get(sessionTempGothUserKey)
 
 	if tempGothUserRaw == nil {
 		fmt.Println("Choose Role POST: No temporary Goth user found.")
-		c.HTML(http.StatusBadRequest, "error.html", gin.H{"message": "Session expired or invalid state. Please try logging in again."})
+		app.renderError(c, http.StatusBadRequest, "Session expired or invalid state. Please try logging in again.")
 		c.Abort()
 		return
 	}
@@ -175,7 +283,7 @@ func (app *App) chooseRolePostHandler(c *gin.Context) {
 		fmt.Println("Choose Role POST: Invalid temporary user data type.")
 		session.Delete(sessionTempGothUserKey)
 		session.Save()
-		c.HTML(http.StatusBadRequest, "error.html", gin.H{"message": "Invalid session state. Please try logging in again."})
+		app.renderError(c, http.StatusBadRequest, "Invalid session state. Please try logging in again.")
 		c.Abort()
 		return
 	}
@@ -191,33 +299,35 @@ func (app *App) chooseRolePostHandler(c *gin.Context) {
 
 	//parameters for the database
 	newUserParams := db.CreateUserParams{
-		GoogleID: tempGothUser.UserID,
+		GoogleID: providerSubject(tempGothUser.Provider, tempGothUser.UserID),
 		Email:    tempGothUser.Email,
 		Name:     tempGothUser.Name,
 		Role:     chosenRole,
 	}
 
-	fmt.Printf("Creating user %s (Google ID: %s) with role %s\n", newUserParams.Email, newUserParams.GoogleID, newUserParams.Role)
+	fmt.Printf("Creating user %s (provider ID: %s) with role %s\n", newUserParams.Email, newUserParams.GoogleID, newUserParams.Role)
 	newUser, err := app.db.CreateUser(c.Request.Context(), newUserParams)
 	if err != nil {
 		fmt.Printf("Choose Role POST: Failed to create user in database: %v\n", err)
-		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"message": "Failed to register user. It's possible the email or account is already registered."})
+		app.renderError(c, http.StatusInternalServerError, "Failed to register user. It's possible the email or account is already registered.")
 		c.Abort()
 		return
 	}
 
 	fmt.Printf("User created successfully with DB ID: %s\n", newUser.ID.String())
-
-	session.Delete(sessionTempGothUserKey)  // Clean up temporary data
-	session.Set(sessionUserKey, newUser.ID) // Store DB User ID
-	if saveErr := session.Save(); saveErr != nil {
-		fmt.Printf("Choose Role POST: User created (ID: %s) but failed to save final session: %v\n", newUser.ID.String(), saveErr)
-		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"message": "Account created, but failed to log you in automatically. Please try logging in again."})
-		c.Abort()
-		return
+	err = app.createIdentity(c.Request.Context(), newUser.ID, pendingIdentity{
+		Provider: tempGothUser.Provider,
+		Subject:  tempGothUser.UserID,
+		Email:    tempGothUser.Email,
+	})
+	if err != nil {
+		fmt.Printf("Choose Role POST: Failed to record identity for user %s: %v\n", newUser.ID.String(), err)
 	}
 
-	c.Redirect(http.StatusTemporaryRedirect, "/profile")
+	// Sign in the same way as any other login, so the suspension check and a
+	// required second factor apply to new accounts too.
+	session.Delete(sessionTempGothUserKey)
+	app.completeLogin(c, newUser.ID)
 }
 
 func (app *App) logoutHandler(c *gin.Context) {
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/openidConnect"
+)
+
+// authProvider is an OAuth/OIDC provider users can sign in with. Name is the
+// goth provider name used in /auth/:provider.
+type authProvider struct {
+	Name  string
+	Label string
+}
+
+// providerCallbackURL returns the OAuth callback for a provider: the
+// <PREFIX>_CALLBACK_URL variable if set, otherwise derived from PUBLIC_BASE_URL.
+func providerCallbackURL(envPrefix, name, baseURL string) string {
+	if callbackURL := os.Getenv(envPrefix + "_CALLBACK_URL"); callbackURL != "" {
+		return callbackURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/auth/" + name + "/callback"
+}
+
+// configureAuthProviders registers every provider that has credentials in the
+// environment with goth and returns them in display order.
+func configureAuthProviders(googleCallbackURL, baseURL string) []authProvider {
+	var providers []goth.Provider
+	var enabled []authProvider
+
+	if id, secret := os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"); id != "" && secret != "" {
+		providers = append(providers, google.New(id, secret, googleCallbackURL))
+		enabled = append(enabled, authProvider{Name: "google", Label: "Google"})
+	}
+	if id, secret := os.Getenv("GITHUB_CLIENT_ID"), os.Getenv("GITHUB_CLIENT_SECRET"); id != "" && secret != "" {
+		providers = append(providers, github.New(id, secret, providerCallbackURL("GITHUB", "github", baseURL), "read:user", "user:email"))
+		enabled = append(enabled, authProvider{Name: "github", Label: "GitHub"})
+	}
+	if id, secret := os.Getenv("MICROSOFT_CLIENT_ID"), os.Getenv("MICROSOFT_CLIENT_SECRET"); id != "" && secret != "" {
+		providers = append(providers, microsoftonline.New(id, secret, providerCallbackURL("MICROSOFT", "microsoftonline", baseURL)))
+		enabled = append(enabled, authProvider{Name: "microsoftonline", Label: "Microsoft"})
+	}
+	if id, secret, discoveryURL := os.Getenv("OIDC_CLIENT_ID"), os.Getenv("OIDC_CLIENT_SECRET"), os.Getenv("OIDC_DISCOVERY_URL"); id != "" && secret != "" && discoveryURL != "" {
+		// Discovery makes a network call; a broken IdP should not stop the app.
+		provider, err := openidConnect.New(id, secret, providerCallbackURL("OIDC", "openid-connect", baseURL), discoveryURL, "openid", "email", "profile")
+		if err != nil {
+			log.Printf("WARNING: OpenID Connect provider disabled: %v", err)
+		} else {
+			label := os.Getenv("OIDC_LABEL")
+			if label == "" {
+				label = "Single Sign-On"
+			}
+			providers = append(providers, provider)
+			enabled = append(enabled, authProvider{Name: provider.Name(), Label: label})
+		}
+	}
+
+	if len(enabled) == 0 {
+		log.Println("WARNING: No OAuth providers configured. Only email/password login is available.")
+	}
+	goth.UseProviders(providers...)
+	return enabled
+}
+
+// providerSubject is the value stored in users.google_id for an account from
+// the given provider. Google accounts keep the bare Google ID they have always
+// used; other providers are prefixed so their IDs cannot collide.
+func providerSubject(provider, userID string) string {
+	if provider == "google" {
+		return userID
+	}
+	return provider + ":" + userID
+}
+
+// providerEmailVerified reports whether the provider vouches that the user
+// owns their email address. Google and OpenID Connect providers say so in the
+// email_verified claim, and GitHub only shares verified addresses. Other
+// providers are not trusted to have checked.
+func providerEmailVerified(gothUser goth.User) bool {
+	if gothUser.Provider == "github" {
+		return gothUser.Email != ""
+	}
+	for _, claim := range []string{"email_verified", "verified_email"} {
+		switch verified := gothUser.RawData[claim].(type) {
+		case bool:
+			if verified {
+				return true
+			}
+		case string:
+			if verified == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
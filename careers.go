@@ -0,0 +1,243 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// newJobSlug builds a URL slug from the job title, with a random suffix so
+// postings sharing a title get distinct URLs.
+func newJobSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	base := strings.TrimSuffix(b.String(), "-")
+	if len(base) > 60 {
+		base = strings.TrimSuffix(base[:60], "-")
+	}
+	if base == "" {
+		base = "job"
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return base + "-" + uuid.NewString()[:8]
+	}
+	return base + "-" + hex.EncodeToString(suffix)
+}
+
+func careersURL(slug string) string {
+	return "/careers/" + slug
+}
+
+// safeReturnPath only accepts local paths, so the return_to value can't be used
+// to redirect users off-site.
+func safeReturnPath(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.HasPrefix(path, "/\\")
+}
+
+// popReturnTo removes the stored post-login path from the session and returns
+// it, or fallback when there is none. The caller saves the session.
+func popReturnTo(session sessions.Session, fallback string) string {
+	returnTo, _ := session.Get(sessionReturnToKey).(string)
+	session.Delete(sessionReturnToKey)
+	if returnTo == "" || !safeReturnPath(returnTo) {
+		return fallback
+	}
+	return returnTo
+}
+
+// loadPublicJob loads the :slug posting, hiding drafts and archived postings.
+func (app *App) loadPublicJob(c *gin.Context) (db.GetJobPostingByIDRow, bool) {
+	row, err := app.db.GetJobPostingBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil || row.Status == JobStatusDraft || row.Status == JobStatusArchived {
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("Careers: DB error fetching job %s: %v\n", c.Param("slug"), err)
+		}
+		app.renderError(c, http.StatusNotFound, "Job not found.")
+		return db.GetJobPostingByIDRow{}, false
+	}
+	return db.GetJobPostingByIDRow(row), true
+}
+
+func salaryRangeText(salaryMin, salaryMax pgtype.Numeric) string {
+	minVal, minOK := numericToDecimal(salaryMin)
+	maxVal, maxOK := numericToDecimal(salaryMax)
+	switch {
+	case minOK && maxOK:
+		return minVal.StringFixed(2) + " - " + maxVal.StringFixed(2)
+	case minOK:
+		return "From " + minVal.StringFixed(2)
+	case maxOK:
+		return "Up to " + maxVal.StringFixed(2)
+	}
+	return "Not specified"
+}
+
+func (app *App) getCareersHandler(c *gin.Context) {
+	postings, err := app.db.ListPublishedJobPostings(c.Request.Context())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Careers: DB error listing published jobs: %v\n", err)
+		app.renderError(c, http.StatusInternalServerError, "Error loading job postings.")
+		return
+	}
+
+	app.renderPage(c, http.StatusOK, "careers.html", page{
+		Title: "Open Positions",
+		Head: pageHead{
+			Description: "Browse our open positions and apply online.",
+			Feeds:       true,
+		},
+		Data: gin.H{"Postings": postings},
+	})
+}
+
+func (app *App) getCareerJobHandler(c *gin.Context) {
+	job, ok := app.loadPublicJob(c)
+	if !ok {
+		return
+	}
+
+	metaDescription := job.Title
+	if job.Description.Valid {
+		metaDescription = job.Description.String
+		if len(metaDescription) > 155 {
+			metaDescription = metaDescription[:155] + "..."
+		}
+	}
+	posted := ""
+	if job.PublishedAt.Valid {
+		posted = job.PublishedAt.Time.Format("January 2, 2006")
+	}
+
+	app.renderPage(c, http.StatusOK, "careers_job.html", page{
+		Title: job.Title,
+		Head: pageHead{
+			Description: metaDescription,
+			Canonical:   app.absoluteURL(c, careersURL(job.Slug)),
+			JSONLD:      app.jobPostingJSONLD(c, job),
+		},
+		Data: gin.H{
+			"Job":     job,
+			"Posted":  posted,
+			"Details": app.jobDetails(c.Request.Context(), job),
+			"Open":    job.Status == JobStatusPublished,
+			"Source":  applicationSource(linkSource(c), ApplicationSourceCareers),
+		},
+	})
+}
+
+// getCareerApplyHandler sends signed-in users straight to the application
+// form. Everyone else logs in first and is brought back to the form after.
+func (app *App) getCareerApplyHandler(c *gin.Context) {
+	job, ok := app.loadPublicJob(c)
+	if !ok {
+		return
+	}
+	applyPath := fmt.Sprintf("/jobs/%s/apply?source=%s",
+		uuid.UUID(job.ID.Bytes).String(),
+		applicationSource(linkSource(c), ApplicationSourceCareers),
+	)
+
+	session := sessions.Default(c)
+	if session.Get(sessionUserKey) != nil {
+		c.Redirect(http.StatusSeeOther, applyPath)
+		return
+	}
+
+	session.Set(sessionReturnToKey, applyPath)
+	if err := session.Save(); err != nil {
+		fmt.Printf("Careers: Failed to save return path: %v\n", err)
+	}
+	c.Redirect(http.StatusSeeOther, "/auth/login")
+}
+
+type jobFeedItem struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Location    string     `json:"location"`
+	Description string     `json:"description,omitempty"`
+	SalaryMin   *string    `json:"salary_min"`
+	SalaryMax   *string    `json:"salary_max"`
+	Skills      []string   `json:"skills"`
+	PublishedAt *time.Time `json:"published_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func optionalNumeric(n pgtype.Numeric) *string {
+	if d, ok := numericToDecimal(n); ok {
+		s := d.StringFixed(2)
+		return &s
+	}
+	return nil
+}
+
+func optionalTime(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time.UTC()
+	return &t
+}
+
+// getCareersFeedHandler serves the open positions as JSON for job boards and
+// aggregators.
+func (app *App) getCareersFeedHandler(c *gin.Context) {
+	postings, err := app.db.ListPublishedJobPostings(c.Request.Context())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Careers Feed: DB error listing published jobs: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load jobs"})
+		return
+	}
+
+	items := make([]jobFeedItem, 0, len(postings))
+	for _, posting := range postings {
+		skills, err := app.db.ListJobPostingSkills(c.Request.Context(), posting.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("Careers Feed: DB error fetching skills for job %s: %v\n", posting.ID.String(), err)
+		}
+		skillNames := make([]string, 0, len(skills))
+		for _, skill := range skills {
+			skillNames = append(skillNames, skill.Name)
+		}
+
+		items = append(items, jobFeedItem{
+			ID:          uuid.UUID(posting.ID.Bytes).String(),
+			Title:       posting.Title,
+			URL:         app.absoluteURL(c, careersURL(posting.Slug)),
+			Location:    jobLocationText(posting.Location, posting.Remote),
+			Description: posting.Description.String,
+			SalaryMin:   optionalNumeric(posting.SalaryMin),
+			SalaryMax:   optionalNumeric(posting.SalaryMax),
+			Skills:      skillNames,
+			PublishedAt: optionalTime(posting.PublishedAt),
+			ExpiresAt:   optionalTime(posting.ExpiresAt),
+		})
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"jobs": items})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// newTestCSRFRouter serves GET /form, which answers with the session's CSRF
+// token, and a few POST routes behind csrfMiddleware that answer "ok".
+func newTestCSRFRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	templates, err := loadTemplates()
+	if err != nil {
+		t.Fatalf("loadTemplates: %v", err)
+	}
+	app := &App{}
+	router := gin.New()
+	router.SetHTMLTemplate(templates)
+	router.Use(sessions.Sessions(sessionCookieName, cookie.NewStore([]byte("csrf-test-secret"))))
+	router.Use(func(c *gin.Context) {
+		c.Set(currentUserKey, navData{}) // Keeps the error page's nav from loading the user
+		c.Next()
+	})
+	router.Use(app.csrfMiddleware)
+
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	router.GET("/form", func(c *gin.Context) { c.String(http.StatusOK, csrfToken(c)) })
+	router.POST("/submit", ok)
+	router.POST("/api/jobs", ok)
+	router.POST("/sso/:slug/acs", ok)
+	router.POST("/login", func(c *gin.Context) {
+		if _, ok := app.signIn(c, pgtype.UUID{Bytes: uuid.New(), Valid: true}); ok {
+			c.String(http.StatusOK, "ok")
+		}
+	})
+	return router
+}
+
+// csrfSession loads /form and returns the session cookie and its token.
+func csrfSession(t *testing.T, router *gin.Engine) (*http.Cookie, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := rec.Result().Cookies()
+	if rec.Code != http.StatusOK || len(cookies) == 0 || rec.Body.String() == "" {
+		t.Fatalf("GET /form: status %d, %d cookies, token %q", rec.Code, len(cookies), rec.Body.String())
+	}
+	return cookies[0], rec.Body.String()
+}
+
+// postForm posts form to path with the session cookie, if any, and header
+// token, if any.
+func postForm(router *gin.Engine, path string, session *http.Cookie, headerToken string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if session != nil {
+		req.AddCookie(session)
+	}
+	if headerToken != "" {
+		req.Header.Set(csrfHeader, headerToken)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCSRFRejectsMissingOrInvalidToken(t *testing.T) {
+	router := newTestCSRFRouter(t)
+	session, token := csrfSession(t, router)
+	_, otherToken := csrfSession(t, router)
+
+	tests := []struct {
+		name    string
+		session *http.Cookie
+		form    url.Values
+	}{
+		{"no session", nil, url.Values{csrfFormField: {token}}},
+		{"missing token", session, url.Values{}},
+		{"wrong token", session, url.Values{csrfFormField: {"not-the-token"}}},
+		{"another session's token", session, url.Values{csrfFormField: {otherToken}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postForm(router, "/submit", tt.session, "", tt.form); rec.Code != http.StatusForbidden {
+				t.Errorf("status %d, want 403", rec.Code)
+			}
+		})
+	}
+}
+
+func TestCSRFAcceptsFormFieldAndHeader(t *testing.T) {
+	router := newTestCSRFRouter(t)
+	session, token := csrfSession(t, router)
+
+	if rec := postForm(router, "/submit", session, "", url.Values{csrfFormField: {token}}); rec.Code != http.StatusOK {
+		t.Errorf("form field: status %d, want 200", rec.Code)
+	}
+	if rec := postForm(router, "/submit", session, token, url.Values{}); rec.Code != http.StatusOK {
+		t.Errorf("header: status %d, want 200", rec.Code)
+	}
+	if rec := postForm(router, "/submit", session, "not-the-token", url.Values{csrfFormField: {token}}); rec.Code != http.StatusForbidden {
+		t.Errorf("wrong header with right form field: status %d, want 403", rec.Code)
+	}
+}
+
+func TestCSRFExemptRoutes(t *testing.T) {
+	router := newTestCSRFRouter(t)
+	for _, path := range []string{"/api/jobs", "/sso/acme/acs"} {
+		if rec := postForm(router, path, nil, "", url.Values{}); rec.Code != http.StatusOK {
+			t.Errorf("POST %s without a token: status %d, want 200", path, rec.Code)
+		}
+	}
+}
+
+func TestCSRFTokenRotatesOnLogin(t *testing.T) {
+	router := newTestCSRFRouter(t)
+	session, token := csrfSession(t, router)
+
+	rec := postForm(router, "/login", session, "", url.Values{csrfFormField: {token}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /login: status %d, want 200", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("POST /login did not save the session")
+	}
+	signedIn := cookies[0]
+
+	if rec := postForm(router, "/submit", signedIn, "", url.Values{csrfFormField: {token}}); rec.Code != http.StatusForbidden {
+		t.Errorf("token from before login: status %d, want 403", rec.Code)
+	}
+}
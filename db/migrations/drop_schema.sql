@@ -1,3 +1,30 @@
+DROP TABLE if exists rate_limits;
+DROP TABLE if exists user_recovery_codes;
+DROP TABLE if exists user_sessions;
+DROP TABLE if exists api_keys;
+DROP TABLE if exists user_tokens;
+DROP TABLE if exists user_identities;
+DROP TABLE if exists saml_connections;
+ALTER TABLE if exists users DROP COLUMN if exists organization_id;
+DROP TABLE if exists organizations;
+DROP VIEW if exists application_metrics;
+DROP TABLE if exists webhook_deliveries;
+DROP TABLE if exists webhook_endpoints;
+DROP TABLE if exists job_templates;
+DROP TABLE if exists job_posting_skills;
+DROP TABLE if exists offers;
+DROP TABLE if exists scorecard_scores;
+DROP TABLE if exists scorecards;
+DROP TABLE if exists scorecard_criteria;
+DROP TABLE if exists application_ratings;
+DROP TABLE if exists application_notes;
+DROP TABLE if exists job_interviewers;
+DROP TABLE if exists application_attachments;
+DROP TABLE if exists screening_answers;
+DROP TABLE if exists screening_questions;
+DROP TABLE if exists application_status_history;
+DROP TABLE if exists message_attachments;
+DROP TABLE if exists application_messages;
 DROP TABLE if exists resumes;
 DROP TABLE if exists job_postings;
 DROP TABLE if exists users;
@@ -6,7 +6,18 @@ CREATE TABLE "users" (
     "role" varchar NOT NULL DEFAULT 'applicant',
     resume_pdf BYTEA,
     parsed_resume JSONB,
-    PRIMARY KEY ("id")
+    "external_id" varchar,
+    "imported_by" uuid REFERENCES "users"("id") ON DELETE SET NULL,
+    "password_hash" varchar, -- bcrypt; NULL for accounts that only use a login provider
+    "email_verified_at" timestamptz,
+    "suspended_at" timestamptz, -- Suspended users are signed out and cannot sign in
+    "totp_secret" varchar, -- Base32 TOTP secret; set once two-factor authentication is confirmed
+    "totp_enabled_at" timestamptz,
+    "totp_last_step" bigint, -- Last accepted TOTP time step, so a code cannot be replayed
+    "totp_failed_attempts" integer NOT NULL DEFAULT 0, -- Incorrect two-factor codes since the last correct one
+    "totp_locked_until" timestamptz, -- Two-factor codes are refused until then after too many incorrect ones
+    PRIMARY KEY ("id"),
+    UNIQUE ("imported_by", "external_id")
 );
 
 CREATE TABLE "resumes" (
@@ -22,10 +33,22 @@ CREATE TABLE job_postings (
     "id" uuid DEFAULT gen_random_uuid(),
     "recruiter_id" uuid NOT NULL,
     "title" VARCHAR NOT NULL,
+    "slug" varchar NOT NULL UNIQUE,
+    "description" text,
+    "location" varchar,
+    "remote" boolean NOT NULL DEFAULT false,
     "salary_min" numeric(10,2),
     "salary_max" numeric(10,2),
-    "status" varchar(20) NOT NULL DEFAULT 'active',
-    PRIMARY KEY ("id")
+    "status" varchar(20) NOT NULL DEFAULT 'draft',
+    "headcount" int NOT NULL DEFAULT 1,
+    "auto_close" boolean NOT NULL DEFAULT false,
+    "publish_at" timestamptz,
+    "expires_at" timestamptz,
+    "published_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "external_id" varchar,
+    PRIMARY KEY ("id"),
+    UNIQUE ("recruiter_id", "external_id")
 );
 
 CREATE TABLE "skills" (
@@ -46,6 +69,8 @@ CREATE TABLE "applications" (
     "resume_id" uuid REFERENCES "resumes"("id") ON DELETE SET NULL, 
     "status" varchar NOT NULL DEFAULT 'submitted', 
     "applied_at" timestamptz NOT NULL DEFAULT now(),
+    "cover_letter" text,
+    "source" varchar NOT NULL DEFAULT 'direct',
     UNIQUE ("user_id", "job_posting_id") 
 );
 
@@ -59,3 +84,301 @@ CREATE TABLE "interviews" (
 ALTER TABLE "resumes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
 ALTER TABLE "resumes" ADD FOREIGN KEY ("job_posting_id") REFERENCES "job_postings" ("id");
 ALTER TABLE "job_postings" ADD FOREIGN KEY ("recruiter_id") REFERENCES "users" ("id");
+
+CREATE TABLE "application_messages" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "sender_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "body" text NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "read_at" timestamptz
+);
+
+CREATE TABLE "message_attachments" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "message_id" uuid NOT NULL REFERENCES "application_messages"("id") ON DELETE CASCADE,
+    "file_name" varchar NOT NULL,
+    "content_type" varchar NOT NULL,
+    "data" BYTEA NOT NULL
+);
+
+CREATE TABLE "application_status_history" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "status" varchar NOT NULL,
+    "changed_by" uuid REFERENCES "users"("id") ON DELETE SET NULL,
+    "reason" text,
+    "changed_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "screening_questions" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "job_posting_id" uuid NOT NULL REFERENCES "job_postings"("id") ON DELETE CASCADE,
+    "position" int NOT NULL DEFAULT 0,
+    "prompt" text NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "options" text[] NOT NULL DEFAULT '{}',
+    "required" boolean NOT NULL DEFAULT true,
+    "knockout" boolean NOT NULL DEFAULT false,
+    "knockout_answers" text[] NOT NULL DEFAULT '{}',
+    "knockout_min" numeric(12,2),
+    "knockout_max" numeric(12,2)
+);
+
+CREATE TABLE "screening_answers" (
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "question_id" uuid NOT NULL REFERENCES "screening_questions"("id") ON DELETE CASCADE,
+    "answer" text NOT NULL,
+    PRIMARY KEY ("application_id", "question_id")
+);
+
+CREATE TABLE "application_attachments" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "kind" varchar(20) NOT NULL DEFAULT 'attachment',
+    "file_name" varchar NOT NULL,
+    "content_type" varchar NOT NULL,
+    "data" BYTEA NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "job_interviewers" (
+    "job_posting_id" uuid NOT NULL REFERENCES "job_postings"("id") ON DELETE CASCADE,
+    "user_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "added_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("job_posting_id", "user_id")
+);
+
+CREATE TABLE "application_notes" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "author_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "body" text NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "application_ratings" (
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "rater_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "rating" int NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("application_id", "rater_id")
+);
+
+CREATE TABLE "scorecard_criteria" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "job_posting_id" uuid NOT NULL REFERENCES "job_postings"("id") ON DELETE CASCADE,
+    "position" int NOT NULL DEFAULT 0,
+    "name" varchar NOT NULL,
+    "description" text
+);
+
+CREATE TABLE "scorecards" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "application_id" uuid NOT NULL REFERENCES "applications"("id") ON DELETE CASCADE,
+    "interviewer_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "recommendation" varchar(20) NOT NULL,
+    "summary" text,
+    "submitted_at" timestamptz NOT NULL DEFAULT now(),
+    UNIQUE ("application_id", "interviewer_id")
+);
+
+CREATE TABLE "scorecard_scores" (
+    "scorecard_id" uuid NOT NULL REFERENCES "scorecards"("id") ON DELETE CASCADE,
+    "criterion_id" uuid NOT NULL REFERENCES "scorecard_criteria"("id") ON DELETE CASCADE,
+    "score" int NOT NULL CHECK ("score" BETWEEN 1 AND 5),
+    "comment" text,
+    PRIMARY KEY ("scorecard_id", "criterion_id")
+);
+
+CREATE TABLE "offers" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "application_id" uuid NOT NULL UNIQUE REFERENCES "applications"("id") ON DELETE CASCADE,
+    "created_by" uuid NOT NULL REFERENCES "users"("id"),
+    "salary" numeric(10,2) NOT NULL,
+    "start_date" date NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "notes" text,
+    "status" varchar(20) NOT NULL DEFAULT 'draft',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "sent_at" timestamptz,
+    "responded_at" timestamptz
+);
+
+CREATE TABLE "job_posting_skills" (
+    "job_posting_id" uuid NOT NULL REFERENCES "job_postings"("id") ON DELETE CASCADE,
+    "skill_id" uuid NOT NULL REFERENCES "skills"("id") ON DELETE CASCADE,
+    PRIMARY KEY ("job_posting_id", "skill_id")
+);
+
+CREATE TABLE "job_templates" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "recruiter_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "name" varchar NOT NULL,
+    "title" varchar NOT NULL,
+    "description" text,
+    "salary_min" numeric(10,2),
+    "salary_max" numeric(10,2),
+    "headcount" int NOT NULL DEFAULT 1,
+    "skill_ids" uuid[] NOT NULL DEFAULT '{}',
+    "screening_questions" jsonb NOT NULL DEFAULT '[]',
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+-- Webhook endpoints are registered for an organization and get events for the
+-- job postings of all its recruiters. Endpoints made by a recruiter without an
+-- organization have no organization_id and only get that recruiter's events.
+CREATE TABLE "webhook_endpoints" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "recruiter_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE, -- Who registered the endpoint
+    "url" text NOT NULL,
+    "secret" varchar NOT NULL,
+    "events" text[] NOT NULL,
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "webhook_deliveries" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "endpoint_id" uuid NOT NULL REFERENCES "webhook_endpoints"("id") ON DELETE CASCADE,
+    "event" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" int NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+    "last_status_code" int,
+    "last_error" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "delivered_at" timestamptz
+);
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+-- Client organizations that sign their recruiters in through a corporate
+-- identity provider. Users are matched to one by their email domain.
+CREATE TABLE "organizations" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "slug" varchar NOT NULL UNIQUE,
+    "email_domain" varchar NOT NULL UNIQUE,
+    "domain_verification_token" varchar NOT NULL DEFAULT replace(gen_random_uuid()::text, '-', ''), -- Published in DNS to prove the organization owns email_domain
+    "domain_verified_at" timestamptz, -- Single sign-on only vouches for addresses at a verified domain
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "users" ADD COLUMN "organization_id" uuid REFERENCES "organizations"("id") ON DELETE SET NULL;
+ALTER TABLE "webhook_endpoints" ADD COLUMN "organization_id" uuid REFERENCES "organizations"("id") ON DELETE CASCADE;
+
+CREATE TABLE "saml_connections" (
+    "organization_id" uuid PRIMARY KEY REFERENCES "organizations"("id") ON DELETE CASCADE,
+    "idp_metadata" text NOT NULL,
+    "email_attribute" varchar NOT NULL DEFAULT '', -- Empty means try the usual email attribute names
+    "name_attribute" varchar NOT NULL DEFAULT '',
+    "enabled" boolean NOT NULL DEFAULT false,
+    "allow_idp_initiated" boolean NOT NULL DEFAULT false, -- Accept responses the IdP sends without a request from us
+    "updated_at" timestamptz NOT NULL DEFAULT now()
+);
+
+-- Login provider accounts linked to a user. Logins are only matched here;
+-- users.google_id keeps the account's first login but is not used to sign in,
+-- so unlinking an identity here is final. Databases from before this table
+-- need upgrade_user_identities.sql run once after creating it.
+CREATE TABLE "user_identities" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "user_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "provider" varchar NOT NULL,
+    "subject" varchar NOT NULL, -- The provider's user ID
+    "email" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    UNIQUE ("provider", "subject")
+);
+
+CREATE INDEX ON "user_identities" ("user_id");
+
+-- One-time tokens emailed for verifying an address or resetting a password.
+CREATE TABLE "user_tokens" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "user_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "purpose" varchar(20) NOT NULL,
+    "token_hash" bytea NOT NULL UNIQUE,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "api_keys" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "recruiter_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "organization_id" uuid REFERENCES "organizations"("id") ON DELETE CASCADE, -- The recruiter's organization when the key was created; the key stops working if they leave it
+    "name" varchar NOT NULL,
+    "prefix" varchar NOT NULL UNIQUE, -- Shown in the UI and used to look the key up
+    "key_hash" bytea NOT NULL, -- SHA-256 of the full key; the key itself is never stored
+    "scopes" text[] NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz
+);
+
+-- Server-side login sessions. The cookie only carries a random token; rows
+-- are looked up by its hash. user_role is the role at sign-in, so a session
+-- stops working when the user's role changes.
+CREATE TABLE "user_sessions" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "token_hash" bytea NOT NULL UNIQUE,
+    "user_id" uuid REFERENCES "users"("id") ON DELETE CASCADE, -- NULL until someone signs in
+    "user_role" varchar,
+    "data" bytea NOT NULL,
+    "ip_address" varchar NOT NULL DEFAULT '',
+    "user_agent" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "last_seen_at" timestamptz NOT NULL DEFAULT now(),
+    "expires_at" timestamptz NOT NULL
+);
+CREATE INDEX ON "user_sessions" ("user_id");
+
+-- Single-use codes for signing in when the authenticator app is unavailable.
+CREATE TABLE "user_recovery_codes" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "user_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "code_hash" bytea NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    UNIQUE ("user_id", "code_hash")
+);
+
+-- Fixed-window request counters, used when RATE_LIMIT_STORE=postgres so
+-- every instance shares the same limits. Losing them on a crash is harmless.
+CREATE UNLOGGED TABLE "rate_limits" (
+    "key" varchar PRIMARY KEY,
+    "window_start" timestamptz NOT NULL DEFAULT now(),
+    "hits" integer NOT NULL DEFAULT 1
+);
+
+-- application_metrics flattens each application's progress for the recruiter
+-- analytics: the furthest funnel stage it reached (1 applied, 2 screening,
+-- 3 interview, 4 offer, 5 hired), the first status change made by someone
+-- other than the applicant, and when it was hired.
+CREATE VIEW "application_metrics" AS
+SELECT
+    a.id AS application_id,
+    a.job_posting_id,
+    j.recruiter_id,
+    a.source,
+    a.status,
+    a.applied_at,
+    GREATEST(
+        CASE a.status
+            WHEN 'screening' THEN 2 WHEN 'accepted' THEN 3 WHEN 'offered' THEN 4
+            WHEN 'declined' THEN 4 WHEN 'hired' THEN 5 ELSE 1
+        END,
+        (SELECT COALESCE(MAX(CASE h.status
+            WHEN 'screening' THEN 2 WHEN 'accepted' THEN 3 WHEN 'offered' THEN 4
+            WHEN 'declined' THEN 4 WHEN 'hired' THEN 5 ELSE 1
+        END), 1) FROM application_status_history h WHERE h.application_id = a.id)
+    )::int AS stage_rank,
+    (SELECT MIN(h.changed_at) FROM application_status_history h
+     WHERE h.application_id = a.id AND h.changed_by IS DISTINCT FROM a.user_id) AS first_response_at,
+    (SELECT MIN(h.changed_at) FROM application_status_history h
+     WHERE h.application_id = a.id AND h.status = 'hired') AS hired_at
+FROM applications a
+JOIN job_postings j ON a.job_posting_id = j.id;
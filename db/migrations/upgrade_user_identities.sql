@@ -0,0 +1,18 @@
+-- Run once on databases created before user_identities, right after creating
+-- the table; a fresh install has nothing to copy. Logins are only matched
+-- against user_identities, so until this runs existing users cannot sign in.
+--
+-- Accounts from before user_identities only had users.google_id: a bare
+-- Google ID, or "<provider>:<id>" for other providers. Placeholders for
+-- imported, password and SAML accounts are not logins.
+INSERT INTO "user_identities" ("user_id", "provider", "subject", "email")
+SELECT "id",
+       CASE WHEN "google_id" LIKE '%:%' THEN split_part("google_id", ':', 1) ELSE 'google' END,
+       CASE WHEN "google_id" LIKE '%:%' THEN substr("google_id", strpos("google_id", ':') + 1) ELSE "google_id" END,
+       "email"
+FROM "users"
+WHERE "google_id" <> ''
+  AND "google_id" NOT LIKE 'import:%'
+  AND "google_id" NOT LIKE 'password:%'
+  AND "google_id" NOT LIKE 'saml:%'
+ON CONFLICT ("provider", "subject") DO NOTHING;
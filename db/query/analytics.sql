@@ -0,0 +1,60 @@
+-- name: GetRecruitingMetrics :one
+SELECT
+    COUNT(*) AS applied,
+    COUNT(*) FILTER (WHERE stage_rank >= 2) AS screened,
+    COUNT(*) FILTER (WHERE stage_rank >= 3) AS interviewed,
+    COUNT(*) FILTER (WHERE stage_rank >= 4) AS offered,
+    COUNT(*) FILTER (WHERE stage_rank >= 5) AS hired,
+    COUNT(*) FILTER (WHERE status = 'rejected') AS rejected,
+    COUNT(*) FILTER (WHERE status = 'withdrawn') AS withdrawn,
+    COUNT(*) FILTER (WHERE status = 'declined') AS declined,
+    COUNT(first_response_at) AS responded,
+    COALESCE(AVG(EXTRACT(EPOCH FROM first_response_at - applied_at)) / 3600, 0)::float8 AS avg_first_response_hours,
+    COALESCE(AVG(EXTRACT(EPOCH FROM hired_at - applied_at)) / 86400, 0)::float8 AS avg_time_to_hire_days
+FROM application_metrics
+WHERE recruiter_id = sqlc.arg(recruiter_id)
+  AND (sqlc.narg(job_id)::uuid IS NULL OR job_posting_id = sqlc.narg(job_id)::uuid)
+  AND applied_at >= sqlc.arg(since);
+
+-- name: ListJobMetrics :many
+SELECT
+    j.id,
+    j.title,
+    j.status,
+    COUNT(m.application_id) AS applied,
+    COUNT(m.application_id) FILTER (WHERE m.stage_rank >= 3) AS interviewed,
+    COUNT(m.application_id) FILTER (WHERE m.stage_rank >= 4) AS offered,
+    COUNT(m.application_id) FILTER (WHERE m.stage_rank >= 5) AS hired,
+    COUNT(m.application_id) FILTER (WHERE m.status = 'rejected') AS rejected,
+    COALESCE(AVG(EXTRACT(EPOCH FROM m.first_response_at - m.applied_at)) / 3600, 0)::float8 AS avg_first_response_hours,
+    COALESCE(AVG(EXTRACT(EPOCH FROM m.hired_at - m.applied_at)) / 86400, 0)::float8 AS avg_time_to_hire_days
+FROM job_postings j
+LEFT JOIN application_metrics m ON m.job_posting_id = j.id AND m.applied_at >= sqlc.arg(since)
+WHERE j.recruiter_id = sqlc.arg(recruiter_id)
+  AND (sqlc.narg(job_id)::uuid IS NULL OR j.id = sqlc.narg(job_id)::uuid)
+GROUP BY j.id, j.title, j.status
+ORDER BY applied DESC, j.title;
+
+-- name: ListApplicationsPerWeek :many
+SELECT
+    date_trunc('week', applied_at)::timestamptz AS week,
+    COUNT(*) AS applied
+FROM application_metrics
+WHERE recruiter_id = sqlc.arg(recruiter_id)
+  AND (sqlc.narg(job_id)::uuid IS NULL OR job_posting_id = sqlc.narg(job_id)::uuid)
+  AND applied_at >= sqlc.arg(since)
+GROUP BY week
+ORDER BY week;
+
+-- name: ListSourceMetrics :many
+SELECT
+    source,
+    COUNT(*) AS applied,
+    COUNT(*) FILTER (WHERE stage_rank >= 3) AS interviewed,
+    COUNT(*) FILTER (WHERE stage_rank >= 5) AS hired
+FROM application_metrics
+WHERE recruiter_id = sqlc.arg(recruiter_id)
+  AND (sqlc.narg(job_id)::uuid IS NULL OR job_posting_id = sqlc.narg(job_id)::uuid)
+  AND applied_at >= sqlc.arg(since)
+GROUP BY source
+ORDER BY applied DESC;
@@ -0,0 +1,39 @@
+-- name: CreateAPIKey :one
+-- The key is scoped to the recruiter's organization at the time it is created.
+INSERT INTO api_keys
+(recruiter_id, organization_id, name, prefix, key_hash, scopes)
+SELECT u.id, u.organization_id, sqlc.arg(name)::varchar, sqlc.arg(prefix)::varchar, sqlc.arg(key_hash)::bytea, sqlc.arg(scopes)::text[]
+FROM users u
+WHERE u.id = sqlc.arg(recruiter_id)::uuid
+RETURNING id;
+
+-- name: ListAPIKeys :many
+SELECT k.id, k.name, k.prefix, k.scopes, k.created_at, k.last_used_at, k.revoked_at,
+       (k.organization_id IS NOT DISTINCT FROM u.organization_id)::boolean AS in_organization
+FROM api_keys k
+JOIN users u ON k.recruiter_id = u.id
+WHERE k.recruiter_id = $1
+ORDER BY k.created_at DESC;
+
+-- name: GetActiveAPIKeyByPrefix :one
+SELECT k.id, k.recruiter_id, k.key_hash, k.scopes
+FROM api_keys k
+JOIN users u ON k.recruiter_id = u.id
+WHERE k.prefix = $1
+  AND k.revoked_at IS NULL
+  AND k.organization_id IS NOT DISTINCT FROM u.organization_id
+  AND u.role = 'recruiter'
+  AND u.suspended_at IS NULL;
+
+-- name: TouchAPIKey :exec
+-- Only writes once a minute per key, so busy integrations do not turn every
+-- request into an UPDATE.
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
+
+-- name: RevokeAPIKey :exec
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1 AND recruiter_id = $2 AND revoked_at IS NULL;
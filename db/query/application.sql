@@ -1,8 +1,8 @@
 -- name: CreateApplication :one
 INSERT INTO applications 
-(user_id, job_posting_id, status, applied_at) 
+(user_id, job_posting_id, cover_letter, source, status, applied_at) 
 VALUES 
-($1, $2, 'submitted', NOW())
+($1, $2, $3, $4, 'submitted', NOW())
 RETURNING id, user_id, job_posting_id, status, applied_at; 
 
 -- name: CheckApplicationExists :one
@@ -31,11 +31,95 @@ SELECT
     a.resume_id,
     a.status, 
     a.applied_at,
+    a.cover_letter,
     u.email AS applicant_email, 
-    j.recruiter_id 
+    u.name AS applicant_name,
+    j.recruiter_id,
+    j.title AS job_title
 FROM applications a
 JOIN users u ON a.user_id = u.id
 JOIN job_postings j ON a.job_posting_id = j.id
 WHERE a.id = $1;
 
 
+
+-- name: CreateApplicationStatusHistory :exec
+INSERT INTO application_status_history
+(application_id, status, changed_by, reason)
+VALUES
+($1, $2, $3, $4);
+
+-- name: ListApplicationStatusHistory :many
+SELECT
+    h.status,
+    h.reason,
+    h.changed_at,
+    u.name AS changed_by_name
+FROM application_status_history h
+LEFT JOIN users u ON h.changed_by = u.id
+WHERE h.application_id = $1
+ORDER BY h.changed_at ASC;
+
+-- name: CreateResumeSnapshot :one
+INSERT INTO resumes
+(user_id, job_posting_id, resume_pdf, parsed_resume)
+SELECT u.id, sqlc.arg(job_posting_id), u.resume_pdf, u.parsed_resume
+FROM users u
+WHERE u.id = sqlc.arg(user_id) AND u.resume_pdf IS NOT NULL
+RETURNING id;
+
+-- name: SetApplicationResume :exec
+UPDATE applications
+SET resume_id = $2
+WHERE id = $1;
+
+-- name: GetResumeByID :one
+SELECT id, user_id, resume_pdf, parsed_resume
+FROM resumes
+WHERE id = $1;
+
+-- name: CreateApplicationAttachment :one
+INSERT INTO application_attachments
+(application_id, kind, file_name, content_type, data)
+VALUES
+($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: ListApplicationAttachments :many
+SELECT
+    id,
+    kind,
+    file_name,
+    content_type,
+    octet_length(data)::int AS size_bytes
+FROM application_attachments
+WHERE application_id = $1
+ORDER BY kind, created_at;
+
+-- name: GetApplicationAttachment :one
+SELECT id, application_id, file_name, content_type, data
+FROM application_attachments
+WHERE id = $1;
+
+-- name: ListApplicationsForExport :many
+SELECT
+    a.id,
+    a.status,
+    a.source,
+    a.applied_at,
+    u.name AS applicant_name,
+    u.email AS applicant_email,
+    COALESCE((
+        SELECT string_agg(s.name, '; ' ORDER BY s.name)
+        FROM user_skills us
+        JOIN skills s ON s.id = us.skill_id
+        WHERE us.user_id = u.id
+    ), '')::text AS skills,
+    COALESCE(r.parsed_resume, u.parsed_resume) AS parsed_resume
+FROM applications a
+JOIN users u ON a.user_id = u.id
+LEFT JOIN resumes r ON a.resume_id = r.id
+WHERE a.job_posting_id = sqlc.arg(job_posting_id)
+  AND (a.applied_at, a.id) > (sqlc.arg(after_applied_at)::timestamptz, sqlc.arg(after_id)::uuid)
+ORDER BY a.applied_at, a.id
+LIMIT sqlc.arg(page_size)::int;
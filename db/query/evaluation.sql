@@ -0,0 +1,118 @@
+-- name: AddJobInterviewer :exec
+INSERT INTO job_interviewers
+(job_posting_id, user_id)
+VALUES
+($1, $2)
+ON CONFLICT (job_posting_id, user_id) DO NOTHING;
+
+-- name: RemoveJobInterviewer :exec
+DELETE FROM job_interviewers
+WHERE job_posting_id = $1 AND user_id = $2;
+
+-- name: ListJobInterviewers :many
+SELECT u.id, u.name, u.email
+FROM job_interviewers ji
+JOIN users u ON ji.user_id = u.id
+WHERE ji.job_posting_id = $1
+ORDER BY u.name;
+
+-- name: IsJobInterviewer :one
+SELECT EXISTS (
+    SELECT 1 FROM job_interviewers
+    WHERE job_posting_id = $1 AND user_id = $2
+);
+
+-- name: ListJobsForInterviewer :many
+SELECT j.id, j.title, j.status
+FROM job_interviewers ji
+JOIN job_postings j ON ji.job_posting_id = j.id
+WHERE ji.user_id = $1
+ORDER BY ji.added_at DESC;
+
+-- name: CreateApplicationNote :exec
+INSERT INTO application_notes
+(application_id, author_id, body)
+VALUES
+($1, $2, $3);
+
+-- name: ListApplicationNotes :many
+SELECT n.id, n.body, n.created_at, u.name AS author_name
+FROM application_notes n
+JOIN users u ON n.author_id = u.id
+WHERE n.application_id = $1
+ORDER BY n.created_at DESC;
+
+-- name: UpsertApplicationRating :exec
+INSERT INTO application_ratings
+(application_id, rater_id, rating)
+VALUES
+($1, $2, $3)
+ON CONFLICT (application_id, rater_id) DO UPDATE
+SET rating = EXCLUDED.rating,
+    updated_at = now();
+
+-- name: ListApplicationRatings :many
+SELECT r.rater_id, r.rating, r.updated_at, u.name AS rater_name
+FROM application_ratings r
+JOIN users u ON r.rater_id = u.id
+WHERE r.application_id = $1
+ORDER BY u.name;
+
+-- name: ListRatingSummariesForJob :many
+SELECT
+    r.application_id,
+    AVG(r.rating)::float8 AS average_rating,
+    COUNT(*) AS rating_count
+FROM application_ratings r
+JOIN applications a ON r.application_id = a.id
+WHERE a.job_posting_id = $1
+GROUP BY r.application_id;
+
+-- name: CreateScorecardCriterion :exec
+INSERT INTO scorecard_criteria
+(job_posting_id, position, name, description)
+VALUES
+($1, $2, $3, $4);
+
+-- name: ListScorecardCriteria :many
+SELECT id, position, name, description
+FROM scorecard_criteria
+WHERE job_posting_id = $1
+ORDER BY position, name;
+
+-- name: DeleteScorecardCriterion :exec
+DELETE FROM scorecard_criteria
+WHERE id = $1 AND job_posting_id = $2;
+
+-- name: UpsertScorecard :one
+INSERT INTO scorecards
+(application_id, interviewer_id, recommendation, summary)
+VALUES
+($1, $2, $3, $4)
+ON CONFLICT (application_id, interviewer_id) DO UPDATE
+SET recommendation = EXCLUDED.recommendation,
+    summary = EXCLUDED.summary,
+    submitted_at = now()
+RETURNING id;
+
+-- name: UpsertScorecardScore :exec
+INSERT INTO scorecard_scores
+(scorecard_id, criterion_id, score, comment)
+VALUES
+($1, $2, $3, $4)
+ON CONFLICT (scorecard_id, criterion_id) DO UPDATE
+SET score = EXCLUDED.score,
+    comment = EXCLUDED.comment;
+
+-- name: ListScorecardsForApplication :many
+SELECT s.id, s.interviewer_id, s.recommendation, s.summary, s.submitted_at, u.name AS interviewer_name
+FROM scorecards s
+JOIN users u ON s.interviewer_id = u.id
+WHERE s.application_id = $1
+ORDER BY s.submitted_at;
+
+-- name: ListScorecardScoresForApplication :many
+SELECT ss.scorecard_id, ss.criterion_id, ss.score, ss.comment
+FROM scorecard_scores ss
+JOIN scorecards s ON ss.scorecard_id = s.id
+WHERE s.application_id = $1;
@@ -0,0 +1,32 @@
+-- name: GetUserByIdentity :one
+SELECT u.id, u.email, u.name, u.role
+FROM user_identities i
+JOIN users u ON i.user_id = u.id
+WHERE i.provider = $1 AND i.subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities
+(user_id, provider, subject, email)
+VALUES
+($1, $2, $3, $4);
+
+-- name: ListUserIdentities :many
+SELECT id, provider, email, created_at
+FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteUserIdentity :execrows
+-- Refuses to remove the user's last way to sign in.
+DELETE FROM user_identities i
+WHERE i.id = sqlc.arg(id)
+  AND i.user_id = sqlc.arg(user_id)
+  AND (
+    (SELECT count(*) FROM user_identities WHERE user_id = sqlc.arg(user_id)) > 1
+    OR EXISTS (SELECT 1 FROM users WHERE id = sqlc.arg(user_id) AND password_hash IS NOT NULL)
+  );
+
+-- name: UserHasPassword :one
+SELECT (password_hash IS NOT NULL)::boolean AS has_password
+FROM users
+WHERE id = $1;
@@ -0,0 +1,20 @@
+-- name: UpsertInterview :exec
+INSERT INTO interviews
+(application_id, requesting_user_id, proposed_details, status)
+VALUES
+($1, $2, $3, 'requested')
+ON CONFLICT (application_id) DO UPDATE
+SET requesting_user_id = EXCLUDED.requesting_user_id,
+    proposed_details = EXCLUDED.proposed_details,
+    status = 'requested';
+
+-- name: GetInterviewByApplicationID :one
+SELECT i.id, i.proposed_details, i.status, u.name AS requested_by_name
+FROM interviews i
+JOIN users u ON i.requesting_user_id = u.id
+WHERE i.application_id = $1;
+
+-- name: UpdateInterviewStatusByApplication :exec
+UPDATE interviews
+SET status = $2
+WHERE application_id = $1;
@@ -1,41 +1,79 @@
 -- name: CreateJobPosting :one
 INSERT INTO job_postings 
-(recruiter_id, title, salary_min, salary_max, status) 
+(recruiter_id, title, salary_min, salary_max, status, headcount, auto_close, publish_at, expires_at, published_at, description, slug, location, remote) 
 VALUES 
-($1, $2, $3, $4, $5) 
-RETURNING id, recruiter_id, title, salary_min, salary_max, status; 
+($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) 
+RETURNING id, recruiter_id, title, salary_min, salary_max, status, slug; 
 
 -- name: ListJobPostingsByRecruiter :many
-SELECT id, title, status, salary_min, salary_max 
+SELECT id, title, status, salary_min, salary_max, publish_at, expires_at 
 FROM job_postings
 WHERE recruiter_id = $1
 ORDER BY salary_max DESC; 
 
--- name: ListActiveJobPostings :many
+-- name: ListPublishedJobPostings :many
 SELECT 
     j.id, 
     j.title, 
+    j.slug,
+    j.description,
+    j.location,
+    j.remote,
     j.status, 
     j.salary_min, 
     j.salary_max, 
+    j.published_at,
+    j.expires_at,
     u.name AS recruiter_name 
 FROM job_postings j
 JOIN users u ON j.recruiter_id = u.id
-WHERE j.status = 'active' 
+WHERE j.status = 'published' 
 ORDER BY j.title; 
 
 -- name: GetJobPostingByID :one
 SELECT 
     j.id, 
     j.title, 
+    j.slug,
+    j.description,
+    j.location,
+    j.remote,
     j.status, 
     j.salary_min, 
     j.salary_max, 
+    j.recruiter_id,
+    j.headcount,
+    j.auto_close,
+    j.publish_at,
+    j.expires_at,
+    j.published_at,
     u.name AS recruiter_name 
 FROM job_postings j
 JOIN users u ON j.recruiter_id = u.id
 WHERE j.id = $1;
 
+-- name: GetJobPostingBySlug :one
+SELECT 
+    j.id, 
+    j.title, 
+    j.slug,
+    j.description,
+    j.location,
+    j.remote,
+    j.status, 
+    j.salary_min, 
+    j.salary_max, 
+    j.recruiter_id,
+    j.headcount,
+    j.auto_close,
+    j.publish_at,
+    j.expires_at,
+    j.published_at,
+    u.name AS recruiter_name 
+FROM job_postings j
+JOIN users u ON j.recruiter_id = u.id
+WHERE j.slug = $1;
+
 -- name: GetApplicationsForJobPosting :many
 SELECT 
     a.id AS application_id,
@@ -49,7 +87,59 @@ JOIN users u ON a.user_id = u.id
 WHERE a.job_posting_id = $1
 ORDER BY a.applied_at ASC; 
 
--- name: UpdateApplicationStatus :exec
+-- name: UpdateApplicationStatus :execrows
+-- Only changes an application still in from_status, so a concurrent change
+-- cannot be overwritten with a transition the status rules forbid.
 UPDATE applications
-SET status = $2
-WHERE id = $1; 
\ No newline at end of file
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status);
+
+-- name: UpdateJobPostingStatus :exec
+UPDATE job_postings
+SET status = sqlc.arg(status),
+    published_at = CASE WHEN sqlc.arg(status) = 'published' THEN COALESCE(published_at, now()) ELSE published_at END
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateJobPostingSchedule :exec
+UPDATE job_postings
+SET publish_at = $2, expires_at = $3
+WHERE id = $1;
+
+-- name: PublishScheduledJobPostings :many
+UPDATE job_postings
+SET status = 'published', published_at = COALESCE(published_at, now())
+WHERE status = 'draft' AND publish_at IS NOT NULL AND publish_at <= now()
+RETURNING id, title;
+
+-- name: ExpireJobPostings :many
+UPDATE job_postings
+SET status = 'closed'
+WHERE status IN ('published', 'paused') AND expires_at IS NOT NULL AND expires_at <= now()
+RETURNING id, title;
+
+-- name: CountApplicationsByStatus :one
+SELECT COUNT(*)
+FROM applications
+WHERE job_posting_id = $1 AND status = $2;
+
+-- name: UpsertImportedJobPosting :one
+INSERT INTO job_postings
+(recruiter_id, external_id, title, slug, description, location, remote, salary_min, salary_max, headcount, status, published_at)
+VALUES
+(sqlc.arg(recruiter_id), sqlc.arg(external_id), sqlc.arg(title), sqlc.arg(slug), sqlc.arg(description), sqlc.arg(location),
+ sqlc.arg(remote), sqlc.arg(salary_min), sqlc.arg(salary_max), sqlc.arg(headcount), sqlc.arg(status),
+ CASE WHEN sqlc.arg(status) = 'published' THEN now() END)
+ON CONFLICT (recruiter_id, external_id) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    location = EXCLUDED.location,
+    remote = EXCLUDED.remote,
+    salary_min = EXCLUDED.salary_min,
+    salary_max = EXCLUDED.salary_max,
+    headcount = EXCLUDED.headcount
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: GetJobPostingIDByExternalID :one
+SELECT id
+FROM job_postings
+WHERE recruiter_id = $1 AND external_id = $2;
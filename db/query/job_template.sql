@@ -0,0 +1,21 @@
+-- name: CreateJobTemplate :one
+INSERT INTO job_templates
+(recruiter_id, name, title, description, salary_min, salary_max, headcount, skill_ids, screening_questions)
+VALUES
+($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id;
+
+-- name: ListJobTemplatesByRecruiter :many
+SELECT id, name, title, created_at
+FROM job_templates
+WHERE recruiter_id = $1
+ORDER BY name;
+
+-- name: GetJobTemplate :one
+SELECT *
+FROM job_templates
+WHERE id = $1 AND recruiter_id = $2;
+
+-- name: DeleteJobTemplate :exec
+DELETE FROM job_templates
+WHERE id = $1 AND recruiter_id = $2;
@@ -0,0 +1,65 @@
+-- name: CreateMessage :one
+INSERT INTO application_messages
+(application_id, sender_id, body)
+VALUES
+($1, $2, $3)
+RETURNING id, application_id, sender_id, body, created_at, read_at;
+
+-- name: ListMessagesForApplication :many
+SELECT
+    m.id,
+    m.sender_id,
+    u.name AS sender_name,
+    m.body,
+    m.created_at,
+    m.read_at
+FROM application_messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.application_id = $1
+ORDER BY m.created_at ASC;
+
+-- name: MarkMessagesRead :exec
+UPDATE application_messages
+SET read_at = NOW()
+WHERE application_id = sqlc.arg(application_id)
+AND sender_id <> sqlc.arg(reader_id)
+AND read_at IS NULL;
+
+-- name: CountUnreadMessagesForUser :one
+SELECT COUNT(*)
+FROM application_messages m
+JOIN applications a ON m.application_id = a.id
+JOIN job_postings j ON a.job_posting_id = j.id
+WHERE m.read_at IS NULL
+AND m.sender_id <> sqlc.arg(user_id)
+AND (a.user_id = sqlc.arg(user_id) OR j.recruiter_id = sqlc.arg(user_id));
+
+-- name: CreateMessageAttachment :one
+INSERT INTO message_attachments
+(message_id, file_name, content_type, data)
+VALUES
+($1, $2, $3, $4)
+RETURNING id;
+
+-- name: ListMessageAttachmentsForApplication :many
+SELECT
+    a.id,
+    a.message_id,
+    a.file_name,
+    a.content_type,
+    octet_length(a.data)::int AS size_bytes
+FROM message_attachments a
+JOIN application_messages m ON a.message_id = m.id
+WHERE m.application_id = $1
+ORDER BY a.file_name;
+
+-- name: GetMessageAttachment :one
+SELECT
+    a.id,
+    a.file_name,
+    a.content_type,
+    a.data,
+    m.application_id
+FROM message_attachments a
+JOIN application_messages m ON a.message_id = m.id
+WHERE a.id = $1;
@@ -0,0 +1,43 @@
+-- name: UpsertOfferDraft :exec
+INSERT INTO offers
+(application_id, created_by, salary, start_date, expires_at, notes, status)
+VALUES
+($1, $2, $3, $4, $5, $6, 'draft')
+ON CONFLICT (application_id) DO UPDATE
+SET created_by = EXCLUDED.created_by,
+    salary = EXCLUDED.salary,
+    start_date = EXCLUDED.start_date,
+    expires_at = EXCLUDED.expires_at,
+    notes = EXCLUDED.notes,
+    status = 'draft',
+    sent_at = NULL,
+    responded_at = NULL;
+
+-- name: GetOfferByApplicationID :one
+SELECT *
+FROM offers
+WHERE application_id = $1;
+
+-- name: MarkOfferSent :exec
+UPDATE offers
+SET status = 'sent', sent_at = now()
+WHERE id = $1;
+
+-- name: UpdateOfferStatus :exec
+UPDATE offers
+SET status = $2, responded_at = now()
+WHERE id = $1;
+
+-- name: ListOffersForApplicant :many
+SELECT
+    o.application_id,
+    o.salary,
+    o.start_date,
+    o.expires_at,
+    o.status,
+    j.title AS job_title
+FROM offers o
+JOIN applications a ON o.application_id = a.id
+JOIN job_postings j ON a.job_posting_id = j.id
+WHERE a.user_id = $1 AND o.status <> 'draft'
+ORDER BY o.sent_at DESC;
@@ -0,0 +1,16 @@
+-- name: HitRateLimit :one
+-- Counts one request against the key, starting a new window when the current
+-- one began before window_cutoff.
+INSERT INTO rate_limits (key, window_start, hits)
+VALUES (sqlc.arg(key), now(), 1)
+ON CONFLICT (key) DO UPDATE
+SET hits = CASE WHEN rate_limits.window_start > sqlc.arg(window_cutoff)::timestamptz
+                THEN rate_limits.hits + 1 ELSE 1 END,
+    window_start = CASE WHEN rate_limits.window_start > sqlc.arg(window_cutoff)::timestamptz
+                        THEN rate_limits.window_start ELSE now() END
+RETURNING hits, window_start;
+
+-- name: DeleteExpiredRateLimits :execrows
+DELETE FROM rate_limits
+WHERE starts_with(key, sqlc.arg(key_prefix)::varchar)
+  AND window_start < sqlc.arg(before)::timestamptz;
@@ -0,0 +1,49 @@
+-- name: CreateScreeningQuestion :one
+INSERT INTO screening_questions
+(job_posting_id, position, prompt, kind, options, required, knockout, knockout_answers, knockout_min, knockout_max)
+VALUES
+($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id;
+
+-- name: ListScreeningQuestionsForJob :many
+SELECT *
+FROM screening_questions
+WHERE job_posting_id = $1
+ORDER BY position, prompt;
+
+-- name: DeleteScreeningQuestion :exec
+DELETE FROM screening_questions
+WHERE id = $1 AND job_posting_id = $2;
+
+-- name: CreateScreeningAnswer :exec
+INSERT INTO screening_answers
+(application_id, question_id, answer)
+VALUES
+($1, $2, $3)
+ON CONFLICT (application_id, question_id) DO UPDATE
+SET answer = EXCLUDED.answer;
+
+-- name: ListScreeningAnswersForJob :many
+SELECT
+    sa.application_id,
+    q.prompt,
+    sa.answer
+FROM screening_answers sa
+JOIN screening_questions q ON sa.question_id = q.id
+WHERE q.job_posting_id = $1
+ORDER BY q.position, q.prompt;
+
+-- name: ListScreeningAnswersForApplication :many
+SELECT
+    q.prompt,
+    q.kind,
+    sa.answer
+FROM screening_answers sa
+JOIN screening_questions q ON sa.question_id = q.id
+WHERE sa.application_id = $1
+ORDER BY q.position, q.prompt;
+
+-- name: ListScreeningAnswersForApplications :many
+SELECT application_id, question_id, answer
+FROM screening_answers
+WHERE application_id = ANY(sqlc.arg(application_ids)::uuid[]);
@@ -0,0 +1,65 @@
+-- name: GetUserSession :one
+-- Sessions of suspended users, and of users whose role has changed since they
+-- signed in, are treated as gone.
+SELECT s.id, s.user_id, s.data, s.last_seen_at
+FROM user_sessions s
+LEFT JOIN users u ON s.user_id = u.id
+WHERE s.token_hash = sqlc.arg(token_hash)
+  AND s.expires_at > now()
+  AND s.last_seen_at > sqlc.arg(seen_after)::timestamptz
+  AND (s.user_id IS NULL OR (u.suspended_at IS NULL AND u.role = s.user_role));
+
+-- name: CreateUserSession :exec
+INSERT INTO user_sessions
+(token_hash, user_id, user_role, data, ip_address, user_agent, expires_at)
+VALUES
+(sqlc.arg(token_hash), sqlc.narg(user_id), (SELECT role FROM users WHERE id = sqlc.narg(user_id)),
+ sqlc.arg(data), sqlc.arg(ip_address), sqlc.arg(user_agent), sqlc.arg(expires_at));
+
+-- name: UpdateUserSession :execrows
+-- Matches only while the session belongs to the same user, so signing in or
+-- out makes the caller issue a fresh token instead.
+UPDATE user_sessions
+SET data = sqlc.arg(data),
+    expires_at = sqlc.arg(expires_at),
+    ip_address = sqlc.arg(ip_address),
+    user_agent = sqlc.arg(user_agent),
+    last_seen_at = now()
+WHERE token_hash = sqlc.arg(token_hash)
+  AND user_id IS NOT DISTINCT FROM sqlc.narg(user_id);
+
+-- name: TouchUserSession :exec
+-- Only writes once a minute per session, like TouchAPIKey.
+UPDATE user_sessions
+SET last_seen_at = now(), ip_address = $2, user_agent = $3
+WHERE token_hash = $1
+  AND last_seen_at < now() - interval '1 minute';
+
+-- name: DeleteUserSessionByToken :exec
+DELETE FROM user_sessions
+WHERE token_hash = $1;
+
+-- name: ListUserSessions :many
+SELECT id, token_hash, ip_address, user_agent, created_at, last_seen_at
+FROM user_sessions
+WHERE user_id = sqlc.arg(user_id)
+  AND expires_at > now()
+  AND last_seen_at > sqlc.arg(seen_after)::timestamptz
+ORDER BY last_seen_at DESC;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM user_sessions
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteOtherUserSessions :execrows
+DELETE FROM user_sessions
+WHERE user_id = $1 AND token_hash <> $2;
+
+-- name: DeleteAllUserSessions :exec
+DELETE FROM user_sessions
+WHERE user_id = $1;
+
+-- name: DeleteExpiredUserSessions :execrows
+DELETE FROM user_sessions
+WHERE expires_at <= now()
+   OR last_seen_at <= sqlc.arg(seen_before)::timestamptz;
@@ -32,4 +32,31 @@ JOIN user_skills us ON u.id = us.user_id
 WHERE u.role = 'applicant'                    
 AND us.skill_id = ANY(sqlc.arg(skill_ids)::uuid[]) 
 GROUP BY u.id, u.name, u.email, u.role         
-HAVING COUNT(DISTINCT us.skill_id) = sqlc.arg(num_skills)::int; 
\ No newline at end of file
+HAVING COUNT(DISTINCT us.skill_id) = sqlc.arg(num_skills)::int; 
+-- name: AddSkillToJobPosting :exec
+INSERT INTO job_posting_skills (job_posting_id, skill_id)
+VALUES ($1, $2)
+ON CONFLICT (job_posting_id, skill_id) DO NOTHING;
+
+-- name: ListJobPostingSkills :many
+SELECT s.id, s.name
+FROM skills s
+JOIN job_posting_skills js ON s.id = js.skill_id
+WHERE js.job_posting_id = $1
+ORDER BY s.name;
+
+-- name: ListApplicantsForExport :many
+SELECT
+    u.id,
+    u.name,
+    u.email,
+    COALESCE((
+        SELECT string_agg(s.name, '; ' ORDER BY s.name)
+        FROM user_skills us2
+        JOIN skills s ON s.id = us2.skill_id
+        WHERE us2.user_id = u.id
+    ), '')::text AS skills,
+    u.parsed_resume
+FROM users u
+WHERE u.id = ANY(sqlc.arg(user_ids)::uuid[])
+ORDER BY u.name;
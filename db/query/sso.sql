@@ -0,0 +1,79 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations
+(name, slug, email_domain)
+VALUES
+($1, $2, lower(sqlc.arg(email_domain)))
+RETURNING id;
+
+-- name: ListOrganizations :many
+SELECT o.id, o.name, o.slug, o.email_domain, o.domain_verified_at,
+       COALESCE(s.enabled, false) AS sso_enabled,
+       (SELECT COUNT(*) FROM users u WHERE u.organization_id = o.id) AS member_count
+FROM organizations o
+LEFT JOIN saml_connections s ON s.organization_id = o.id
+ORDER BY o.name;
+
+-- name: GetOrganization :one
+SELECT *
+FROM organizations
+WHERE id = $1;
+
+-- name: GetSAMLConnection :one
+SELECT *
+FROM saml_connections
+WHERE organization_id = $1;
+
+-- name: UpsertSAMLConnection :exec
+INSERT INTO saml_connections
+(organization_id, idp_metadata, email_attribute, name_attribute, enabled, allow_idp_initiated)
+VALUES
+($1, $2, $3, $4, $5, $6)
+ON CONFLICT (organization_id) DO UPDATE
+SET idp_metadata = EXCLUDED.idp_metadata,
+    email_attribute = EXCLUDED.email_attribute,
+    name_attribute = EXCLUDED.name_attribute,
+    enabled = EXCLUDED.enabled,
+    allow_idp_initiated = EXCLUDED.allow_idp_initiated,
+    updated_at = now();
+
+-- name: GetEnabledSAMLConnectionBySlug :one
+SELECT o.id, o.name, o.slug, o.email_domain, o.domain_verified_at,
+       s.idp_metadata, s.email_attribute, s.name_attribute, s.allow_idp_initiated
+FROM organizations o
+JOIN saml_connections s ON s.organization_id = o.id
+WHERE o.slug = $1 AND s.enabled;
+
+-- name: GetSSOOrganizationSlugByDomain :one
+SELECT o.slug
+FROM organizations o
+JOIN saml_connections s ON s.organization_id = o.id
+WHERE o.email_domain = lower(sqlc.arg(email_domain))
+  AND o.domain_verified_at IS NOT NULL
+  AND s.enabled;
+
+-- name: CreateSSOUser :one
+-- Just-in-time provisioning: the IdP vouches for the address, so it starts
+-- out verified.
+INSERT INTO users
+(google_id, email, name, role, organization_id, email_verified_at)
+VALUES
+('saml:' || gen_random_uuid()::text, sqlc.arg(email), sqlc.arg(name), 'recruiter', sqlc.arg(organization_id), now())
+RETURNING id;
+
+-- name: GetSSOLinkCandidate :one
+-- The existing account, if any, that an SSO login with this address would
+-- sign in to.
+SELECT id, role, organization_id
+FROM users
+WHERE lower(email) = lower($1)
+LIMIT 1;
+
+-- name: MarkOrganizationDomainVerified :exec
+UPDATE organizations
+SET domain_verified_at = now()
+WHERE id = $1;
+
+-- name: GetOrganizationBySlug :one
+SELECT *
+FROM organizations
+WHERE slug = $1;
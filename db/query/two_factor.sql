@@ -0,0 +1,68 @@
+-- name: GetUserTwoFactor :one
+SELECT id, email, role, totp_secret, totp_enabled_at, totp_locked_until
+FROM users
+WHERE id = $1;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_secret = sqlc.arg(totp_secret)::varchar,
+    totp_enabled_at = now(),
+    totp_last_step = sqlc.arg(totp_last_step)::bigint
+WHERE id = sqlc.arg(id);
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled_at = NULL,
+    totp_last_step = NULL,
+    totp_failed_attempts = 0,
+    totp_locked_until = NULL
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+-- Records the time step of an accepted code; matches nothing when that step
+-- (or a later one) was already used, so each code works once.
+UPDATE users
+SET totp_last_step = sqlc.arg(step)::bigint
+WHERE id = sqlc.arg(id)
+  AND totp_enabled_at IS NOT NULL
+  AND (totp_last_step IS NULL OR totp_last_step < sqlc.arg(step)::bigint);
+
+-- name: RecordTwoFactorFailure :one
+-- Counts an incorrect code. Once the count reaches max_attempts the account is
+-- locked until locked_until and the count starts over.
+UPDATE users
+SET totp_failed_attempts = CASE
+        WHEN totp_failed_attempts + 1 >= sqlc.arg(max_attempts)::integer THEN 0
+        ELSE totp_failed_attempts + 1
+    END,
+    totp_locked_until = CASE
+        WHEN totp_failed_attempts + 1 >= sqlc.arg(max_attempts)::integer THEN sqlc.arg(locked_until)::timestamptz
+        ELSE totp_locked_until
+    END
+WHERE id = sqlc.arg(id)
+RETURNING totp_locked_until;
+
+-- name: ResetTwoFactorFailures :exec
+UPDATE users
+SET totp_failed_attempts = 0,
+    totp_locked_until = NULL
+WHERE id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM user_recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
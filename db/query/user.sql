@@ -48,3 +48,72 @@ WHERE id = $1;
 UPDATE users
 SET parsed_resume = $2
 WHERE id = $1;
+
+-- name: GetUserByEmail :one
+SELECT id, name, email, role
+FROM users
+WHERE lower(email) = lower($1)
+LIMIT 1;
+
+-- name: UpsertImportedCandidate :one
+INSERT INTO users
+(google_id, email, name, role, external_id, imported_by)
+VALUES
+('import:' || gen_random_uuid()::text, sqlc.arg(email), sqlc.arg(name), 'applicant', sqlc.arg(external_id), sqlc.arg(imported_by))
+ON CONFLICT (imported_by, external_id) DO UPDATE
+SET email = EXCLUDED.email,
+    name = EXCLUDED.name
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: ClaimImportedUser :one
+UPDATE users
+SET google_id = sqlc.arg(google_id)
+WHERE lower(email) = lower(sqlc.arg(email)) AND google_id LIKE 'import:%'
+RETURNING id, google_id, email, name, role;
+
+-- name: CreatePasswordUser :one
+INSERT INTO users
+(google_id, email, name, role, password_hash)
+VALUES
+('password:' || gen_random_uuid()::text, sqlc.arg(email), sqlc.arg(name), sqlc.arg(role), sqlc.arg(password_hash))
+RETURNING id, name, email;
+
+-- name: GetPasswordLogin :one
+SELECT id, email, password_hash, email_verified_at
+FROM users
+WHERE lower(email) = lower($1)
+LIMIT 1;
+
+-- name: SetUserPassword :exec
+-- Resetting a password through an emailed link also proves the address.
+UPDATE users
+SET password_hash = $2,
+    email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1;
+
+-- name: MarkUserEmailVerified :exec
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1;
+
+-- name: IsUserSuspended :one
+SELECT (suspended_at IS NOT NULL)::boolean AS suspended
+FROM users
+WHERE id = $1;
+
+-- name: ListUsersForAdmin :many
+SELECT id, name, email, role, suspended_at, totp_enabled_at
+FROM users
+WHERE google_id NOT LIKE 'import:%'
+ORDER BY lower(name)
+LIMIT 500;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2
+WHERE id = $1 AND role <> $2;
+
+-- name: SetUserSuspended :execrows
+UPDATE users
+SET suspended_at = CASE WHEN sqlc.arg(suspended)::bool THEN COALESCE(suspended_at, now()) END
+WHERE id = sqlc.arg(id);
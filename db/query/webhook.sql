@@ -0,0 +1,101 @@
+-- name: CreateWebhookEndpoint :one
+-- The endpoint is registered for the recruiter's organization, if they have one.
+INSERT INTO webhook_endpoints
+(recruiter_id, organization_id, url, secret, events)
+SELECT u.id, u.organization_id, sqlc.arg(url)::text, sqlc.arg(secret)::varchar, sqlc.arg(events)::text[]
+FROM users u
+WHERE u.id = sqlc.arg(recruiter_id)::uuid
+RETURNING id;
+
+-- name: ListWebhookEndpoints :many
+-- The endpoints a recruiter manages: their organization's, and any they
+-- registered without one.
+SELECT e.*
+FROM webhook_endpoints e
+WHERE (e.organization_id = (SELECT u.organization_id FROM users u WHERE u.id = sqlc.arg(recruiter_id))
+       OR (e.organization_id IS NULL AND e.recruiter_id = sqlc.arg(recruiter_id)))
+ORDER BY e.created_at;
+
+-- name: GetWebhookEndpoint :one
+SELECT e.*
+FROM webhook_endpoints e
+WHERE e.id = sqlc.arg(id)
+  AND (e.organization_id = (SELECT u.organization_id FROM users u WHERE u.id = sqlc.arg(recruiter_id))
+       OR (e.organization_id IS NULL AND e.recruiter_id = sqlc.arg(recruiter_id)));
+
+-- name: SetWebhookEndpointActive :exec
+UPDATE webhook_endpoints e
+SET active = sqlc.arg(active)
+WHERE e.id = sqlc.arg(id)
+  AND (e.organization_id = (SELECT u.organization_id FROM users u WHERE u.id = sqlc.arg(recruiter_id))
+       OR (e.organization_id IS NULL AND e.recruiter_id = sqlc.arg(recruiter_id)));
+
+-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints e
+WHERE e.id = sqlc.arg(id)
+  AND (e.organization_id = (SELECT u.organization_id FROM users u WHERE u.id = sqlc.arg(recruiter_id))
+       OR (e.organization_id IS NULL AND e.recruiter_id = sqlc.arg(recruiter_id)));
+
+-- name: EnqueueWebhookDeliveries :many
+-- Fans the event out to the endpoints of the organization of recruiter_id,
+-- who owns the job, or to their own endpoints when they have none.
+INSERT INTO webhook_deliveries (endpoint_id, event, payload)
+SELECT e.id, sqlc.arg(event), sqlc.arg(payload)
+FROM webhook_endpoints e
+WHERE (e.organization_id = (SELECT u.organization_id FROM users u WHERE u.id = sqlc.arg(recruiter_id))
+       OR (e.organization_id IS NULL AND e.recruiter_id = sqlc.arg(recruiter_id)))
+  AND e.active
+  AND sqlc.arg(event)::text = ANY(e.events)
+RETURNING id;
+
+-- name: CreateWebhookRedelivery :one
+INSERT INTO webhook_deliveries (endpoint_id, event, payload)
+SELECT d.endpoint_id, d.event, d.payload
+FROM webhook_deliveries d
+WHERE d.id = $1 AND d.endpoint_id = $2
+RETURNING id;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Pushes next_attempt_at forward as a lease, so other instances skip these
+-- deliveries while this one sends them. Deliveries to disabled endpoints stay
+-- pending and go out if the endpoint is enabled again.
+UPDATE webhook_deliveries d
+SET next_attempt_at = now() + interval '5 minutes'
+FROM webhook_endpoints e
+WHERE d.endpoint_id = e.id
+  AND e.active
+  AND d.id IN (
+    SELECT pending.id
+    FROM webhook_deliveries pending
+    JOIN webhook_endpoints pe ON pe.id = pending.endpoint_id
+    WHERE pending.status = 'pending' AND pending.next_attempt_at <= now() AND pe.active
+    ORDER BY pending.next_attempt_at
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE OF pending SKIP LOCKED
+  )
+RETURNING d.id, d.event, d.payload, d.attempts, e.url, e.secret;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = now()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+    attempts = attempts + 1,
+    last_status_code = sqlc.narg(last_status_code),
+    last_error = sqlc.arg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at)
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+SELECT id, event, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at
+FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY created_at DESC
+LIMIT 50;
@@ -0,0 +1,700 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	RecommendationStrongYes = "strong_yes"
+	RecommendationYes       = "yes"
+	RecommendationNo        = "no"
+	RecommendationStrongNo  = "strong_no"
+
+	minScore = 1
+	maxScore = 5
+)
+
+var recommendations = []string{RecommendationStrongYes, RecommendationYes, RecommendationNo, RecommendationStrongNo}
+
+var recommendationLabels = map[string]string{
+	RecommendationStrongYes: "Strong Yes",
+	RecommendationYes:       "Yes",
+	RecommendationNo:        "No",
+	RecommendationStrongNo:  "Strong No",
+}
+
+// loadEvaluationJob loads the :jobID posting for a recruiter who either owns it
+// or has been added to it as an interviewer. Only owners can manage the
+// scorecard and the interviewer list.
+func (app *App) loadEvaluationJob(c *gin.Context, recruiterID pgtype.UUID) (db.GetJobPostingByIDRow, bool, bool) {
+	jobIDStr := c.Param("jobID")
+	jobUUID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		app.renderError(c, http.StatusBadRequest, "Invalid Job ID format")
+		return db.GetJobPostingByIDRow{}, false, false
+	}
+
+	job, err := app.db.GetJobPostingByID(c.Request.Context(), pgtype.UUID{Bytes: jobUUID, Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.String(http.StatusNotFound, "Job posting not found")
+		} else {
+			fmt.Printf("Evaluation: DB error fetching job %s: %v\n", jobIDStr, err)
+			c.String(http.StatusInternalServerError, "Error fetching job data")
+		}
+		return db.GetJobPostingByIDRow{}, false, false
+	}
+
+	if job.RecruiterID.Valid && job.RecruiterID.Bytes == recruiterID.Bytes {
+		return job, true, true
+	}
+
+	isInterviewer, err := app.db.IsJobInterviewer(c.Request.Context(), db.IsJobInterviewerParams{
+		JobPostingID: job.ID,
+		UserID:       recruiterID,
+	})
+	if err != nil {
+		fmt.Printf("Evaluation: DB error checking interviewer for job %s: %v\n", jobIDStr, err)
+	}
+	if !isInterviewer {
+		app.renderError(c, http.StatusForbidden, "Forbidden: You are not on the hiring team for this job posting")
+		return db.GetJobPostingByIDRow{}, false, false
+	}
+	return job, false, true
+}
+
+// loadEvaluationApplication loads the :applicationID application and checks it
+// belongs to the given job.
+func (app *App) loadEvaluationApplication(c *gin.Context, job db.GetJobPostingByIDRow) (db.GetApplicationByIDRow, bool) {
+	appUUID, err := uuid.Parse(c.Param("applicationID"))
+	if err != nil {
+		app.renderError(c, http.StatusBadRequest, "Invalid Application ID format")
+		return db.GetApplicationByIDRow{}, false
+	}
+
+	application, err := app.db.GetApplicationByID(c.Request.Context(), pgtype.UUID{Bytes: appUUID, Valid: true})
+	if err != nil || application.JobPostingID.Bytes != job.ID.Bytes {
+		c.String(http.StatusNotFound, "Application not found.")
+		return db.GetApplicationByIDRow{}, false
+	}
+	return application, true
+}
+
+func evaluationURL(jobID, applicationID pgtype.UUID) string {
+	return fmt.Sprintf("/recruiter/jobs/%s/applications/%s/evaluation", uuid.UUID(jobID.Bytes).String(), uuid.UUID(applicationID.Bytes).String())
+}
+
+// stars draws a rating out of maxScore, rounded to whole stars.
+func stars(rating float64) string {
+	full := int(rating + 0.5)
+	return strings.Repeat("\u2605", full) + strings.Repeat("\u2606", maxScore-full)
+}
+
+// scoreScale lists the values a rating or criterion score can take.
+func scoreScale() []int {
+	scale := make([]int, 0, maxScore-minScore+1)
+	for value := minScore; value <= maxScore; value++ {
+		scale = append(scale, value)
+	}
+	return scale
+}
+
+// getScorecardSetupHandler lets the job owner define scorecard criteria and
+// choose which recruiters interview for the job.
+func (app *App) getScorecardSetupHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+
+	criteria, err := app.db.ListScorecardCriteria(c.Request.Context(), job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Scorecard Setup GET: DB error fetching criteria for job %s: %v\n", jobIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading scorecard criteria.")
+		return
+	}
+	interviewers, err := app.db.ListJobInterviewers(c.Request.Context(), job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Scorecard Setup GET: DB error fetching interviewers for job %s: %v\n", jobIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading interviewers.")
+		return
+	}
+
+	app.render(c, http.StatusOK, "scorecard.html", "Scorecard: "+job.Title, gin.H{
+		"Job":          job,
+		"Criteria":     criteria,
+		"Interviewers": interviewers,
+		"Error":        c.Query("error"),
+		"CSRF":         csrfToken(c),
+	})
+}
+
+func (app *App) postScorecardCriterionHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	setupURL := fmt.Sprintf("/recruiter/jobs/%s/scorecard", uuid.UUID(job.ID.Bytes).String())
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		c.Redirect(http.StatusSeeOther, setupURL)
+		return
+	}
+	description := strings.TrimSpace(c.PostForm("description"))
+	position, _ := strconv.Atoi(c.PostForm("position"))
+
+	err := app.db.CreateScorecardCriterion(c.Request.Context(), db.CreateScorecardCriterionParams{
+		JobPostingID: job.ID,
+		Position:     int32(position),
+		Name:         name,
+		Description:  pgtype.Text{String: description, Valid: description != ""},
+	})
+	if err != nil {
+		fmt.Printf("Scorecard Criterion POST: DB error for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save criterion.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, setupURL)
+}
+
+func (app *App) deleteScorecardCriterionHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+
+	criterionUUID, err := uuid.Parse(c.Param("criterionID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid criterion ID.")
+		return
+	}
+	err = app.db.DeleteScorecardCriterion(c.Request.Context(), db.DeleteScorecardCriterionParams{
+		ID:           pgtype.UUID{Bytes: criterionUUID, Valid: true},
+		JobPostingID: job.ID,
+	})
+	if err != nil {
+		fmt.Printf("Scorecard Criterion Delete: DB error for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to remove criterion.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("/recruiter/jobs/%s/scorecard", uuid.UUID(job.ID.Bytes).String()))
+}
+
+func (app *App) postJobInterviewerHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	setupURL := fmt.Sprintf("/recruiter/jobs/%s/scorecard", uuid.UUID(job.ID.Bytes).String())
+
+	email := strings.TrimSpace(c.PostForm("email"))
+	user, err := app.db.GetUserByEmail(c.Request.Context(), email)
+	if err != nil || user.Role != RoleRecruiter {
+		c.Redirect(http.StatusSeeOther, setupURL+"?error="+url.QueryEscape("No recruiter account found for "+email+"."))
+		return
+	}
+	if user.ID.Bytes == recruiter.ID.Bytes {
+		c.Redirect(http.StatusSeeOther, setupURL)
+		return
+	}
+
+	err = app.db.AddJobInterviewer(c.Request.Context(), db.AddJobInterviewerParams{
+		JobPostingID: job.ID,
+		UserID:       user.ID,
+	})
+	if err != nil {
+		fmt.Printf("Job Interviewer POST: DB error for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to add interviewer.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, setupURL)
+}
+
+func (app *App) deleteJobInterviewerHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+
+	userUUID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid user ID.")
+		return
+	}
+	err = app.db.RemoveJobInterviewer(c.Request.Context(), db.RemoveJobInterviewerParams{
+		JobPostingID: job.ID,
+		UserID:       pgtype.UUID{Bytes: userUUID, Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("Job Interviewer Delete: DB error for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to remove interviewer.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("/recruiter/jobs/%s/scorecard", uuid.UUID(job.ID.Bytes).String()))
+}
+
+// getJobEvaluationsHandler lists a job's candidates with their average rating
+// for everyone on the hiring team.
+func (app *App) getJobEvaluationsHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, isOwner, ok := app.loadEvaluationJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+
+	applications, err := app.db.GetApplicationsForJobPosting(c.Request.Context(), job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Job Evaluations GET: DB error fetching applications for job %s: %v\n", jobIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading applications.")
+		return
+	}
+	summaries, err := app.db.ListRatingSummariesForJob(c.Request.Context(), job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Job Evaluations GET: DB error fetching ratings for job %s: %v\n", jobIDStr, err)
+	}
+	ratingsByApplication := make(map[[16]byte]db.ListRatingSummariesForJobRow)
+	for _, summary := range summaries {
+		ratingsByApplication[summary.ApplicationID.Bytes] = summary
+	}
+
+	rows := make([]evaluationRow, 0, len(applications))
+	for _, application := range applications {
+		summary, rated := ratingsByApplication[application.ApplicationID.Bytes]
+		rows = append(rows, evaluationRow{
+			Application: application,
+			Status:      applicationStatusLabels[application.ApplicationStatus],
+			Rated:       rated,
+			Rating:      summary,
+		})
+	}
+
+	app.render(c, http.StatusOK, "job_evaluations.html", "Evaluations: "+job.Title, gin.H{
+		"Job":          job,
+		"Applications": rows,
+		"IsOwner":      isOwner,
+	})
+}
+
+// getApplicationEvaluationHandler shows the internal notes, ratings and
+// scorecards for an application. Interviewers only see other people's
+// scorecards once they have submitted their own, so each score is given
+// independently; the job owner always sees them.
+func (app *App) getApplicationEvaluationHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, isOwner, ok := app.loadEvaluationJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	application, ok := app.loadEvaluationApplication(c, job)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+	pageURL := evaluationURL(job.ID, application.ID)
+
+	criteria, err := app.db.ListScorecardCriteria(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Evaluation GET: DB error fetching criteria for job %s: %v\n", jobIDStr, err)
+	}
+	scorecards, err := app.db.ListScorecardsForApplication(ctx, application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Evaluation GET: DB error fetching scorecards for application %s: %v\n", applicationIDStr, err)
+	}
+	scores, err := app.db.ListScorecardScoresForApplication(ctx, application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Evaluation GET: DB error fetching scores for application %s: %v\n", applicationIDStr, err)
+	}
+
+	var ownScorecard *db.ListScorecardsForApplicationRow
+	for i := range scorecards {
+		if scorecards[i].InterviewerID.Bytes == recruiter.ID.Bytes {
+			ownScorecard = &scorecards[i]
+		}
+	}
+	scoresByCard := make(map[[16]byte][]db.ScorecardScore)
+	for _, score := range scores {
+		scoresByCard[score.ScorecardID.Bytes] = append(scoresByCard[score.ScorecardID.Bytes], score)
+	}
+
+	app.render(c, http.StatusOK, "application_evaluation.html", "Evaluation: "+application.ApplicantName, gin.H{
+		"Job":         job,
+		"Application": application,
+		"Status":      applicationStatusLabels[application.Status],
+		"IsOwner":     isOwner,
+		"URL":         pageURL,
+		"Ratings":     app.evaluationRatings(ctx, application, recruiter.ID),
+		"Scorecards":  summarizeScorecards(criteria, scorecards, scoresByCard, isOwner || ownScorecard != nil),
+		"Form":        newScorecardForm(criteria, ownScorecard, scoresByCard),
+		"Notes":       app.applicationNotes(ctx, application),
+		"CSRF":        csrfToken(c),
+	})
+}
+
+// evaluationRow is an application as listed on the job's evaluations page.
+type evaluationRow struct {
+	Application db.GetApplicationsForJobPostingRow
+	Status      string
+	Rated       bool
+	Rating      db.ListRatingSummariesForJobRow
+}
+
+// evaluationRatings is every recruiter's rating of an application, with the
+// viewer's own rating preselected in the rating form.
+type evaluationRatings struct {
+	Ratings []ratingView
+	Average float64
+	Own     int
+	Scale   []int
+}
+
+type ratingView struct {
+	RaterName string
+	Rating    float64
+}
+
+func (app *App) evaluationRatings(ctx context.Context, application db.GetApplicationByIDRow, raterID pgtype.UUID) evaluationRatings {
+	ratings, err := app.db.ListApplicationRatings(ctx, application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Evaluation: DB error fetching ratings for application %s: %v\n", application.ID.String(), err)
+	}
+
+	result := evaluationRatings{Scale: scoreScale()}
+	total := 0
+	for _, rating := range ratings {
+		result.Ratings = append(result.Ratings, ratingView{RaterName: rating.RaterName, Rating: float64(rating.Rating)})
+		total += int(rating.Rating)
+		if rating.RaterID.Bytes == raterID.Bytes {
+			result.Own = int(rating.Rating)
+		}
+	}
+	if len(ratings) > 0 {
+		result.Average = float64(total) / float64(len(ratings))
+	}
+	return result
+}
+
+func (app *App) applicationNotes(ctx context.Context, application db.GetApplicationByIDRow) []db.ListApplicationNotesRow {
+	notes, err := app.db.ListApplicationNotes(ctx, application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Evaluation: DB error fetching notes for application %s: %v\n", application.ID.String(), err)
+	}
+	return notes
+}
+
+// scorecardSummary aggregates the submitted scorecards into an average per
+// criterion and a count per recommendation, followed by each scorecard.
+type scorecardSummary struct {
+	Count           int
+	Visible         bool
+	MaxScore        int
+	Criteria        []criterionSummary
+	Recommendations []recommendationCount
+	Scorecards      []scorecardView
+}
+
+type criterionSummary struct {
+	Name    string
+	Count   int
+	Average float64
+}
+
+type recommendationCount struct {
+	Label string
+	Count int
+}
+
+type scorecardView struct {
+	InterviewerName string
+	Recommendation  string
+	SubmittedAt     pgtype.Timestamptz
+	Scores          []scoreView
+	Summary         string
+}
+
+type scoreView struct {
+	Criterion string
+	Score     int
+	Comment   string
+}
+
+func summarizeScorecards(criteria []db.ListScorecardCriteriaRow, scorecards []db.ListScorecardsForApplicationRow, scoresByCard map[[16]byte][]db.ScorecardScore, visible bool) scorecardSummary {
+	summary := scorecardSummary{Count: len(scorecards), Visible: visible, MaxScore: maxScore}
+	if len(scorecards) == 0 || !visible {
+		return summary
+	}
+
+	totals := make(map[[16]byte]int)
+	counts := make(map[[16]byte]int)
+	for _, cardScores := range scoresByCard {
+		for _, score := range cardScores {
+			totals[score.CriterionID.Bytes] += int(score.Score)
+			counts[score.CriterionID.Bytes]++
+		}
+	}
+	criterionNames := make(map[[16]byte]string)
+	for _, criterion := range criteria {
+		criterionNames[criterion.ID.Bytes] = criterion.Name
+		row := criterionSummary{Name: criterion.Name, Count: counts[criterion.ID.Bytes]}
+		if row.Count > 0 {
+			row.Average = float64(totals[criterion.ID.Bytes]) / float64(row.Count)
+		}
+		summary.Criteria = append(summary.Criteria, row)
+	}
+
+	recommendationCounts := make(map[string]int)
+	for _, scorecard := range scorecards {
+		recommendationCounts[scorecard.Recommendation]++
+	}
+	for _, recommendation := range recommendations {
+		summary.Recommendations = append(summary.Recommendations, recommendationCount{
+			Label: recommendationLabels[recommendation],
+			Count: recommendationCounts[recommendation],
+		})
+	}
+
+	for _, scorecard := range scorecards {
+		view := scorecardView{
+			InterviewerName: scorecard.InterviewerName,
+			Recommendation:  recommendationLabels[scorecard.Recommendation],
+			SubmittedAt:     scorecard.SubmittedAt,
+			Summary:         scorecard.Summary.String,
+		}
+		for _, score := range scoresByCard[scorecard.ID.Bytes] {
+			name, found := criterionNames[score.CriterionID.Bytes]
+			if !found {
+				continue
+			}
+			view.Scores = append(view.Scores, scoreView{Criterion: name, Score: int(score.Score), Comment: score.Comment.String})
+		}
+		summary.Scorecards = append(summary.Scorecards, view)
+	}
+	return summary
+}
+
+// scorecardForm is the viewer's scorecard form, filled in with the scorecard
+// they already submitted, if any.
+type scorecardForm struct {
+	Submitted       bool
+	Criteria        []criterionInput
+	Recommendations []recommendationOption
+	Summary         string
+	Scale           []int
+}
+
+type criterionInput struct {
+	ID          pgtype.UUID
+	Name        string
+	Description string
+	Score       int
+	Comment     string
+}
+
+type recommendationOption struct {
+	Value   string
+	Label   string
+	Checked bool
+}
+
+func newScorecardForm(criteria []db.ListScorecardCriteriaRow, own *db.ListScorecardsForApplicationRow, scoresByCard map[[16]byte][]db.ScorecardScore) scorecardForm {
+	form := scorecardForm{Submitted: own != nil, Scale: scoreScale()}
+	ownScores := make(map[[16]byte]db.ScorecardScore)
+	ownRecommendation := ""
+	if own != nil {
+		for _, score := range scoresByCard[own.ID.Bytes] {
+			ownScores[score.CriterionID.Bytes] = score
+		}
+		ownRecommendation = own.Recommendation
+		form.Summary = own.Summary.String
+	}
+
+	for _, criterion := range criteria {
+		existing := ownScores[criterion.ID.Bytes]
+		form.Criteria = append(form.Criteria, criterionInput{
+			ID:          criterion.ID,
+			Name:        criterion.Name,
+			Description: criterion.Description.String,
+			Score:       int(existing.Score),
+			Comment:     existing.Comment.String,
+		})
+	}
+	for _, recommendation := range recommendations {
+		form.Recommendations = append(form.Recommendations, recommendationOption{
+			Value:   recommendation,
+			Label:   recommendationLabels[recommendation],
+			Checked: recommendation == ownRecommendation,
+		})
+	}
+	return form
+}
+
+func (app *App) postApplicationNoteHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, _, ok := app.loadEvaluationJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	application, ok := app.loadEvaluationApplication(c, job)
+	if !ok {
+		return
+	}
+
+	body := strings.TrimSpace(c.PostForm("body"))
+	if body != "" {
+		err := app.db.CreateApplicationNote(c.Request.Context(), db.CreateApplicationNoteParams{
+			ApplicationID: application.ID,
+			AuthorID:      recruiter.ID,
+			Body:          body,
+		})
+		if err != nil {
+			fmt.Printf("Note POST: DB error for application %s: %v\n", application.ID.String(), err)
+			c.String(http.StatusInternalServerError, "Failed to save note.")
+			return
+		}
+	}
+	c.Redirect(http.StatusSeeOther, evaluationURL(job.ID, application.ID))
+}
+
+func (app *App) postApplicationRatingHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, _, ok := app.loadEvaluationJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	application, ok := app.loadEvaluationApplication(c, job)
+	if !ok {
+		return
+	}
+
+	rating, err := strconv.Atoi(c.PostForm("rating"))
+	if err != nil || rating < minScore || rating > maxScore {
+		c.String(http.StatusBadRequest, "Rating must be between %d and %d.", minScore, maxScore)
+		return
+	}
+
+	err = app.db.UpsertApplicationRating(c.Request.Context(), db.UpsertApplicationRatingParams{
+		ApplicationID: application.ID,
+		RaterID:       recruiter.ID,
+		Rating:        int32(rating),
+	})
+	if err != nil {
+		fmt.Printf("Rating POST: DB error for application %s: %v\n", application.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save rating.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, evaluationURL(job.ID, application.ID))
+}
+
+func (app *App) postScorecardHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, _, ok := app.loadEvaluationJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	application, ok := app.loadEvaluationApplication(c, job)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	recommendation := c.PostForm("recommendation")
+	if _, known := recommendationLabels[recommendation]; !known {
+		c.String(http.StatusBadRequest, "Please choose a recommendation.")
+		return
+	}
+
+	criteria, err := app.db.ListScorecardCriteria(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Scorecard POST: DB error fetching criteria for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save scorecard.")
+		return
+	}
+
+	scoreParams := make([]db.UpsertScorecardScoreParams, 0, len(criteria))
+	for _, criterion := range criteria {
+		criterionIDStr := uuid.UUID(criterion.ID.Bytes).String()
+		score, err := strconv.Atoi(c.PostForm("score_" + criterionIDStr))
+		if err != nil || score < minScore || score > maxScore {
+			c.String(http.StatusBadRequest, "Please score %q between %d and %d.", criterion.Name, minScore, maxScore)
+			return
+		}
+		comment := strings.TrimSpace(c.PostForm("comment_" + criterionIDStr))
+		scoreParams = append(scoreParams, db.UpsertScorecardScoreParams{
+			CriterionID: criterion.ID,
+			Score:       int32(score),
+			Comment:     pgtype.Text{String: comment, Valid: comment != ""},
+		})
+	}
+
+	summary := strings.TrimSpace(c.PostForm("summary"))
+	scorecardID, err := app.db.UpsertScorecard(ctx, db.UpsertScorecardParams{
+		ApplicationID:  application.ID,
+		InterviewerID:  recruiter.ID,
+		Recommendation: recommendation,
+		Summary:        pgtype.Text{String: summary, Valid: summary != ""},
+	})
+	if err != nil {
+		fmt.Printf("Scorecard POST: DB error for application %s: %v\n", application.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save scorecard.")
+		return
+	}
+	for _, params := range scoreParams {
+		params.ScorecardID = scorecardID
+		if err := app.db.UpsertScorecardScore(ctx, params); err != nil {
+			fmt.Printf("Scorecard POST: DB error saving score for application %s: %v\n", application.ID.String(), err)
+		}
+	}
+
+	c.Redirect(http.StatusSeeOther, evaluationURL(job.ID, application.ID))
+}
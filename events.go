@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	EventApplicationCreated       = "application.created"
+	EventApplicationStatusChanged = "application.status_changed"
+	EventInterviewUpdated         = "interview.updated"
+
+	// Postgres channel used to fan events out to every running instance.
+	eventsChannel = "recruitment_events"
+)
+
+type appEvent struct {
+	Type          string    `json:"type"`
+	ApplicationID string    `json:"application_id,omitempty"`
+	JobPostingID  string    `json:"job_posting_id,omitempty"`
+	JobTitle      string    `json:"job_title,omitempty"`
+	ApplicantID   string    `json:"applicant_id,omitempty"`
+	ApplicantName string    `json:"applicant_name,omitempty"`
+	RecruiterID   string    `json:"recruiter_id,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// visibleTo reports whether the event concerns the given user, either as the
+// applicant or as the recruiter owning the job posting.
+func (e appEvent) visibleTo(userID string) bool {
+	return userID != "" && (e.ApplicantID == userID || e.RecruiterID == userID)
+}
+
+// eventBroker is an in-process pub/sub for dashboard updates. When pool is set,
+// Publish goes through Postgres NOTIFY and Run delivers what LISTEN receives,
+// so subscribers on every instance see every event.
+type eventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[chan appEvent]struct{}
+	pool        *pgxpool.Pool
+}
+
+func newEventBroker(pool *pgxpool.Pool) *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[chan appEvent]struct{}),
+		pool:        pool,
+	}
+}
+
+func (b *eventBroker) Subscribe() chan appEvent {
+	ch := make(chan appEvent, 16)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *eventBroker) Unsubscribe(ch chan appEvent) {
+	b.mu.Lock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+}
+
+// broadcast hands the event to local subscribers. Slow subscribers miss events
+// rather than blocking the publisher.
+func (b *eventBroker) broadcast(ev appEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (b *eventBroker) Publish(ctx context.Context, ev appEvent) {
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+	if b.pool == nil {
+		b.broadcast(ev)
+		return
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		fmt.Printf("Events: Failed to marshal %s event: %v\n", ev.Type, err)
+		return
+	}
+	if _, err := b.pool.Exec(ctx, "SELECT pg_notify($1, $2)", eventsChannel, string(payload)); err != nil {
+		fmt.Printf("Events: pg_notify failed, delivering %s locally only: %v\n", ev.Type, err)
+		b.broadcast(ev)
+	}
+}
+
+// Run listens for events published by any instance until ctx is cancelled,
+// reconnecting if the listening connection drops.
+func (b *eventBroker) Run(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("Events: LISTEN connection lost, retrying in 5s: %v\n", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (b *eventBroker) listen(ctx context.Context) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+eventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var ev appEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &ev); err != nil {
+			fmt.Printf("Events: Ignoring malformed notification: %v\n", err)
+			continue
+		}
+		b.broadcast(ev)
+	}
+}
+
+// publishApplicationEvent loads the application and publishes an event about it
+// to the applicant and the recruiter who owns the job posting, and to the
+// recruiter's webhooks.
+func (app *App) publishApplicationEvent(ctx context.Context, eventType string, applicationID pgtype.UUID) {
+	application, err := app.db.GetApplicationByID(ctx, applicationID)
+	if err != nil {
+		fmt.Printf("Events: Failed to load application %s for %s event: %v\n", applicationID.String(), eventType, err)
+		return
+	}
+
+	app.events.Publish(ctx, appEvent{
+		Type:          eventType,
+		ApplicationID: uuid.UUID(application.ID.Bytes).String(),
+		JobPostingID:  uuid.UUID(application.JobPostingID.Bytes).String(),
+		JobTitle:      application.JobTitle,
+		ApplicantID:   uuid.UUID(application.UserID.Bytes).String(),
+		ApplicantName: application.ApplicantName,
+		RecruiterID:   uuid.UUID(application.RecruiterID.Bytes).String(),
+		Status:        application.Status,
+	})
+	app.enqueueApplicationWebhook(ctx, eventType, application)
+}
+
+func (app *App) eventStreamHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	userIDStr := uuid.UUID(pgID.Bytes).String()
+
+	ch := app.events.Subscribe()
+	defer app.events.Unsubscribe(ch)
+
+	keepAlive := time.NewTicker(25 * time.Second)
+	defer keepAlive.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", userIDStr)
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case ev, ok := <-ch:
+			if !ok {
+				return false
+			}
+			if ev.visibleTo(userIDStr) {
+				c.SSEvent(ev.Type, ev)
+			}
+			return true
+		case <-keepAlive.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+
+	// Excel refuses cells longer than this.
+	maxXLSXCellLength = 32767
+)
+
+// tableWriter streams rows of a spreadsheet-style export. Close must be called
+// to finish the file.
+type tableWriter interface {
+	WriteRow(cells []string) error
+	Flush() error
+	Close() error
+}
+
+// newTableWriter returns a writer for the export format, and the content type
+// to serve it with.
+func newTableWriter(format string, w io.Writer, sheetName string) (tableWriter, string, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvTableWriter{w: csv.NewWriter(w)}, "text/csv; charset=utf-8", nil
+	case ExportFormatXLSX:
+		writer, err := newXLSXTableWriter(w, sheetName)
+		return writer, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", err
+	}
+	return nil, "", fmt.Errorf("unsupported export format %q", format)
+}
+
+type csvTableWriter struct {
+	w *csv.Writer
+}
+
+func (t *csvTableWriter) WriteRow(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		// Stop spreadsheet apps from evaluating applicant-supplied text as a formula.
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cell = "'" + cell
+		}
+		escaped[i] = cell
+	}
+	return t.w.Write(escaped)
+}
+
+func (t *csvTableWriter) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+func (t *csvTableWriter) Close() error {
+	return t.Flush()
+}
+
+// xlsxTableWriter writes a single-sheet workbook with inline strings, so rows
+// can be streamed without holding a shared string table in memory.
+type xlsxTableWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+var xlsxStaticParts = []struct{ Name, Body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+func newXLSXTableWriter(w io.Writer, sheetName string) (*xlsxTableWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`, xmlEscape(xlsxSheetName(sheetName)))
+
+	// The worksheet is the last part, so it can stay open while rows arrive.
+	f, err = zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &xlsxTableWriter{zip: zw, sheet: sheet}, nil
+}
+
+func (t *xlsxTableWriter) WriteRow(cells []string) error {
+	t.rows++
+	fmt.Fprintf(t.sheet, `<row r="%d">`, t.rows)
+	for _, cell := range cells {
+		if len(cell) > maxXLSXCellLength {
+			cell = cell[:maxXLSXCellLength]
+		}
+		fmt.Fprintf(t.sheet, `<c t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(cell))
+	}
+	_, err := t.sheet.WriteString("</row>")
+	return err
+}
+
+func (t *xlsxTableWriter) Flush() error {
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	return t.zip.Flush()
+}
+
+func (t *xlsxTableWriter) Close() error {
+	t.sheet.WriteString("</sheetData></worksheet>")
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	return t.zip.Close()
+}
+
+// xmlEscape escapes text for XML, replacing characters XML cannot hold.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxSheetName trims a name to Excel's sheet name rules: at most 31
+// characters and none of []:*?/\.
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if strings.TrimSpace(name) == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+// resumeExportFields picks the commonly useful fields out of a parsed resume
+// for the export columns. The parsed resume has no fixed schema, so keys are
+// matched loosely.
+func resumeExportFields(parsedResume []byte) (skills, experience, education string) {
+	if len(parsedResume) == 0 {
+		return "", "", ""
+	}
+	var parsed map[string]any
+	if err := json.Unmarshal(parsedResume, &parsed); err != nil {
+		return "", "", ""
+	}
+
+	keys := make([]string, 0, len(parsed))
+	for key := range parsed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		normalized := strings.ToLower(key)
+		switch {
+		case skills == "" && strings.Contains(normalized, "skill"):
+			skills = flattenResumeValue(parsed[key])
+		case experience == "" && (strings.Contains(normalized, "experience") || strings.Contains(normalized, "employment")):
+			experience = flattenResumeValue(parsed[key])
+		case education == "" && strings.Contains(normalized, "education"):
+			education = flattenResumeValue(parsed[key])
+		}
+	}
+	return skills, experience, education
+}
+
+// flattenResumeValue renders a JSON value as one line of text: list items are
+// separated by "; " and object fields by ", ".
+func flattenResumeValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := flattenResumeValue(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "; ")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(v))
+		for _, key := range keys {
+			if text := flattenResumeValue(v[key]); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(value)
+}
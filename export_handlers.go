@@ -0,0 +1,217 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// exportPageSize is how many applications are loaded per query while an
+// export streams, so large jobs are never held in memory at once.
+const exportPageSize = 500
+
+// startExport writes the download headers and returns the table writer for
+// the requested format. When it returns false the response has already been
+// written.
+func startExport(c *gin.Context, filename, sheetName string) (tableWriter, bool) {
+	format := c.DefaultQuery("format", ExportFormatCSV)
+	writer, contentType, err := newTableWriter(format, c.Writer, sheetName)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Unsupported export format. Use csv or xlsx.")
+		return nil, false
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Status(http.StatusOK)
+	return writer, true
+}
+
+// exportFilename builds a download name like "backend-engineer-applications-2025-01-31".
+func exportFilename(name, kind string) string {
+	slug := newJobSlug(name)
+	// Drop the random suffix newJobSlug adds.
+	if i := strings.LastIndex(slug, "-"); i > 0 {
+		slug = slug[:i]
+	}
+	return fmt.Sprintf("%s-%s-%s", slug, kind, time.Now().Format("2006-01-02"))
+}
+
+// getJobApplicationsExportHandler downloads a job's applications with applicant
+// skills, parsed resume fields and one column per screening question.
+func (app *App) getJobApplicationsExportHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	questions, err := app.db.ListScreeningQuestionsForJob(ctx, job.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Applications Export: DB error fetching questions for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to export applications.")
+		return
+	}
+
+	writer, ok := startExport(c, exportFilename(job.Title, "applications"), "Applications")
+	if !ok {
+		return
+	}
+	header := []string{"Application ID", "Name", "Email", "Status", "Applied At", "Source", "Profile Skills", "Resume Skills", "Work Experience", "Education"}
+	for _, question := range questions {
+		header = append(header, question.Prompt)
+	}
+	writer.WriteRow(header)
+
+	afterAppliedAt := pgtype.Timestamptz{Time: time.Unix(0, 0), Valid: true}
+	afterID := pgtype.UUID{Valid: true}
+	exported := 0
+	for {
+		page, err := app.db.ListApplicationsForExport(ctx, db.ListApplicationsForExportParams{
+			JobPostingID:   job.ID,
+			AfterAppliedAt: afterAppliedAt,
+			AfterID:        afterID,
+			PageSize:       exportPageSize,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			// Headers are already sent, so the best we can do is end the file early.
+			fmt.Printf("Applications Export: DB error after %d rows for job %s: %v\n", exported, job.ID.String(), err)
+			break
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		applicationIDs := make([]pgtype.UUID, 0, len(page))
+		for _, application := range page {
+			applicationIDs = append(applicationIDs, application.ID)
+		}
+		answers, err := app.db.ListScreeningAnswersForApplications(ctx, applicationIDs)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("Applications Export: DB error fetching screening answers for job %s: %v\n", job.ID.String(), err)
+		}
+		answersByApplication := make(map[[16]byte]map[[16]byte]string, len(page))
+		for _, answer := range answers {
+			if answersByApplication[answer.ApplicationID.Bytes] == nil {
+				answersByApplication[answer.ApplicationID.Bytes] = make(map[[16]byte]string)
+			}
+			answersByApplication[answer.ApplicationID.Bytes][answer.QuestionID.Bytes] = answer.Answer
+		}
+
+		for _, application := range page {
+			resumeSkills, experience, education := resumeExportFields(application.ParsedResume)
+			row := []string{
+				uuid.UUID(application.ID.Bytes).String(),
+				application.ApplicantName,
+				application.ApplicantEmail,
+				applicationStatusLabels[application.Status],
+				application.AppliedAt.Time.Format(time.RFC3339),
+				application.Source,
+				application.Skills,
+				resumeSkills,
+				experience,
+				education,
+			}
+			for _, question := range questions {
+				row = append(row, answersByApplication[application.ID.Bytes][question.ID.Bytes])
+			}
+			writer.WriteRow(row)
+		}
+		exported += len(page)
+		if err := writer.Flush(); err != nil {
+			fmt.Printf("Applications Export: Client went away after %d rows for job %s: %v\n", exported, job.ID.String(), err)
+			return
+		}
+		c.Writer.Flush()
+
+		last := page[len(page)-1]
+		afterAppliedAt, afterID = last.AppliedAt, last.ID
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		fmt.Printf("Applications Export: Failed to finish export for job %s: %v\n", job.ID.String(), err)
+		return
+	}
+	fmt.Printf("Exported %d applications for job %s to recruiter %s\n", exported, job.ID.String(), recruiter.ID.String())
+}
+
+// getSkillSearchExportHandler downloads the applicants matching a skill
+// search, taking the same skill_id parameters as the results page.
+func (app *App) getSkillSearchExportHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	skillIDs := parseSkillIDs(c.QueryArray("skill_id"))
+	if len(skillIDs) == 0 {
+		c.String(http.StatusBadRequest, "Please select at least one skill to export.")
+		return
+	}
+	applicants, err := app.db.SearchApplicantsBySkills(ctx, db.SearchApplicantsBySkillsParams{
+		SkillIds:  skillIDs,
+		NumSkills: int32(len(skillIDs)),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Search Export: DB error searching applicants: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to export search results.")
+		return
+	}
+
+	writer, ok := startExport(c, exportFilename("skill search", "applicants"), "Applicants")
+	if !ok {
+		return
+	}
+	writer.WriteRow([]string{"Applicant ID", "Name", "Email", "Profile Skills", "Resume Skills", "Work Experience", "Education"})
+
+	for start := 0; start < len(applicants); start += exportPageSize {
+		end := min(start+exportPageSize, len(applicants))
+		userIDs := make([]pgtype.UUID, 0, end-start)
+		for _, applicant := range applicants[start:end] {
+			userIDs = append(userIDs, applicant.ID)
+		}
+		page, err := app.db.ListApplicantsForExport(ctx, userIDs)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("Search Export: DB error loading applicant details: %v\n", err)
+			break
+		}
+		for _, applicant := range page {
+			resumeSkills, experience, education := resumeExportFields(applicant.ParsedResume)
+			writer.WriteRow([]string{
+				uuid.UUID(applicant.ID.Bytes).String(),
+				applicant.Name,
+				applicant.Email,
+				applicant.Skills,
+				resumeSkills,
+				experience,
+				education,
+			})
+		}
+		if err := writer.Flush(); err != nil {
+			fmt.Printf("Search Export: Client went away: %v\n", err)
+			return
+		}
+		c.Writer.Flush()
+	}
+
+	if err := writer.Close(); err != nil {
+		fmt.Printf("Search Export: Failed to finish export: %v\n", err)
+		return
+	}
+	fmt.Printf("Exported %d search results to recruiter %s\n", len(applicants), recruiter.ID.String())
+}
@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -15,18 +14,12 @@ import (
 )
 
 func (app *App) homeHandler(c *gin.Context) {
-	session := sessions.Default(c)
-	userIDRaw := session.Get(sessionUserKey)
-	loggedIn := userIDRaw != nil
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	if loggedIn {
-		c.String(http.StatusOK, `<h1>Welcome Back!</h1><p><a href="/profile">Profile</a></p><p><a href="/logout">Logout</a></p>`)
-	} else {
-		c.String(http.StatusOK, `<h1>Welcome!</h1><p><a href="/auth/google">Login with Google</a></p>`)
-	}
-
+	app.render(c, http.StatusOK, "home.html", "", gin.H{
+		"Providers": app.authProviders,
+		"SSO":       app.saml != nil,
+	})
 }
+
 func (app *App) profileHandler(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -54,18 +47,7 @@ func (app *App) profileHandler(c *gin.Context) {
 		return
 	}
 
-	// Display user information
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, `
-        <h1>Profile</h1>
-        <p>Welcome, %s!</p>
-        <p>Email: %s</p>
-        <p>Your Role: <strong>%s</strong></p>
-        <p>Google ID: %s</p>
-        <p><a href="/">Home</a></p>
-        <p><a href="/logout">Logout</a></p>
-		<p><a href="/dashboard">Dashboard</a></p>
-    `, user.Name, user.Email, user.Role, user.GoogleID)
+	app.render(c, http.StatusOK, "profile.html", "Profile", user)
 }
 
 func (app *App) dashboardRedirectHandler(c *gin.Context) {
@@ -96,6 +78,9 @@ func (app *App) dashboardRedirectHandler(c *gin.Context) {
 	} else if user.Role == RoleApplicant {
 		c.Redirect(http.StatusTemporaryRedirect, "/applicant/dashboard")
 		c.Abort()
+	} else if user.Role == RoleAdmin {
+		c.Redirect(http.StatusTemporaryRedirect, "/admin/sso")
+		c.Abort()
 	} else {
 		fmt.Printf("Dashboard Redirect: User %s has unknown role: %s\n", pgID.String(), user.Role)
 		c.Redirect(http.StatusTemporaryRedirect, "/")
@@ -128,62 +113,34 @@ func (app *App) recruiterDashboardHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Access denied</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Access denied")
 		c.Abort()
 		return
 	}
 
-	userName := user.Name
+	unreadMessages, err := app.db.CountUnreadMessagesForUser(c.Request.Context(), pgID)
+	if err != nil {
+		fmt.Printf("Recruiter Dashboard: Failed to count unread messages for %s: %v\n", pgID.String(), err)
+	}
 
 	postings, err := app.db.ListJobPostingsByRecruiter(c.Request.Context(), pgID)
+	postingsError := err != nil && err != sql.ErrNoRows
+	if postingsError {
+		fmt.Printf("Recruiter Dashboard: Failed to list job postings for %s: %v\n", pgID.String(), err)
+	}
 
-	var jobsHtmlBuilder strings.Builder
+	interviewingJobs, err := app.db.ListJobsForInterviewer(c.Request.Context(), pgID)
 	if err != nil && err != sql.ErrNoRows {
-		fmt.Printf("Recruiter Dashboard: Failed to list job postings for %s: %v\n", pgID.String(), err)
-		jobsHtmlBuilder.WriteString("<p style='color:red;'>Error loading job postings.</p>")
-	} else if len(postings) == 0 {
-		jobsHtmlBuilder.WriteString("<p>You have not posted any jobs yet.</p>")
-	} else {
-		jobsHtmlBuilder.WriteString("<ul>")
-		for _, posting := range postings {
-
-			var jobIDStr string
-			if posting.ID.Valid {
-				jobIDStr = uuid.UUID(posting.ID.Bytes).String()
-			} else {
-				continue
-			}
-
-			manageAppLink := fmt.Sprintf("/recruiter/jobs/%s/applications", jobIDStr)
-
-			jobsHtmlBuilder.WriteString(fmt.Sprintf(
-				`<li>%s (Status: %s) - <a href="%s">Manage Applications</a> </li>`,
-				posting.Title,
-				posting.Status,
-				manageAppLink,
-			))
-		}
-		jobsHtmlBuilder.WriteString("</ul>")
-	}
-
-	dashboardHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Recruiter Dashboard</title></head><body>
-		<h1>Recruiter Dashboard</h1>
-		<p>Welcome, %s!</p>
-		<hr>
-		<h2>My Job Postings</h2>
-		%s 
-		<p><a href="/jobs/new">Create New Job Posting</a></p> 
-        <hr>
-        <h2>Other Actions</h2>
-		<p><a href="/recruiter/search">Search Applicants By Skill</a></p>
-        <p><a href="/logout">Logout</a></p>
-		</body></html>`,
-		userName, jobsHtmlBuilder.String())
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, dashboardHTML)
+		fmt.Printf("Recruiter Dashboard: Failed to list interviewer jobs for %s: %v\n", pgID.String(), err)
+	}
+
+	app.render(c, http.StatusOK, "recruiter_dashboard.html", "Recruiter Dashboard", gin.H{
+		"Name":           user.Name,
+		"UnreadMessages": unreadMessages,
+		"Postings":       postings,
+		"PostingsError":  postingsError,
+		"Interviewing":   interviewingJobs,
+	})
 }
 
 func (app *App) applicantDashboardHandler(c *gin.Context) {
@@ -212,39 +169,21 @@ func (app *App) applicantDashboardHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleApplicant {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Access denied</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Access denied")
 		c.Abort()
 		return
 	}
 
 	applications, err := app.db.GetApplicationsByUserID(c.Request.Context(), pgID)
-	if err != nil && err != sql.ErrNoRows {
+	applicationsError := err != nil && err != sql.ErrNoRows
+	if applicationsError {
 		fmt.Printf("Applicant Dashboard: Failed to get applications for %s: %v\n", pgID.String(), err)
 	}
 
-	var applicationsHtmlBuilder strings.Builder
-	if err != nil && err != sql.ErrNoRows {
-		applicationsHtmlBuilder.WriteString("<p style='color:red;'>Error loading application history.</p>")
-	} else if len(applications) == 0 {
-		applicationsHtmlBuilder.WriteString("<p>You have not submitted any applications yet.</p>")
-	} else {
-		applicationsHtmlBuilder.WriteString("<ul>")
-		for _, application := range applications {
-
-			appliedAtStr := "N/A"
-			if application.AppliedAt.Valid {
-				appliedAtStr = application.AppliedAt.Time.Format(time.RFC822)
-			}
-
-			applicationsHtmlBuilder.WriteString(fmt.Sprintf(
-				"<li>Job: %s | Status: %s | Applied: %s</li>",
-				application.JobTitle, application.ApplicationStatus, appliedAtStr,
-			))
-		}
-		applicationsHtmlBuilder.WriteString("</ul>")
+	unreadMessages, err := app.db.CountUnreadMessagesForUser(c.Request.Context(), pgID)
+	if err != nil {
+		fmt.Printf("Applicant Dashboard: Failed to count unread messages for %s: %v\n", pgID.String(), err)
 	}
-	applicationsHtml := applicationsHtmlBuilder.String()
 
 	skillNames, err := app.db.GetUserSkillNames(c.Request.Context(), pgID)
 	if err != nil && err != sql.ErrNoRows {
@@ -255,41 +194,42 @@ func (app *App) applicantDashboardHandler(c *gin.Context) {
 		return
 	}
 
-	var skillsHtml strings.Builder
+	offers, err := app.db.ListOffersForApplicant(c.Request.Context(), pgID)
 	if err != nil && err != sql.ErrNoRows {
-		skillsHtml.WriteString("<p style='color:red;'>Error loading skills.</p>")
-	} else if len(skillNames) == 0 {
-		skillsHtml.WriteString("<p>You haven't added any skills yet.</p>")
-	} else {
-		skillsHtml.WriteString("<ul>")
-		for _, name := range skillNames {
-			skillsHtml.WriteString(fmt.Sprintf("<li>%s</li>", name))
+		fmt.Printf("Applicant Dashboard: Failed to get offers for %s: %v\n", pgID.String(), err)
+	}
+	type offerSummary struct {
+		JobTitle      string
+		Status        string
+		ApplicationID string
+		Action        string
+	}
+	offerSummaries := make([]offerSummary, 0, len(offers))
+	for _, offer := range offers {
+		status := offer.Status
+		if status == OfferStatusSent && offer.ExpiresAt.Valid && time.Now().After(offer.ExpiresAt.Time) {
+			status = OfferStatusExpired
 		}
-		skillsHtml.WriteString("</ul>")
-	}
-
-	dashboardHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Applicant Dashboard</title></head><body>
-		<h1>Applicant Dashboard</h1>
-		<p>Welcome, %s!</p>
-		<hr>
-		<h2>My Applications</h2>
-        %s
-		<p><a href="/jobs">Browse Open Jobs</a></p> 
-        <hr>
-        <h2>My Profile</h2>
-		<p><a href="/applicant/resume">Manage Resume</a></p>
-        <p><a href="/applicant/skills">Manage Skills</a></p>
-        <h3>Current Skills:</h3>
-        %s
-        <hr>
-        <p><a href="/logout">Logout</a></p>
-		</body></html>`,
-		user.Name, applicationsHtml, skillsHtml.String())
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, dashboardHTML)
-
+		action := "View"
+		if status == OfferStatusSent {
+			action = fmt.Sprintf("Respond by %s", offer.ExpiresAt.Time.Format(time.RFC822))
+		}
+		offerSummaries = append(offerSummaries, offerSummary{
+			JobTitle:      offer.JobTitle,
+			Status:        status,
+			ApplicationID: uuid.UUID(offer.ApplicationID.Bytes).String(),
+			Action:        action,
+		})
+	}
+
+	app.render(c, http.StatusOK, "applicant_dashboard.html", "Applicant Dashboard", gin.H{
+		"Name":              user.Name,
+		"UnreadMessages":    unreadMessages,
+		"Applications":      applications,
+		"ApplicationsError": applicationsError,
+		"Offers":            offerSummaries,
+		"Skills":            skillNames,
+	})
 }
 
 func (app *App) getManageSkillsHandler(c *gin.Context) {
@@ -325,8 +265,7 @@ func (app *App) getManageSkillsHandler(c *gin.Context) {
 	allSkills, err := app.db.ListSkills(c.Request.Context())
 	if err != nil {
 		fmt.Printf("Manage Skills GET: Failed to list skills: %v\n", err)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusInternalServerError, "<html><body>Error loading skills list</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error loading skills list")
 		c.Redirect(http.StatusTemporaryRedirect, "/")
 		return
 	}
@@ -334,8 +273,7 @@ func (app *App) getManageSkillsHandler(c *gin.Context) {
 	currentUserSkills, err := app.db.GetUserSkillIDs(c.Request.Context(), pgID)
 	if err != nil && err != sql.ErrNoRows {
 		fmt.Printf("Manage Skills GET: Failed to get user skills: %v\n", err)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusInternalServerError, "<html><body>Error loading your skills</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error loading your skills")
 		return
 	}
 	if err == sql.ErrNoRows {
@@ -348,50 +286,33 @@ func (app *App) getManageSkillsHandler(c *gin.Context) {
 		}
 	}
 
-	var skillsChecklistHTML strings.Builder
-	skillsChecklistHTML.WriteString(`<form method="POST" action="/applicant/skills">`)
-	skillsChecklistHTML.WriteString("<h3>Select your skills:</h3>")
+	app.render(c, http.StatusOK, "skills.html", "Manage Skills", gin.H{
+		"CSRF":   csrfToken(c),
+		"Skills": skillOptions(allSkills, currentUserSkillsMap),
+	})
+}
 
-	if len(allSkills) == 0 {
-		skillsChecklistHTML.WriteString("<p>No skills available to select.</p>")
-	} else {
-		for _, skill := range allSkills {
-			var skillUUID uuid.UUID
-			var skillIDStr string
-			if skill.ID.Valid {
-				skillUUID = uuid.UUID(skill.ID.Bytes)
-				skillIDStr = skillUUID.String()
-			} else {
-				continue
-			}
-
-			isChecked := currentUserSkillsMap[skillUUID]
-			checkedAttr := ""
-			if isChecked {
-				checkedAttr = " checked"
-			}
-
-			skillsChecklistHTML.WriteString(fmt.Sprintf(
-				`<div><input type="checkbox" id="skill_%s" name="skill_ids" value="%s"%s> <label for="skill_%s">%s</label></div>`,
-				skillIDStr, skillIDStr, checkedAttr, skillIDStr, skill.Name,
-			))
+// skillOption is one checkbox in the skill pickers.
+type skillOption struct {
+	ID      string
+	Name    string
+	Checked bool
+}
+
+func skillOptions(skills []db.Skill, checked map[uuid.UUID]bool) []skillOption {
+	options := make([]skillOption, 0, len(skills))
+	for _, skill := range skills {
+		if !skill.ID.Valid {
+			continue
 		}
-		skillsChecklistHTML.WriteString(`<br><button type="submit">Update Skills</button>`)
-	}
-	skillsChecklistHTML.WriteString(`</form>`)
-
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Manage Skills</title></head><body>
-		<nav>...</nav> <hr> 
-		<h1>Manage Your Skills</h1>
-		%s
-		<hr>
-		<p><a href="/applicant/dashboard">Back to Dashboard</a></p>
-		</body></html>`,
-		skillsChecklistHTML.String())
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+		skillUUID := uuid.UUID(skill.ID.Bytes)
+		options = append(options, skillOption{
+			ID:      skillUUID.String(),
+			Name:    skill.Name,
+			Checked: checked[skillUUID],
+		})
+	}
+	return options
 }
 
 func (app *App) postManageSkillsHandler(c *gin.Context) {
@@ -439,8 +360,7 @@ func (app *App) postManageSkillsHandler(c *gin.Context) {
 	err = app.db.DeleteUserSkills(c.Request.Context(), pgID)
 	if err != nil {
 		fmt.Printf("Manage Skills POST: Failed to delete old skills for user %s: %v\n", pgID.String(), err)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusInternalServerError, "<html><body>Error updating skills (step 1)</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error updating skills (step 1)")
 		c.Redirect(http.StatusTemporaryRedirect, "/")
 		c.Abort()
 		return
@@ -490,8 +410,7 @@ func (app *App) getSkillSearchFormHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only recruiters can search applicants</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only recruiters can search applicants")
 		c.Abort()
 		return
 	}
@@ -499,48 +418,13 @@ func (app *App) getSkillSearchFormHandler(c *gin.Context) {
 	allSkills, err := app.db.ListSkills(c.Request.Context())
 	if err != nil {
 		fmt.Printf("Skill Search GET: Failed to list skills: %v\n", err)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusInternalServerError, "<html><body>Error loading skills list</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error loading skills list")
 		return
 	}
 
-	var skillsChecklistHTML strings.Builder
-	skillsChecklistHTML.WriteString(`<form method="GET" action="/recruiter/search/results">`)
-	skillsChecklistHTML.WriteString("<h3>Select skills to search for applicants:</h3>")
-
-	if len(allSkills) == 0 {
-		skillsChecklistHTML.WriteString("<p>No skills available in the system.</p>")
-	} else {
-		for _, skill := range allSkills {
-			var skillUUID uuid.UUID
-			var skillIDStr string
-			if skill.ID.Valid {
-				skillUUID = uuid.UUID(skill.ID.Bytes)
-				skillIDStr = skillUUID.String()
-			} else {
-				continue
-			}
-			skillsChecklistHTML.WriteString(fmt.Sprintf(
-				`<div><input type="checkbox" id="skill_%s" name="skill_id" value="%s"> <label for="skill_%s">%s</label></div>`,
-				skillIDStr, skillIDStr, skillIDStr, skill.Name,
-			))
-		}
-		skillsChecklistHTML.WriteString(`<br><button type="submit">Search Applicants</button>`)
-	}
-	skillsChecklistHTML.WriteString(`</form>`)
-
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Search Applicants by Skill</title></head><body>
-		<nav>...</nav><hr>
-		<h1>Search Applicants by Skill</h1>
-		%s
-		<hr>
-		<p><a href="/recruiter/dashboard">Back to Dashboard</a></p>
-		</body></html>`,
-		skillsChecklistHTML.String())
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	app.render(c, http.StatusOK, "skill_search.html", "Search Applicants by Skill", gin.H{
+		"Skills": skillOptions(allSkills, nil),
+	})
 }
 
 func (app *App) getSkillSearchResultsHandler(c *gin.Context) {
@@ -565,8 +449,7 @@ func (app *App) getSkillSearchResultsHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only recruiters can search applicants</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only recruiters can search applicants")
 		c.Abort()
 		return
 	}
@@ -574,8 +457,7 @@ func (app *App) getSkillSearchResultsHandler(c *gin.Context) {
 	skillIDStrings := c.QueryArray("skill_id")
 
 	if len(skillIDStrings) == 0 {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Please select at least one skill to search. <a href='/recruiter/search'>Go back</a></body></html>")
+		app.renderErrorBack(c, http.StatusBadRequest, "Please select at least one skill to search.", "/recruiter/search", "Go back")
 		return
 	}
 
@@ -583,8 +465,7 @@ func (app *App) getSkillSearchResultsHandler(c *gin.Context) {
 	for _, idStr := range skillIDStrings {
 		parsedUUID, err := uuid.Parse(idStr)
 		if err != nil {
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusBadRequest, "<html><body>Invalid skill ID format submitted. <a href='/recruiter/search'>Go back</a></body></html>")
+			app.renderErrorBack(c, http.StatusBadRequest, "Invalid skill ID format submitted.", "/recruiter/search", "Go back")
 			return
 		}
 		skillPgUUIDs = append(skillPgUUIDs, pgtype.UUID{Bytes: parsedUUID, Valid: true})
@@ -598,46 +479,64 @@ func (app *App) getSkillSearchResultsHandler(c *gin.Context) {
 	applicants, err := app.db.SearchApplicantsBySkills(c.Request.Context(), params)
 	if err != nil && err != sql.ErrNoRows {
 		fmt.Printf("Skill Search Results: DB error searching applicants: %v\n", err)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusInternalServerError, "<html><body>Error searching applicants. Please try again.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error searching applicants. Please try again.")
 		return
 	}
 
-	var resultsHTML strings.Builder
-	resultsHTML.WriteString("<h2>Search Results</h2>")
-	resultsHTML.WriteString(fmt.Sprintf("<p>Found %d applicant(s) matching ALL selected skills:</p>", len(applicants)))
+	exportQuery := c.Request.URL.Query()
+	exportQuery.Set("format", ExportFormatCSV)
+	csvLink := "/recruiter/search/export?" + exportQuery.Encode()
+	exportQuery.Set("format", ExportFormatXLSX)
+	xlsxLink := "/recruiter/search/export?" + exportQuery.Encode()
 
-	if len(applicants) == 0 {
-		resultsHTML.WriteString("<p>No applicants found matching all the selected skills.</p>")
-	} else {
-		resultsHTML.WriteString("<ul>")
-		for _, applicant := range applicants {
-			applicantIDStr := ""
-			if applicant.ID.Valid {
-				applicantIDStr = uuid.UUID(applicant.ID.Bytes).String()
-			}
-			viewProfileLink := fmt.Sprintf("/recruiter/applicant/%s", applicantIDStr)
-
-			resultsHTML.WriteString(fmt.Sprintf(
-				`<li>Name: %s | Email: %s (ID = %s )<a href="%s">View Full Profile</a></li>`,
-				applicant.Name,
-				applicant.Email,
-				applicantIDStr,
-				viewProfileLink))
-		}
-		resultsHTML.WriteString("</ul>")
+	app.render(c, http.StatusOK, "skill_search_results.html", "Applicant Search Results", gin.H{
+		"Applicants": applicants,
+		"CSVLink":    csvLink,
+		"XLSXLink":   xlsxLink,
+	})
+}
+
+// requireRole loads the logged-in user and checks that they have the given
+// role. When it returns false the response has already been written.
+// signedInUserID returns the user set by the auth middleware, answering 401
+// Unauthorized when a route is reached without one.
+func (app *App) signedInUserID(c *gin.Context) (pgtype.UUID, bool) {
+	userID, exists := c.Get("userID")
+	pgID, ok := userID.(pgtype.UUID)
+	if !exists || !ok || !pgID.Valid {
+		app.renderError(c, http.StatusUnauthorized, "Please sign in to continue.")
+		c.Abort()
+		return pgtype.UUID{}, false
+	}
+	return pgID, true
+}
+
+func (app *App) requireRole(c *gin.Context, role string) (db.GetUserRow, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return db.GetUserRow{}, false
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return db.GetUserRow{}, false
 	}
 
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Applicant Search Results</title></head><body>
-		<nav>...</nav><hr>
-		%s
-		<hr>
-		<p><a href="/recruiter/search">New Search</a></p>
-		<p><a href="/recruiter/dashboard">Back to Dashboard</a></p>
-		</body></html>`,
-		resultsHTML.String())
+	user, err := app.db.GetUser(c.Request.Context(), pgID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Internal Server Error: %v", err)
+		c.Abort()
+		return db.GetUserRow{}, false
+	}
+	if user.Role != role {
+		app.renderError(c, http.StatusForbidden, "You do not have access to this page.")
+		c.Abort()
+		return db.GetUserRow{}, false
+	}
 
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	c.Set(currentUserKey, navData{LoggedIn: true, Name: user.Name, Role: user.Role})
+	return user, true
 }
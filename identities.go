@@ -0,0 +1,246 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// pendingIdentity is a provider login whose email belongs to an existing
+// account. It is kept in the session and linked once the person proves they
+// own that account by signing in to it.
+type pendingIdentity struct {
+	Provider string
+	Subject  string
+	Email    string
+}
+
+func (app *App) providerLabel(name string) string {
+	for _, provider := range app.authProviders {
+		if provider.Name == name {
+			return provider.Label
+		}
+	}
+	return name
+}
+
+// findUserForIdentity returns the user an identity signs in to. Only
+// user_identities is consulted, so an unlinked identity no longer signs in;
+// logins from before the table are copied into it by
+// db/migrations/upgrade_user_identities.sql.
+func (app *App) findUserForIdentity(ctx context.Context, identity pendingIdentity) (pgtype.UUID, error) {
+	user, err := app.db.GetUserByIdentity(ctx, db.GetUserByIdentityParams{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+	})
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	return user.ID, nil
+}
+
+func (app *App) createIdentity(ctx context.Context, userID pgtype.UUID, identity pendingIdentity) error {
+	return app.db.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// attachPendingIdentity links the identity waiting in the session to the user
+// who just signed in, as long as the email addresses match. It runs on every
+// login, so whichever way the user signs in completes the link.
+func (app *App) attachPendingIdentity(c *gin.Context, session sessions.Session, userID pgtype.UUID) {
+	identity, ok := session.Get(sessionPendingIdentityKey).(pendingIdentity)
+	if !ok {
+		return
+	}
+	session.Delete(sessionPendingIdentityKey)
+
+	user, err := app.db.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Printf("Identities: Failed to load user %s to link %s: %v\n", userID.String(), identity.Provider, err)
+		return
+	}
+	if !strings.EqualFold(user.Email, identity.Email) {
+		fmt.Printf("Identities: Not linking %s login for %s to user %s with a different email.\n", identity.Provider, identity.Email, userID.String())
+		return
+	}
+	if err := app.createIdentity(c.Request.Context(), userID, identity); err != nil && !isUniqueViolation(err) {
+		fmt.Printf("Identities: Failed to link %s to user %s: %v\n", identity.Provider, userID.String(), err)
+		return
+	}
+	fmt.Printf("Linked %s login to existing user %s.\n", identity.Provider, userID.String())
+}
+
+// getLinkAccountHandler explains that the provider login matches an existing
+// account and asks the user to sign in to that account to link it.
+func (app *App) getLinkAccountHandler(c *gin.Context) {
+	session := sessions.Default(c)
+	identity, ok := session.Get(sessionPendingIdentityKey).(pendingIdentity)
+	if !ok {
+		c.Redirect(http.StatusSeeOther, "/auth/login")
+		return
+	}
+
+	var providers []authProvider
+	for _, provider := range app.authProviders {
+		if provider.Name != identity.Provider {
+			providers = append(providers, provider)
+		}
+	}
+
+	app.authPage(c, "link_account.html", "Link Your Account", gin.H{
+		"Email":         identity.Email,
+		"ProviderLabel": app.providerLabel(identity.Provider),
+		"Providers":     providers,
+		"CSRF":          csrfToken(c),
+	})
+}
+
+func (app *App) postCancelLinkAccountHandler(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Delete(sessionPendingIdentityKey)
+	if err := session.Save(); err != nil {
+		fmt.Printf("Link Account: Failed to save session: %v\n", err)
+	}
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// identityRow is a linked provider on the sign-in methods page.
+type identityRow struct {
+	ID        pgtype.UUID
+	Provider  string
+	Email     string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (app *App) getIdentitiesHandler(c *gin.Context) {
+	userID, ok := app.signedInUserID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	identities, err := app.db.ListUserIdentities(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Identities GET: DB error for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load sign-in methods.")
+		return
+	}
+	hasPassword, err := app.db.UserHasPassword(ctx, userID)
+	if err != nil {
+		fmt.Printf("Identities GET: DB error checking password for user %s: %v\n", userID.String(), err)
+	}
+	linked := make(map[string]bool, len(identities))
+	rows := make([]identityRow, 0, len(identities))
+	for _, identity := range identities {
+		linked[identity.Provider] = true
+		rows = append(rows, identityRow{
+			ID:        identity.ID,
+			Provider:  app.providerLabel(identity.Provider),
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	var unlinked []authProvider
+	for _, provider := range app.authProviders {
+		if !linked[provider.Name] {
+			unlinked = append(unlinked, provider)
+		}
+	}
+
+	app.render(c, http.StatusOK, "identities.html", "Sign-in Methods", gin.H{
+		"Identities":  rows,
+		"Unlinked":    unlinked,
+		"CanUnlink":   hasPassword || len(identities) > 1,
+		"HasPassword": hasPassword,
+		"Error":       c.Query("error"),
+		"CSRF":        csrfToken(c),
+	})
+}
+
+// getLinkIdentityHandler starts the provider login that links a new identity
+// to the signed-in user; authCallbackHandler finishes it.
+func (app *App) getLinkIdentityHandler(c *gin.Context) {
+	provider, ok := authProviderParam(c)
+	if !ok {
+		return
+	}
+	session := sessions.Default(c)
+	session.Set(sessionLinkIdentityKey, true)
+	if err := session.Save(); err != nil {
+		fmt.Printf("Link Identity: Failed to save session: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to start linking.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/auth/"+provider)
+}
+
+// linkIdentity finishes linking a provider login to the signed-in user.
+func (app *App) linkIdentity(c *gin.Context, userID pgtype.UUID, identity pendingIdentity) {
+	err := app.createIdentity(c.Request.Context(), userID, identity)
+	if isUniqueViolation(err) {
+		existing, lookupErr := app.db.GetUserByIdentity(c.Request.Context(), db.GetUserByIdentityParams{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+		})
+		message := "That " + app.providerLabel(identity.Provider) + " account is already linked to another user."
+		if lookupErr == nil && existing.ID.Bytes == userID.Bytes {
+			message = "That " + app.providerLabel(identity.Provider) + " account is already linked."
+		}
+		c.Redirect(http.StatusSeeOther, "/account/identities?error="+url.QueryEscape(message))
+		return
+	} else if err != nil {
+		fmt.Printf("Link Identity: Failed to link %s to user %s: %v\n", identity.Provider, userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to link account.")
+		return
+	}
+	fmt.Printf("User %s linked a %s login.\n", userID.String(), identity.Provider)
+	c.Redirect(http.StatusSeeOther, "/account/identities")
+}
+
+func (app *App) postUnlinkIdentityHandler(c *gin.Context) {
+	userID, ok := app.signedInUserID(c)
+	if !ok {
+		return
+	}
+	identityUUID, err := uuid.Parse(c.Param("identityID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid identity ID.")
+		return
+	}
+
+	removed, err := app.db.DeleteUserIdentity(c.Request.Context(), db.DeleteUserIdentityParams{
+		ID:     pgtype.UUID{Bytes: identityUUID, Valid: true},
+		UserID: userID,
+	})
+	if err != nil {
+		fmt.Printf("Unlink Identity: DB error for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to unlink provider.")
+		return
+	}
+	if removed == 0 {
+		c.Redirect(http.StatusSeeOther, "/account/identities?error="+url.QueryEscape("You cannot remove your only way to sign in. Link another provider or set a password first."))
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/account/identities")
+}
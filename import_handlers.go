@@ -0,0 +1,571 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	maxImportFileSize    = 10 * 1024 * 1024  // 10 MB
+	maxImportArchiveSize = 200 * 1024 * 1024 // 200 MB
+)
+
+// importRow is one record of an import file, keyed by lower-cased column name.
+// Line is the CSV line or JSON array position, for the report.
+type importRow struct {
+	Line   int
+	Fields map[string]string
+}
+
+func (r importRow) get(field string) string {
+	return strings.TrimSpace(r.Fields[field])
+}
+
+type importResult struct {
+	Line       int
+	ExternalID string
+	Outcome    string
+	Message    string
+}
+
+// importError is a problem with a row that is reported to the user as is.
+type importError string
+
+func (e importError) Error() string { return string(e) }
+
+// parseImportFile reads a CSV file with a header row, or a JSON array of
+// objects.
+func parseImportFile(name string, data []byte) ([]importRow, error) {
+	trimmed := bytes.TrimSpace(data)
+	if strings.EqualFold(path.Ext(name), ".json") || bytes.HasPrefix(trimmed, []byte("[")) {
+		var records []map[string]any
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		rows := make([]importRow, 0, len(records))
+		for i, record := range records {
+			fields := make(map[string]string, len(record))
+			for key, value := range record {
+				fields[strings.ToLower(strings.TrimSpace(key))] = importValue(value)
+			}
+			rows = append(rows, importRow{Line: i + 1, Fields: fields})
+		}
+		return rows, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	rows := make([]importRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		fields := make(map[string]string, len(header))
+		for j, value := range record {
+			if j < len(header) {
+				fields[header[j]] = value
+			}
+		}
+		rows = append(rows, importRow{Line: i + 2, Fields: fields})
+	}
+	return rows, nil
+}
+
+// importValue converts a JSON value to the text a CSV cell would hold. Lists
+// become "; "-separated, like the skills column.
+func importValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, importValue(item))
+		}
+		return strings.Join(parts, "; ")
+	}
+	return fmt.Sprint(value)
+}
+
+func readImportUpload(fileHeader *multipart.FileHeader, limit int64) ([]byte, error) {
+	if fileHeader.Size > limit {
+		return nil, importError(fmt.Sprintf("%s exceeds the size limit (%d MB).", fileHeader.Filename, limit/1024/1024))
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func parseImportBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		return true
+	}
+	return false
+}
+
+func parseImportNumeric(value, field string) (pgtype.Numeric, decimal.Decimal, error) {
+	var n pgtype.Numeric
+	if value == "" {
+		return n, decimal.Decimal{}, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil || d.IsNegative() {
+		return n, d, importError(fmt.Sprintf("%s must be a positive number.", field))
+	}
+	if err := n.Scan(d.String()); err != nil {
+		return n, d, importError(fmt.Sprintf("%s is not a valid amount.", field))
+	}
+	return n, d, nil
+}
+
+// parseImportSkills maps "; "-separated skill names to skill IDs. Skills must
+// already exist.
+func parseImportSkills(value string, skillsByName map[string]pgtype.UUID) ([]pgtype.UUID, error) {
+	var skillIDs []pgtype.UUID
+	for _, name := range strings.Split(value, ";") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		skillID, ok := skillsByName[strings.ToLower(name)]
+		if !ok {
+			return nil, importError(fmt.Sprintf("Unknown skill %q.", name))
+		}
+		skillIDs = append(skillIDs, skillID)
+	}
+	return skillIDs, nil
+}
+
+func (app *App) skillsByName(ctx context.Context) (map[string]pgtype.UUID, error) {
+	skills, err := app.db.ListSkills(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	byName := make(map[string]pgtype.UUID, len(skills))
+	for _, skill := range skills {
+		byName[strings.ToLower(skill.Name)] = skill.ID
+	}
+	return byName, nil
+}
+
+// runImport calls apply for each row inside one transaction, with a
+// savepoint per row so a bad row is reported without undoing the others. A
+// dry run rolls everything back, so its report reflects exactly what an
+// import would do, database constraints included.
+func (app *App) runImport(ctx context.Context, rows []importRow, dryRun bool, apply func(q *db.Queries, row importRow) (bool, error)) ([]importResult, error) {
+	tx, err := app.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, updated := "Created", "Updated"
+	if dryRun {
+		created, updated = "Would create", "Would update"
+	}
+
+	results := make([]importResult, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		result := importResult{Line: row.Line, ExternalID: row.get("external_id")}
+		if result.ExternalID == "" {
+			result.Outcome, result.Message = "Error", "external_id is required."
+			results = append(results, result)
+			continue
+		}
+		if line, ok := seen[result.ExternalID]; ok {
+			result.Outcome, result.Message = "Error", fmt.Sprintf("Duplicate external_id, already used on line %d.", line)
+			results = append(results, result)
+			continue
+		}
+		seen[result.ExternalID] = row.Line
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		inserted, err := apply(app.db.WithTx(savepoint), row)
+		if err != nil {
+			if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+				return nil, rbErr
+			}
+			result.Outcome, result.Message = "Error", importErrorMessage(err)
+		} else {
+			if err := savepoint.Commit(ctx); err != nil {
+				return nil, err
+			}
+			result.Outcome = updated
+			if inserted {
+				result.Outcome = created
+			}
+		}
+		results = append(results, result)
+	}
+
+	if dryRun {
+		return results, nil
+	}
+	return results, tx.Commit(ctx)
+}
+
+// importErrorMessage turns a row failure into something the user can act on.
+func importErrorMessage(err error) string {
+	var rowErr importError
+	if errors.As(err, &rowErr) {
+		return rowErr.Error()
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			if strings.Contains(pgErr.ConstraintName, "email") {
+				return "An account with this email already exists."
+			}
+			if strings.Contains(pgErr.ConstraintName, "name") {
+				return "An account with this name already exists."
+			}
+			return fmt.Sprintf("Conflicts with an existing record (%s).", pgErr.ConstraintName)
+		case "22001":
+			return "A value is too long."
+		}
+	}
+	fmt.Printf("Import: Unexpected error importing row: %v\n", err)
+	return "Database error while importing this row."
+}
+
+func (app *App) getImportHandler(c *gin.Context) {
+	if _, ok := app.requireRole(c, RoleRecruiter); !ok {
+		return
+	}
+
+	app.render(c, http.StatusOK, "import.html", "Import", gin.H{
+		"Error": c.Query("error"),
+		"CSRF":  csrfToken(c),
+	})
+}
+
+// readImportRows reads and parses the uploaded "file" field. When it returns
+// false the response has already been written.
+func readImportRows(c *gin.Context) ([]importRow, bool) {
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, "/recruiter/import?error="+url.QueryEscape(message))
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		fail("Please choose a file to import.")
+		return nil, false
+	}
+	data, err := readImportUpload(fileHeader, maxImportFileSize)
+	if err != nil {
+		fail(importErrorMessage(err))
+		return nil, false
+	}
+	rows, err := parseImportFile(fileHeader.Filename, data)
+	if err != nil {
+		fail(err.Error())
+		return nil, false
+	}
+	if len(rows) == 0 {
+		fail("The file has no rows to import.")
+		return nil, false
+	}
+	return rows, true
+}
+
+func (app *App) postImportJobsHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	rows, ok := readImportRows(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	dryRun := c.PostForm("action") != "import"
+
+	skillsByName, err := app.skillsByName(ctx)
+	if err != nil {
+		fmt.Printf("Import Jobs: DB error listing skills: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to import job postings.")
+		return
+	}
+
+	results, err := app.runImport(ctx, rows, dryRun, func(q *db.Queries, row importRow) (bool, error) {
+		params := db.UpsertImportedJobPostingParams{
+			RecruiterID: recruiter.ID,
+			ExternalID:  pgtype.Text{String: row.get("external_id"), Valid: true},
+			Title:       row.get("title"),
+			Remote:      parseImportBool(row.get("remote")),
+			Headcount:   1,
+			Status:      JobStatusDraft,
+		}
+		if params.Title == "" {
+			return false, importError("title is required.")
+		}
+		params.Slug = newJobSlug(params.Title)
+		if description := row.get("description"); description != "" {
+			params.Description = pgtype.Text{String: description, Valid: true}
+		}
+		if location := row.get("location"); location != "" {
+			params.Location = pgtype.Text{String: location, Valid: true}
+		}
+
+		var minVal, maxVal decimal.Decimal
+		var err error
+		if params.SalaryMin, minVal, err = parseImportNumeric(row.get("salary_min"), "salary_min"); err != nil {
+			return false, err
+		}
+		if params.SalaryMax, maxVal, err = parseImportNumeric(row.get("salary_max"), "salary_max"); err != nil {
+			return false, err
+		}
+		if params.SalaryMin.Valid && params.SalaryMax.Valid && minVal.GreaterThan(maxVal) {
+			return false, importError("salary_min cannot be greater than salary_max.")
+		}
+		if headcount := row.get("headcount"); headcount != "" {
+			n, err := strconv.Atoi(headcount)
+			if err != nil || n < 1 {
+				return false, importError("headcount must be at least 1.")
+			}
+			params.Headcount = int32(n)
+		}
+		switch status := strings.ToLower(row.get("status")); status {
+		case "", JobStatusDraft:
+		case JobStatusPublished:
+			params.Status = JobStatusPublished
+		default:
+			return false, importError(fmt.Sprintf("status must be %s or %s.", JobStatusDraft, JobStatusPublished))
+		}
+		skillIDs, err := parseImportSkills(row.get("skills"), skillsByName)
+		if err != nil {
+			return false, err
+		}
+
+		job, err := q.UpsertImportedJobPosting(ctx, params)
+		if err != nil {
+			return false, err
+		}
+		for _, skillID := range skillIDs {
+			if err := q.AddSkillToJobPosting(ctx, db.AddSkillToJobPostingParams{JobPostingID: job.ID, SkillID: skillID}); err != nil {
+				return false, err
+			}
+		}
+		return job.Inserted, nil
+	})
+	if err != nil {
+		fmt.Printf("Import Jobs: Failed to run import for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to import job postings.")
+		return
+	}
+
+	if !dryRun {
+		fmt.Printf("Recruiter %s imported %d job posting rows\n", recruiter.ID.String(), len(rows))
+	}
+	app.renderImportReport(c, "Job Postings", dryRun, results)
+}
+
+// openResumeArchive indexes the PDFs in an uploaded zip by base file name.
+func openResumeArchive(c *gin.Context) (map[string]*zip.File, error) {
+	fileHeader, err := c.FormFile("resumes")
+	if err != nil {
+		return nil, nil
+	}
+	data, err := readImportUpload(fileHeader, maxImportArchiveSize)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, importError("The resumes file is not a valid zip archive.")
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		if !f.FileInfo().IsDir() {
+			files[strings.ToLower(path.Base(f.Name))] = f
+		}
+	}
+	return files, nil
+}
+
+func readResumeFromArchive(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[strings.ToLower(path.Base(name))]
+	if !ok {
+		return nil, importError(fmt.Sprintf("Resume %q is not in the resumes zip.", name))
+	}
+	if f.UncompressedSize64 > maxUploadSize {
+		return nil, importError(fmt.Sprintf("Resume %q exceeds the size limit (5MB).", name))
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, importError(fmt.Sprintf("Resume %q could not be read from the zip.", name))
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxUploadSize+1))
+	if err != nil || len(data) > maxUploadSize {
+		return nil, importError(fmt.Sprintf("Resume %q could not be read from the zip.", name))
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return nil, importError(fmt.Sprintf("Resume %q is not a PDF.", name))
+	}
+	return data, nil
+}
+
+func (app *App) postImportCandidatesHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	rows, ok := readImportRows(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	dryRun := c.PostForm("action") != "import"
+
+	resumes, err := openResumeArchive(c)
+	if err != nil {
+		c.Redirect(http.StatusSeeOther, "/recruiter/import?error="+url.QueryEscape(importErrorMessage(err)))
+		return
+	}
+	skillsByName, err := app.skillsByName(ctx)
+	if err != nil {
+		fmt.Printf("Import Candidates: DB error listing skills: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to import candidates.")
+		return
+	}
+
+	results, err := app.runImport(ctx, rows, dryRun, func(q *db.Queries, row importRow) (bool, error) {
+		name, email := row.get("name"), row.get("email")
+		if name == "" {
+			return false, importError("name is required.")
+		}
+		if !strings.Contains(email, "@") {
+			return false, importError("A valid email is required.")
+		}
+		skillIDs, err := parseImportSkills(row.get("skills"), skillsByName)
+		if err != nil {
+			return false, err
+		}
+		var resumePDF []byte
+		if resumeFile := row.get("resume_file"); resumeFile != "" {
+			if resumes == nil {
+				return false, importError("resume_file is set but no resumes zip was uploaded.")
+			}
+			if resumePDF, err = readResumeFromArchive(resumes, resumeFile); err != nil {
+				return false, err
+			}
+		}
+		var jobID pgtype.UUID
+		if jobExternalID := row.get("job_external_id"); jobExternalID != "" {
+			jobID, err = q.GetJobPostingIDByExternalID(ctx, db.GetJobPostingIDByExternalIDParams{
+				RecruiterID: recruiter.ID,
+				ExternalID:  pgtype.Text{String: jobExternalID, Valid: true},
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, importError(fmt.Sprintf("No imported job posting has external_id %q.", jobExternalID))
+			} else if err != nil {
+				return false, err
+			}
+		}
+
+		candidate, err := q.UpsertImportedCandidate(ctx, db.UpsertImportedCandidateParams{
+			Email:      email,
+			Name:       name,
+			ExternalID: pgtype.Text{String: row.get("external_id"), Valid: true},
+			ImportedBy: recruiter.ID,
+		})
+		if err != nil {
+			return false, err
+		}
+		for _, skillID := range skillIDs {
+			if err := q.AddSkillToUser(ctx, db.AddSkillToUserParams{UserID: candidate.ID, SkillID: skillID}); err != nil {
+				return false, err
+			}
+		}
+		if resumePDF != nil {
+			if err := q.UpdateUserResume(ctx, db.UpdateUserResumeParams{ID: candidate.ID, ResumePdf: resumePDF}); err != nil {
+				return false, err
+			}
+		}
+		if jobID.Valid {
+			_, err := q.CheckApplicationExists(ctx, db.CheckApplicationExistsParams{UserID: candidate.ID, JobPostingID: jobID})
+			if errors.Is(err, pgx.ErrNoRows) {
+				_, err = q.CreateApplication(ctx, db.CreateApplicationParams{
+					UserID:       candidate.ID,
+					JobPostingID: jobID,
+					Source:       ApplicationSourceImport,
+				})
+			}
+			if err != nil {
+				return false, err
+			}
+		}
+		return candidate.Inserted, nil
+	})
+	if err != nil {
+		fmt.Printf("Import Candidates: Failed to run import for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to import candidates.")
+		return
+	}
+
+	if !dryRun {
+		fmt.Printf("Recruiter %s imported %d candidate rows\n", recruiter.ID.String(), len(rows))
+	}
+	app.renderImportReport(c, "Candidates", dryRun, results)
+}
+
+func (app *App) renderImportReport(c *gin.Context, kind string, dryRun bool, results []importResult) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Outcome]++
+	}
+	var summary []string
+	for _, outcome := range []string{"Created", "Would create", "Updated", "Would update", "Error"} {
+		if counts[outcome] > 0 {
+			summary = append(summary, fmt.Sprintf("%s: %d", outcome, counts[outcome]))
+		}
+	}
+
+	heading := kind + " Import"
+	if dryRun {
+		heading = kind + " Import: Validation"
+	}
+	app.render(c, http.StatusOK, "import_report.html", heading, gin.H{
+		"Summary": summary,
+		"DryRun":  dryRun,
+		"Results": results,
+	})
+}
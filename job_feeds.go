@@ -0,0 +1,387 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// siteConfig describes the public careers site for structured data and feeds.
+type siteConfig struct {
+	BaseURL     string // e.g. https://careers.example.com; derived from the request when empty
+	CompanyName string
+	Currency    string // ISO 4217 code used for salaries
+}
+
+// absoluteURL turns a site path into a full URL, which feeds and structured
+// data require.
+func (app *App) absoluteURL(c *gin.Context, path string) string {
+	if app.site.BaseURL != "" {
+		return strings.TrimRight(app.site.BaseURL, "/") + path
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+// JSON-LD types for https://schema.org/JobPosting.
+type jsonLDJobPosting struct {
+	Context            string              `json:"@context"`
+	Type               string              `json:"@type"`
+	Title              string              `json:"title"`
+	Description        string              `json:"description"`
+	URL                string              `json:"url"`
+	Identifier         jsonLDPropertyValue `json:"identifier"`
+	DatePosted         string              `json:"datePosted,omitempty"`
+	ValidThrough       string              `json:"validThrough,omitempty"`
+	HiringOrganization jsonLDOrganization  `json:"hiringOrganization"`
+	JobLocation        *jsonLDPlace        `json:"jobLocation,omitempty"`
+	JobLocationType    string              `json:"jobLocationType,omitempty"`
+	BaseSalary         *jsonLDSalary       `json:"baseSalary,omitempty"`
+	TotalJobOpenings   int32               `json:"totalJobOpenings,omitempty"`
+}
+
+type jsonLDPropertyValue struct {
+	Type  string `json:"@type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type jsonLDOrganization struct {
+	Type   string `json:"@type"`
+	Name   string `json:"name"`
+	SameAs string `json:"sameAs,omitempty"`
+}
+
+type jsonLDPlace struct {
+	Type    string `json:"@type"`
+	Address struct {
+		Type            string `json:"@type"`
+		AddressLocality string `json:"addressLocality"`
+	} `json:"address"`
+}
+
+type jsonLDSalary struct {
+	Type     string `json:"@type"`
+	Currency string `json:"currency"`
+	Value    struct {
+		Type     string      `json:"@type"`
+		MinValue json.Number `json:"minValue,omitempty"`
+		MaxValue json.Number `json:"maxValue,omitempty"`
+		UnitText string      `json:"unitText"`
+	} `json:"value"`
+}
+
+// jobPostingJSONLD builds the schema.org JobPosting for a public job page. The
+// layout encodes it into an application/ld+json script tag.
+func (app *App) jobPostingJSONLD(c *gin.Context, job db.GetJobPostingByIDRow) jsonLDJobPosting {
+	posting := jsonLDJobPosting{
+		Context:     "https://schema.org/",
+		Type:        "JobPosting",
+		Title:       job.Title,
+		Description: job.Description.String,
+		URL:         app.absoluteURL(c, careersURL(job.Slug)),
+		Identifier: jsonLDPropertyValue{
+			Type:  "PropertyValue",
+			Name:  app.site.CompanyName,
+			Value: uuid.UUID(job.ID.Bytes).String(),
+		},
+		HiringOrganization: jsonLDOrganization{
+			Type:   "Organization",
+			Name:   app.site.CompanyName,
+			SameAs: app.absoluteURL(c, "/"),
+		},
+		TotalJobOpenings: job.Headcount,
+	}
+	if posting.Description == "" {
+		posting.Description = job.Title
+	}
+	if job.PublishedAt.Valid {
+		posting.DatePosted = job.PublishedAt.Time.UTC().Format(time.RFC3339)
+	}
+	if job.ExpiresAt.Valid {
+		posting.ValidThrough = job.ExpiresAt.Time.UTC().Format(time.RFC3339)
+	}
+	if job.Location.Valid {
+		place := &jsonLDPlace{Type: "Place"}
+		place.Address.Type = "PostalAddress"
+		place.Address.AddressLocality = job.Location.String
+		posting.JobLocation = place
+	}
+	if job.Remote {
+		posting.JobLocationType = "TELECOMMUTE"
+	}
+	minVal, minOK := numericToDecimal(job.SalaryMin)
+	maxVal, maxOK := numericToDecimal(job.SalaryMax)
+	if minOK || maxOK {
+		salary := &jsonLDSalary{Type: "MonetaryAmount", Currency: app.site.Currency}
+		salary.Value.Type = "QuantitativeValue"
+		salary.Value.UnitText = "YEAR"
+		if minOK {
+			salary.Value.MinValue = json.Number(minVal.String())
+		}
+		if maxOK {
+			salary.Value.MaxValue = json.Number(maxVal.String())
+		}
+		posting.BaseSalary = salary
+	}
+	return posting
+}
+
+// listFeedJobs loads the published postings for a feed.
+func (app *App) listFeedJobs(c *gin.Context) ([]db.ListPublishedJobPostingsRow, bool) {
+	postings, err := app.db.ListPublishedJobPostings(c.Request.Context())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Job Feeds: DB error listing published jobs: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to load jobs.")
+		return nil, false
+	}
+	return postings, true
+}
+
+func feedTime(ts pgtype.Timestamptz) time.Time {
+	if !ts.Valid {
+		return time.Time{}
+	}
+	return ts.Time.UTC()
+}
+
+// feedUpdated is the time of the newest posting, or now for an empty feed.
+func feedUpdated(postings []db.ListPublishedJobPostingsRow) time.Time {
+	var updated time.Time
+	for _, posting := range postings {
+		if t := feedTime(posting.PublishedAt); t.After(updated) {
+			updated = t
+		}
+	}
+	if updated.IsZero() {
+		return time.Now().UTC()
+	}
+	return updated
+}
+
+func writeXML(c *gin.Context, contentType string, v any) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Printf("Job Feeds: Failed to encode feed: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to build feed.")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), out...))
+}
+
+// XML job feed in the format most aggregators (Indeed and others) ingest.
+type xmlJobFeed struct {
+	XMLName       xml.Name     `xml:"source"`
+	Publisher     string       `xml:"publisher"`
+	PublisherURL  string       `xml:"publisherurl"`
+	LastBuildDate string       `xml:"lastBuildDate"`
+	Jobs          []xmlFeedJob `xml:"job"`
+}
+
+type xmlFeedJob struct {
+	Title           cdata  `xml:"title"`
+	Date            string `xml:"date"`
+	ReferenceNumber string `xml:"referencenumber"`
+	URL             string `xml:"url"`
+	Company         cdata  `xml:"company"`
+	City            *cdata `xml:"city,omitempty"`
+	RemoteType      string `xml:"remotetype,omitempty"`
+	Description     cdata  `xml:"description"`
+	Salary          string `xml:"salary,omitempty"`
+	ExpirationDate  string `xml:"expirationdate,omitempty"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+func (app *App) getXMLJobFeedHandler(c *gin.Context) {
+	postings, ok := app.listFeedJobs(c)
+	if !ok {
+		return
+	}
+
+	feed := xmlJobFeed{
+		Publisher:     app.site.CompanyName,
+		PublisherURL:  app.absoluteURL(c, "/careers"),
+		LastBuildDate: feedUpdated(postings).Format(time.RFC1123),
+	}
+	for _, posting := range postings {
+		job := xmlFeedJob{
+			Title:           cdata{posting.Title},
+			Date:            feedTime(posting.PublishedAt).Format(time.RFC1123),
+			ReferenceNumber: uuid.UUID(posting.ID.Bytes).String(),
+			URL:             app.absoluteURL(c, careersURL(posting.Slug)),
+			Company:         cdata{app.site.CompanyName},
+			Description:     cdata{posting.Description.String},
+		}
+		if posting.Location.Valid {
+			job.City = &cdata{posting.Location.String}
+		}
+		if posting.Remote {
+			job.RemoteType = "Fully remote"
+		}
+		if salary := salaryRangeText(posting.SalaryMin, posting.SalaryMax); salary != "Not specified" {
+			job.Salary = salary + " " + app.site.Currency + " per year"
+		}
+		if posting.ExpiresAt.Valid {
+			job.ExpirationDate = feedTime(posting.ExpiresAt).Format("2006-01-02")
+		}
+		feed.Jobs = append(feed.Jobs, job)
+	}
+
+	writeXML(c, "application/xml; charset=utf-8", feed)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func (app *App) getRSSJobFeedHandler(c *gin.Context) {
+	postings, ok := app.listFeedJobs(c)
+	if !ok {
+		return
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         app.site.CompanyName + " Careers",
+			Link:          app.absoluteURL(c, "/careers"),
+			Description:   "Open positions at " + app.site.CompanyName,
+			LastBuildDate: feedUpdated(postings).Format(time.RFC1123Z),
+			SelfLink:      atomLink{Href: app.absoluteURL(c, "/careers/rss.xml"), Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, posting := range postings {
+		jobURL := app.absoluteURL(c, careersURL(posting.Slug))
+		item := rssItem{
+			Title:       posting.Title,
+			Link:        jobURL,
+			GUID:        rssGUID{IsPermaLink: true, Value: jobURL},
+			Description: feedSummary(posting),
+		}
+		if posting.PublishedAt.Valid {
+			item.PubDate = feedTime(posting.PublishedAt).Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	writeXML(c, "application/rss+xml; charset=utf-8", feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string   `xml:"title"`
+	ID        string   `xml:"id"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published,omitempty"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary"`
+}
+
+func (app *App) getAtomJobFeedHandler(c *gin.Context) {
+	postings, ok := app.listFeedJobs(c)
+	if !ok {
+		return
+	}
+
+	feed := atomFeed{
+		Title:   app.site.CompanyName + " Careers",
+		ID:      app.absoluteURL(c, "/careers"),
+		Updated: feedUpdated(postings).Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: app.absoluteURL(c, "/careers/atom.xml"), Rel: "self", Type: "application/atom+xml"},
+			{Href: app.absoluteURL(c, "/careers"), Rel: "alternate", Type: "text/html"},
+		},
+		Author: atomAuthor{Name: app.site.CompanyName},
+	}
+	for _, posting := range postings {
+		jobURL := app.absoluteURL(c, careersURL(posting.Slug))
+		entry := atomEntry{
+			Title:   posting.Title,
+			ID:      jobURL,
+			Link:    atomLink{Href: jobURL, Rel: "alternate"},
+			Updated: feedUpdated(postings).Format(time.RFC3339),
+			Summary: feedSummary(posting),
+		}
+		if posting.PublishedAt.Valid {
+			entry.Published = feedTime(posting.PublishedAt).Format(time.RFC3339)
+			entry.Updated = entry.Published
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	writeXML(c, "application/atom+xml; charset=utf-8", feed)
+}
+
+// feedSummary is the plain-text blurb used by the RSS and Atom feeds.
+func feedSummary(posting db.ListPublishedJobPostingsRow) string {
+	summary := fmt.Sprintf("Location: %s. Salary: %s.",
+		jobLocationText(posting.Location, posting.Remote),
+		salaryRangeText(posting.SalaryMin, posting.SalaryMax),
+	)
+	if posting.Description.Valid {
+		summary += "\n\n" + posting.Description.String
+	}
+	return summary
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,44 +41,21 @@ func (app *App) getJobPostingFormHandler(c *gin.Context) {
 		return
 	}
 	if user.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only recruiters can post jobs</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only recruiters can post jobs")
 		c.Abort()
 		return
 	}
 
-	formHTML := `
-        <h2>Create New Job Posting</h2>
-        <form method="POST" action="/jobs">
-            <div>
-                <label for="title">Job Title:</label><br>
-                <input type="text" id="title" name="title" required>
-            </div>
-            <br>
-            <div>
-                <label for="salary_min">Minimum Salary (Optional):</label><br>
-                <input type="number" step="0.01" id="salary_min" name="salary_min" placeholder="e.g., 50000.00">
-            </div>
-            <br>
-            <div>
-                <label for="salary_max">Maximum Salary (Optional):</label><br>
-                <input type="number" step="0.01" id="salary_max" name="salary_max" placeholder="e.g., 80000.00">
-            </div>
-            <br>
-            <button type="submit">Create Job Posting</button>
-        </form>
-        <br>
-        <p><a href="/recruiter/dashboard">Back to Dashboard</a></p>
-    `
-
-	fullHTML := fmt.Sprintf(`
-        <!DOCTYPE html><html><head><title>New Job Posting</title></head><body>
-        <nav>...</nav><hr>
-        %s
-        <hr><footer>...</footer></body></html>`, formHTML)
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	draft := jobPostingDraft{Headcount: 1}
+	if templateIDStr := c.Query("template"); templateIDStr != "" {
+		jobTemplate, questions, found := app.loadTemplate(c.Request.Context(), templateIDStr, pgID)
+		if !found {
+			app.renderError(c, http.StatusNotFound, "Template not found.")
+			return
+		}
+		draft = draftFromTemplate(jobTemplate, questions)
+	}
+	app.renderJobPostingForm(c, draft)
 }
 
 func (app *App) createJobPostingHandler(c *gin.Context) {
@@ -99,19 +79,19 @@ func (app *App) createJobPostingHandler(c *gin.Context) {
 		return
 	}
 	if user.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only recruiters can post jobs</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only recruiters can post jobs")
 		c.Abort()
 		return
 	}
 
 	title := c.PostForm("title")
+	description := strings.TrimSpace(c.PostForm("description"))
+	location := strings.TrimSpace(c.PostForm("location"))
 	salaryMinStr := c.PostForm("salary_min")
 	salaryMaxStr := c.PostForm("salary_max")
 
 	if strings.TrimSpace(title) == "" {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Job title is required.</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Job title is required.")
 		return
 	}
 
@@ -123,13 +103,12 @@ func (app *App) createJobPostingHandler(c *gin.Context) {
 	if salaryMinStr != "" {
 		decMin, err = decimal.NewFromString(salaryMinStr)
 		if err != nil {
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusBadRequest, "<html><body>Invalid Minimum Salary format.</body></html>")
+			app.renderError(c, http.StatusBadRequest, "Invalid Minimum Salary format.")
 			return
 		} else {
 			err = salaryMinPg.Scan(decMin.String())
 			if err != nil {
-				c.String(http.StatusBadRequest, "<html><body>Error processing Minimum Salary: %v</body></html>", err)
+				app.renderError(c, http.StatusBadRequest, fmt.Sprintf("Error processing Minimum Salary: %v", err))
 				c.Abort()
 				return
 			} else {
@@ -141,13 +120,12 @@ func (app *App) createJobPostingHandler(c *gin.Context) {
 	if salaryMaxStr != "" {
 		decMax, err = decimal.NewFromString(salaryMaxStr)
 		if err != nil {
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusBadRequest, "<html><body>Invalid Maximum Salary format.</body></html>")
+			app.renderError(c, http.StatusBadRequest, "Invalid Maximum Salary format.")
 			return
 		} else {
 			err = salaryMaxPg.Scan(decMax.String())
 			if err != nil {
-				c.String(http.StatusBadRequest, "<html><body>Error processing Maximum Salary: %v</body></html>", err)
+				app.renderError(c, http.StatusBadRequest, fmt.Sprintf("Error processing Maximum Salary: %v", err))
 				c.Abort()
 				return
 			} else {
@@ -157,8 +135,39 @@ func (app *App) createJobPostingHandler(c *gin.Context) {
 	}
 
 	if minSet && maxSet && decMin.GreaterThan(decMax) {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Minimum Salary cannot be greater than Maximum Salary.</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Minimum Salary cannot be greater than Maximum Salary.")
+		return
+	}
+
+	headcount := 1
+	if headcountStr := c.PostForm("headcount"); headcountStr != "" {
+		headcount, err = strconv.Atoi(headcountStr)
+		if err != nil || headcount < 1 {
+			app.renderError(c, http.StatusBadRequest, "Positions to Fill must be at least 1.")
+			return
+		}
+	}
+
+	publishAt, err := parseDateTimeLocal(c.PostForm("publish_at"))
+	if err != nil {
+		app.renderError(c, http.StatusBadRequest, "Invalid publish time.")
+		return
+	}
+	expiresAt, err := parseDateTimeLocal(c.PostForm("expires_at"))
+	if err != nil {
+		app.renderError(c, http.StatusBadRequest, "Invalid expiry.")
+		return
+	}
+
+	status := JobStatusDraft
+	var publishedAt pgtype.Timestamptz
+	if c.PostForm("action") == "publish" {
+		status = JobStatusPublished
+		publishAt = pgtype.Timestamptz{}
+		publishedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	if message := validateJobSchedule(publishAt, expiresAt); message != "" {
+		app.renderError(c, http.StatusBadRequest, message)
 		return
 	}
 
@@ -167,18 +176,37 @@ func (app *App) createJobPostingHandler(c *gin.Context) {
 		Title:       title,
 		SalaryMin:   salaryMinPg,
 		SalaryMax:   salaryMaxPg,
-		Status:      "active",
+		Status:      status,
+		Headcount:   int32(headcount),
+		AutoClose:   c.PostForm("auto_close") == "true",
+		PublishAt:   publishAt,
+		ExpiresAt:   expiresAt,
+		PublishedAt: publishedAt,
+		Description: pgtype.Text{String: description, Valid: description != ""},
+		Slug:        newJobSlug(title),
+		Location:    pgtype.Text{String: location, Valid: location != ""},
+		Remote:      c.PostForm("remote") == "true",
+	}
+
+	var templateQuestions []templateQuestion
+	if templateIDStr := c.PostForm("template_id"); templateIDStr != "" {
+		if _, questions, found := app.loadTemplate(c.Request.Context(), templateIDStr, pgID); found {
+			templateQuestions = questions
+		}
 	}
 
-	_, dbErr := app.db.CreateJobPosting(c.Request.Context(), params)
+	created, dbErr := app.db.CreateJobPosting(c.Request.Context(), params)
 	if dbErr != nil {
 		fmt.Printf("Create Job Posting DB Error: %v\n", dbErr)
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusInternalServerError, "<html><body>Failed to create job posting. Please try again.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Failed to create job posting. Please try again.")
 		return
 	}
+	app.addJobPostingDetails(c.Request.Context(), created.ID, parseSkillIDs(c.PostFormArray("skill_ids")), templateQuestions)
+	if status == JobStatusPublished {
+		app.enqueueJobPublishedWebhook(c.Request.Context(), created.ID)
+	}
 
-	fmt.Printf("Successfully created job posting '%s' by recruiter %s\n", title, pgID.String())
+	fmt.Printf("Successfully created %s job posting '%s' by recruiter %s\n", status, title, pgID.String())
 	c.Redirect(http.StatusSeeOther, "/recruiter/dashboard")
 }
 
@@ -203,8 +231,7 @@ func (app *App) getApplicantProfileByRecruiterHandler(c *gin.Context) {
 		return
 	}
 	if recruiterUser.Role != RoleRecruiter {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Access denied</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Access denied")
 		c.Abort()
 		return
 	}
@@ -212,8 +239,7 @@ func (app *App) getApplicantProfileByRecruiterHandler(c *gin.Context) {
 	applicantIDStr := c.Param("applicantID")
 	applicantUUID, err := uuid.Parse(applicantIDStr)
 	if err != nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Invalid Applicant ID </body></html>")
+		app.renderError(c, http.StatusBadRequest, "Invalid Applicant ID ")
 		return
 	}
 	applicantPgID := pgtype.UUID{Bytes: applicantUUID, Valid: true}
@@ -221,19 +247,16 @@ func (app *App) getApplicantProfileByRecruiterHandler(c *gin.Context) {
 	applicantUser, err := app.db.GetUser(c.Request.Context(), applicantPgID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusNotFound, "<html><body>Applicant not does not exist</body></html>")
+			app.renderError(c, http.StatusNotFound, "Applicant not does not exist")
 		} else {
 			fmt.Printf("Applicant Profile View: DB error fetching applicant %s: %v\n", applicantIDStr, err)
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusInternalServerError, "<html><body>Error fetching applicant data</body></html>")
+			app.renderError(c, http.StatusInternalServerError, "Error fetching applicant data")
 		}
 		return
 	}
 
 	if applicantUser.Role != RoleApplicant {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Cannot fetch details for this user</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Cannot fetch details for this user")
 		c.Abort()
 		return
 	}
@@ -242,66 +265,25 @@ func (app *App) getApplicantProfileByRecruiterHandler(c *gin.Context) {
 	if err != nil && err != sql.ErrNoRows {
 		fmt.Printf("Applicant Profile View: Failed to get skills for %s: %v\n", applicantIDStr, err)
 	}
-	var skillsHTML string
-	if len(skillNames) == 0 {
-		skillsHTML = "<p>No skills listed.</p>"
-	} else {
-		skillsHTML = "<ul>"
-		for _, name := range skillNames {
-			skillsHTML += fmt.Sprintf("<li>%s</li>", name)
-		}
-		skillsHTML += "</ul>"
-	}
 
 	parsedResume, err := app.db.GetParsedResume(c.Request.Context(), applicantPgID)
-	parsedResumeHtml := ""
-	if err != nil && err != sql.ErrNoRows {
+	resumeError := err != nil && err != sql.ErrNoRows
+	var prettyResume bytes.Buffer
+	if resumeError {
 		fmt.Printf("Applicant Profile View: Error checking resume for %s: %v\n", applicantIDStr, err)
-		parsedResumeHtml = "<p style='color:red;'>Error checking resume status.</p>"
-	} else if len(parsedResume) == 0 || err == sql.ErrNoRows {
-		parsedResumeHtml = "<p>No resume uploaded.</p>"
-	} else {
-		var prettyJSON bytes.Buffer
-		if err := json.Indent(&prettyJSON, parsedResume, "", "    "); err == nil {
-			parsedResumeHtml = fmt.Sprintf("<pre><code>%s</code></pre>", prettyJSON.String())
-
-		} else {
-			parsedResumeHtml = fmt.Sprintf("<pre><code>%s</code></pre>", prettyJSON.String())
+	} else if len(parsedResume) > 0 {
+		if err := json.Indent(&prettyResume, parsedResume, "", "    "); err != nil {
+			prettyResume.Reset()
+			prettyResume.Write(parsedResume)
 		}
-
 	}
 
-	applicantProfileHTML := fmt.Sprintf(`
-        <h2>Applicant Profile</h2>
-        <p><strong>Name:</strong> %s</p>
-        <p><strong>Email:</strong> %s</p>
-        <hr>
-        <h3>Skills</h3>
-        %s
-        <hr>
-        <h3>Resume</h3>
-        %s
-        <hr>
-        `,
-		applicantUser.Name,
-		applicantUser.Email,
-		skillsHTML,
-		parsedResumeHtml,
-	)
-
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Applicant Profile - %s</title></head><body>
-		<nav>...</nav><hr>
-		%s
-		<hr>
-		<p><a href="/recruiter/search">Back to Search Results</a></p>
-        <p><a href="/recruiter/dashboard">Back to Dashboard</a></p>
-		</body></html>`,
-		applicantUser.Name,
-		applicantProfileHTML)
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	app.render(c, http.StatusOK, "applicant_profile.html", "Applicant Profile - "+applicantUser.Name, gin.H{
+		"Applicant":   applicantUser,
+		"Skills":      skillNames,
+		"Resume":      prettyResume.String(),
+		"ResumeError": resumeError,
+	})
 }
 
 func (app *App) listJobsHandler(c *gin.Context) {
@@ -329,160 +311,93 @@ func (app *App) listJobsHandler(c *gin.Context) {
 		return
 	}
 
-	postings, err := app.db.ListActiveJobPostings(c.Request.Context())
-	if err != nil && err != sql.ErrNoRows {
-		fmt.Printf("List Jobs: DB error listing active jobs: %v\n", err)
-	}
-
-	var jobsListHTML strings.Builder
-	jobsListHTML.WriteString("<h2>Available Job Postings</h2>")
-
-	if err != nil && err != sql.ErrNoRows {
-		jobsListHTML.WriteString("<p style='color:red;'>Error loading job postings.</p>")
-	} else if len(postings) == 0 {
-		jobsListHTML.WriteString("<p>There are currently no active job postings.</p>")
-	} else {
-		jobsListHTML.WriteString("<table border='1' style='border-collapse: collapse; width: 80%;'>")
-		jobsListHTML.WriteString("<thead><tr><th>Title</th><th>Recruiter</th><th>Salary Min</th><th>Salary Max</th><th>Status</th><th>Action</th></tr></thead>")
-		jobsListHTML.WriteString("<tbody>")
-		for _, posting := range postings {
-			var jobIDStr string
-			if posting.ID.Valid {
-				jobIDStr = uuid.UUID(posting.ID.Bytes).String()
-			} else {
-				continue
-			}
-
-			salaryMinVal, err := posting.SalaryMin.Value()
-			if err != nil {
-				salaryMinVal = ""
-			}
-			salaryMaxVal, err := posting.SalaryMax.Value()
-			if err != nil {
-				salaryMaxVal = ""
-			}
-
-			applyLink := fmt.Sprintf("/jobs/%s/apply", jobIDStr)
-
-			jobsListHTML.WriteString("<tr>")
-			jobsListHTML.WriteString(fmt.Sprintf("<td>%s</td>", posting.Title))
-			jobsListHTML.WriteString(fmt.Sprintf("<td>%s</td>", posting.Status))
-			jobsListHTML.WriteString(fmt.Sprintf("<td>%s</td>", salaryMinVal))
-			jobsListHTML.WriteString(fmt.Sprintf("<td>%v</td>", salaryMaxVal))
-			jobsListHTML.WriteString(fmt.Sprintf("<td>%v</td>", posting.Status))
-			jobsListHTML.WriteString(fmt.Sprintf(`<td><a href="%s">Apply</a></td>`, applyLink))
-			jobsListHTML.WriteString("</tr>")
-		}
-		jobsListHTML.WriteString("</tbody></table>")
+	postings, err := app.db.ListPublishedJobPostings(c.Request.Context())
+	postingsError := err != nil && err != sql.ErrNoRows
+	if postingsError {
+		fmt.Printf("List Jobs: DB error listing published jobs: %v\n", err)
 	}
 
 	backLink := "/"
-	if err == nil {
-		if user.Role == RoleApplicant {
-			backLink = "/applicant/dashboard"
-		} else if user.Role == RoleRecruiter {
-			backLink = "/recruiter/dashboard"
-		}
+	if user.Role == RoleApplicant {
+		backLink = "/applicant/dashboard"
+	} else if user.Role == RoleRecruiter {
+		backLink = "/recruiter/dashboard"
 	}
 
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Available Jobs</title></head><body>
-		<nav>...</nav><hr>
-		%s 
-		<hr>
-		<p><a href="%s">Back to Dashboard</a></p>
-		<p><a href="/logout">Logout</a></p>
-		<footer>...</footer></body></html>`,
-		jobsListHTML.String(),
-		backLink,
-	)
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	app.render(c, http.StatusOK, "jobs.html", "Available Jobs", gin.H{
+		"Postings":      postings,
+		"PostingsError": postingsError,
+		"BackLink":      backLink,
+	})
 }
 
 func (app *App) getJobApplicationsHandler(c *gin.Context) {
-
-	jobIDStr := c.Param("jobID")
-	jobUUID, err := uuid.Parse(jobIDStr)
-	if err != nil {
-
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
 		return
 	}
-	jobPgID := pgtype.UUID{Bytes: jobUUID, Valid: true}
-
-	job, err := app.db.GetJobPostingByID(c.Request.Context(), jobPgID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.String(http.StatusNotFound, "Job posting not found")
-		} else {
-			c.String(http.StatusInternalServerError, "Error fetching job data")
-		}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
 		return
 	}
+	jobPgID := job.ID
+	jobIDStr := uuid.UUID(jobPgID.Bytes).String()
 
 	applications, err := app.db.GetApplicationsForJobPosting(c.Request.Context(), jobPgID)
-	if err != nil && err != sql.ErrNoRows {
+	applicationsError := err != nil && err != sql.ErrNoRows
+	if applicationsError {
 		fmt.Printf("Manage Applications GET: DB error fetching applications for job %s: %v\n", jobIDStr, err)
-		c.String(http.StatusInternalServerError, "Failed to fetch applications for job posting: %v", err)
 	}
 
-	var applicationsHTML strings.Builder
-	applicationsHTML.WriteString(fmt.Sprintf("<h2>Applications for: %s</h2>", job.Title))
-
-	if err != nil && err != sql.ErrNoRows {
-		applicationsHTML.WriteString("<p style='color:red;'>Error loading applications.</p>")
-	} else if len(applications) == 0 {
-		applicationsHTML.WriteString("<p>No applications received yet.</p>")
-	} else {
-		applicationsHTML.WriteString("<table border='1' style='border-collapse: collapse;'>")
-		applicationsHTML.WriteString("<thead><tr><th>Applicant Name</th><th>Email</th><th>Status</th><th>Applied At</th><th>Actions</th></tr></thead>")
-		applicationsHTML.WriteString("<tbody>")
-		for _, application := range applications {
-			var appIDStr string
-			if application.ApplicationID.Valid {
-				appIDStr = uuid.UUID(application.ApplicationID.Bytes).String()
-			} else {
-				continue
-			}
-
-			appliedAtStr := "N/A"
-			if application.AppliedAt.Valid {
-				appliedAtStr = application.AppliedAt.Time.Format(time.RFC822)
-			}
-
-			rejectForm := fmt.Sprintf(`<form method="POST" action="/recruiter/jobs/%s/applications/%s/reject" style="display:inline;"><button type="submit">Reject</button></form>`, jobIDStr, appIDStr)
-			interviewForm := fmt.Sprintf(`<form method="POST" action="/recruiter/jobs/%s/applications/%s/interview" style="display:inline;"><button type="submit">Request Interview</button></form>`, jobIDStr, appIDStr)
-
-			if application.ApplicationStatus == "rejected" {
-
-				rejectForm = "<span>Rejected</span>"
-				interviewForm = ""
-			}
-
-			applicationsHTML.WriteString("<tr>")
-			applicationsHTML.WriteString(fmt.Sprintf("<td>%s</td>", application.UserName))
-			applicationsHTML.WriteString(fmt.Sprintf("<td>%s</td>", application.UserEmail))
-			applicationsHTML.WriteString(fmt.Sprintf("<td>%s</td>", application.ApplicationStatus))
-			applicationsHTML.WriteString(fmt.Sprintf("<td>%s</td>", appliedAtStr))
-			applicationsHTML.WriteString(fmt.Sprintf("<td>%s %s</td>", rejectForm, interviewForm))
-			applicationsHTML.WriteString("</tr>")
-		}
-		applicationsHTML.WriteString("</tbody></table>")
+	screeningAnswers, answersErr := app.db.ListScreeningAnswersForJob(c.Request.Context(), jobPgID)
+	if answersErr != nil && !errors.Is(answersErr, sql.ErrNoRows) {
+		fmt.Printf("Manage Applications GET: DB error fetching screening answers for job %s: %v\n", jobIDStr, answersErr)
+	}
+	type screeningAnswer struct {
+		Prompt string
+		Answer string
+	}
+	answersByApplication := make(map[uuid.UUID][]screeningAnswer)
+	for _, answer := range screeningAnswers {
+		applicationID := uuid.UUID(answer.ApplicationID.Bytes)
+		answersByApplication[applicationID] = append(answersByApplication[applicationID],
+			screeningAnswer{Prompt: answer.Prompt, Answer: answer.Answer})
 	}
 
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Manage Applications</title></head><body>
-		<nav>...</nav><hr>
-		%s
-		<hr>
-		<p><a href="/recruiter/dashboard">Back to Dashboard</a></p>
-		</body></html>`,
-		applicationsHTML.String(),
-	)
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	type applicationRow struct {
+		ID           string
+		UserName     string
+		UserEmail    string
+		Status       string
+		AppliedAt    string
+		Answers      []screeningAnswer
+		CanReject    bool
+		CanInterview bool
+	}
+	rows := make([]applicationRow, 0, len(applications))
+	for _, application := range applications {
+		if !application.ApplicationID.Valid {
+			continue
+		}
+		applicationID := uuid.UUID(application.ApplicationID.Bytes)
+		rows = append(rows, applicationRow{
+			ID:           applicationID.String(),
+			UserName:     application.UserName,
+			UserEmail:    application.UserEmail,
+			Status:       application.ApplicationStatus,
+			AppliedAt:    formatTimestamp(application.AppliedAt, "N/A"),
+			Answers:      answersByApplication[applicationID],
+			CanReject:    canTransitionApplication(application.ApplicationStatus, ApplicationStatusRejected),
+			CanInterview: canTransitionApplication(application.ApplicationStatus, ApplicationStatusAccepted),
+		})
+	}
+
+	app.render(c, http.StatusOK, "job_applications.html", "Manage Applications", gin.H{
+		"Job":               job,
+		"Applications":      rows,
+		"ApplicationsError": applicationsError,
+		"CSRF":              csrfToken(c),
+	})
 }
 
 func (app *App) rejectApplicationHandler(c *gin.Context) {
@@ -519,18 +434,36 @@ func (app *App) rejectApplicationHandler(c *gin.Context) {
 	applicationIDStr := c.Param("applicationID")
 	appUUID, err := uuid.Parse(applicationIDStr)
 	if err != nil {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusBadRequest, "<html><body>Invalid Application ID format</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Invalid Application ID format")
 		return
 	}
 	appPgID := pgtype.UUID{Bytes: appUUID, Valid: true}
 
-	params := db.UpdateApplicationStatusParams{
-		ID:     appPgID,
-		Status: "rejected",
-	}
-	err = app.db.UpdateApplicationStatus(c.Request.Context(), params)
+	application, err := app.db.GetApplicationByID(c.Request.Context(), appPgID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.String(http.StatusNotFound, "Application not found.")
+		} else {
+			fmt.Printf("Reject Application POST: DB error fetching application %s: %v\n", applicationIDStr, err)
+			c.String(http.StatusInternalServerError, "Error verifying application.")
+		}
+		return
+	}
+
+	if uuid.UUID(application.JobPostingID.Bytes).String() != jobIDStr {
+		c.String(http.StatusBadRequest, "Application/Job mismatch.")
+		return
+	}
+	if !application.RecruiterID.Valid || application.RecruiterID.Bytes != recruiterPgID.Bytes {
+		c.String(http.StatusForbidden, "Forbidden: You do not own the job posting for this application.")
+		return
+	}
+
+	err = app.changeApplicationStatus(c.Request.Context(), application, ApplicationStatusRejected, recruiterPgID, "")
+	if errors.Is(err, errInvalidStatusTransition) {
+		app.renderErrorBack(c, http.StatusBadRequest, fmt.Sprintf("Cannot reject application with status '%s'.", application.Status), "/recruiter/jobs/"+jobIDStr+"/applications", "Back")
+		return
+	} else if err != nil {
 		fmt.Printf("Reject Application POST: DB error updating status for app %s: %v\n", applicationIDStr, err)
 		c.String(http.StatusInternalServerError, "Failed to update application status.")
 		return
@@ -541,3 +474,76 @@ func (app *App) rejectApplicationHandler(c *gin.Context) {
 	redirectURL := fmt.Sprintf("/recruiter/jobs/%s/applications", jobIDStr)
 	c.Redirect(http.StatusSeeOther, redirectURL)
 }
+
+// loadOwnedJob parses the :jobID param and loads the job posting, writing an
+// error response and returning false unless it belongs to recruiterID.
+func (app *App) loadOwnedJob(c *gin.Context, recruiterID pgtype.UUID) (db.GetJobPostingByIDRow, bool) {
+	jobIDStr := c.Param("jobID")
+	jobUUID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		app.renderError(c, http.StatusBadRequest, "Invalid Job ID format")
+		return db.GetJobPostingByIDRow{}, false
+	}
+
+	job, err := app.db.GetJobPostingByID(c.Request.Context(), pgtype.UUID{Bytes: jobUUID, Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.String(http.StatusNotFound, "Job posting not found")
+		} else {
+			fmt.Printf("Load Job: DB error fetching job %s: %v\n", jobIDStr, err)
+			c.String(http.StatusInternalServerError, "Error fetching job data")
+		}
+		return db.GetJobPostingByIDRow{}, false
+	}
+
+	if !job.RecruiterID.Valid || job.RecruiterID.Bytes != recruiterID.Bytes {
+		app.renderError(c, http.StatusForbidden, "Forbidden: You do not own this job posting")
+		return db.GetJobPostingByIDRow{}, false
+	}
+
+	return job, true
+}
+
+// numericToDecimal converts a Postgres numeric to a decimal, reporting false
+// for NULL values.
+func numericToDecimal(n pgtype.Numeric) (decimal.Decimal, bool) {
+	if !n.Valid || n.NaN || n.Int == nil {
+		return decimal.Decimal{}, false
+	}
+	return decimal.NewFromBigInt(n.Int, n.Exp), true
+}
+
+// jobDetails is what the job_details template shows about a posting.
+type jobDetails struct {
+	Location    string
+	Description string
+	Skills      []string
+}
+
+func (app *App) jobDetails(ctx context.Context, job db.GetJobPostingByIDRow) jobDetails {
+	skills, err := app.db.ListJobPostingSkills(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Job Details: DB error fetching skills for job %s: %v\n", job.ID.String(), err)
+	}
+	details := jobDetails{
+		Location:    jobLocationText(job.Location, job.Remote),
+		Description: job.Description.String,
+		Skills:      make([]string, 0, len(skills)),
+	}
+	for _, skill := range skills {
+		details.Skills = append(details.Skills, skill.Name)
+	}
+	return details
+}
+
+func jobLocationText(location pgtype.Text, remote bool) string {
+	switch {
+	case location.Valid && remote:
+		return location.String + " (Remote)"
+	case location.Valid:
+		return location.String
+	case remote:
+		return "Remote"
+	}
+	return "Not specified"
+}
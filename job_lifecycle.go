@@ -0,0 +1,253 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	JobStatusDraft     = "draft"
+	JobStatusPublished = "published"
+	JobStatusPaused    = "paused"
+	JobStatusClosed    = "closed"
+	JobStatusArchived  = "archived"
+
+	// Layout of <input type="datetime-local"> values.
+	dateTimeLocalLayout = "2006-01-02T15:04"
+)
+
+var jobStatusLabels = map[string]string{
+	JobStatusDraft:     "Draft",
+	JobStatusPublished: "Published",
+	JobStatusPaused:    "Paused",
+	JobStatusClosed:    "Closed",
+	JobStatusArchived:  "Archived",
+}
+
+// jobTransitions lists the statuses a posting may move to from each status,
+// with the label of the button that makes the move. Archived postings are
+// final.
+var jobTransitions = map[string][]struct{ Status, Action string }{
+	JobStatusDraft:     {{JobStatusPublished, "Publish"}, {JobStatusArchived, "Archive"}},
+	JobStatusPublished: {{JobStatusPaused, "Pause"}, {JobStatusClosed, "Close"}},
+	JobStatusPaused:    {{JobStatusPublished, "Resume"}, {JobStatusClosed, "Close"}},
+	JobStatusClosed:    {{JobStatusPublished, "Reopen"}, {JobStatusArchived, "Archive"}},
+}
+
+func canTransitionJob(from, to string) bool {
+	for _, next := range jobTransitions[from] {
+		if next.Status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDateTimeLocal parses an optional datetime-local form value in the
+// server's time zone.
+func parseDateTimeLocal(value string) (pgtype.Timestamptz, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return pgtype.Timestamptz{}, nil
+	}
+	t, err := time.ParseInLocation(dateTimeLocalLayout, value, time.Local)
+	if err != nil {
+		return pgtype.Timestamptz{}, err
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}, nil
+}
+
+func formatDateTimeLocal(ts pgtype.Timestamptz) string {
+	if !ts.Valid {
+		return ""
+	}
+	return ts.Time.Local().Format(dateTimeLocalLayout)
+}
+
+func formatTimestamp(ts pgtype.Timestamptz, fallback string) string {
+	if !ts.Valid {
+		return fallback
+	}
+	return ts.Time.Format(time.RFC822)
+}
+
+// jobScheduleText describes when a posting next changes state, for listing
+// next to its status.
+func jobScheduleText(status string, publishAt, expiresAt pgtype.Timestamptz) string {
+	if status == JobStatusDraft && publishAt.Valid {
+		return " - publishes " + formatTimestamp(publishAt, "")
+	}
+	if expiresAt.Valid && (status == JobStatusPublished || status == JobStatusPaused) {
+		return " - closes " + formatTimestamp(expiresAt, "")
+	}
+	return ""
+}
+
+// validateJobSchedule checks that a posting scheduled to publish expires after
+// it goes live.
+func validateJobSchedule(publishAt, expiresAt pgtype.Timestamptz) string {
+	if expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		return "The expiry must be in the future."
+	}
+	if publishAt.Valid && expiresAt.Valid && !expiresAt.Time.After(publishAt.Time) {
+		return "The expiry must be after the scheduled publish time."
+	}
+	return ""
+}
+
+// getRecruiterJobHandler shows a posting's status and schedule with the
+// lifecycle actions available to its owner.
+func (app *App) getRecruiterJobHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	app.render(c, http.StatusOK, "recruiter_job.html", "Job: "+job.Title, gin.H{
+		"Job":         job,
+		"Transitions": jobTransitions[job.Status],
+		"Draft":       job.Status == JobStatusDraft,
+		"Schedulable": job.Status != JobStatusArchived,
+		"Details":     app.jobDetails(c.Request.Context(), job),
+		"Error":       c.Query("error"),
+		"CSRF":        csrfToken(c),
+	})
+}
+
+func (app *App) postJobStatusHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobURL := "/recruiter/jobs/" + uuid.UUID(job.ID.Bytes).String()
+
+	newStatus := c.PostForm("status")
+	if !canTransitionJob(job.Status, newStatus) {
+		c.Redirect(http.StatusSeeOther, jobURL+"?error="+url.QueryEscape(fmt.Sprintf("A %s posting cannot be moved to %s.", job.Status, newStatus)))
+		return
+	}
+	if newStatus == JobStatusPublished && job.ExpiresAt.Valid && !job.ExpiresAt.Time.After(time.Now()) {
+		c.Redirect(http.StatusSeeOther, jobURL+"?error="+url.QueryEscape("The posting's expiry has passed. Update the schedule before publishing."))
+		return
+	}
+
+	err := app.db.UpdateJobPostingStatus(c.Request.Context(), db.UpdateJobPostingStatusParams{ID: job.ID, Status: newStatus})
+	if err != nil {
+		fmt.Printf("Job Status POST: DB error updating job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to update job status.")
+		return
+	}
+
+	fmt.Printf("Job %s moved from '%s' to '%s' by recruiter %s\n", job.ID.String(), job.Status, newStatus, recruiter.ID.String())
+	if newStatus == JobStatusPublished {
+		app.enqueueJobPublishedWebhook(c.Request.Context(), job.ID)
+	}
+	c.Redirect(http.StatusSeeOther, jobURL)
+}
+
+func (app *App) postJobScheduleHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobURL := "/recruiter/jobs/" + uuid.UUID(job.ID.Bytes).String()
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, jobURL+"?error="+url.QueryEscape(message))
+	}
+
+	if job.Status == JobStatusArchived {
+		fail("Archived postings cannot be rescheduled.")
+		return
+	}
+
+	publishAt := job.PublishAt
+	if job.Status == JobStatusDraft {
+		var err error
+		publishAt, err = parseDateTimeLocal(c.PostForm("publish_at"))
+		if err != nil {
+			fail("Invalid publish time.")
+			return
+		}
+	}
+	expiresAt, err := parseDateTimeLocal(c.PostForm("expires_at"))
+	if err != nil {
+		fail("Invalid expiry.")
+		return
+	}
+	if job.Status == JobStatusDraft {
+		if message := validateJobSchedule(publishAt, expiresAt); message != "" {
+			fail(message)
+			return
+		}
+	} else if message := validateJobSchedule(pgtype.Timestamptz{}, expiresAt); message != "" {
+		fail(message)
+		return
+	}
+
+	err = app.db.UpdateJobPostingSchedule(c.Request.Context(), db.UpdateJobPostingScheduleParams{
+		ID:        job.ID,
+		PublishAt: publishAt,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		fmt.Printf("Job Schedule POST: DB error updating job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to update schedule.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, jobURL)
+}
+
+// runJobScheduler publishes postings whose scheduled time has come and closes
+// postings past their expiry, checking every interval until ctx is cancelled.
+func (app *App) runJobScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		app.applyJobSchedules(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *App) applyJobSchedules(ctx context.Context) {
+	published, err := app.db.PublishScheduledJobPostings(ctx)
+	if err != nil {
+		fmt.Printf("Job Scheduler: Failed to publish scheduled postings: %v\n", err)
+	}
+	for _, job := range published {
+		fmt.Printf("Job Scheduler: Published '%s' (%s)\n", job.Title, job.ID.String())
+		app.enqueueJobPublishedWebhook(ctx, job.ID)
+	}
+
+	expired, err := app.db.ExpireJobPostings(ctx)
+	if err != nil {
+		fmt.Printf("Job Scheduler: Failed to close expired postings: %v\n", err)
+	}
+	for _, job := range expired {
+		fmt.Printf("Job Scheduler: Closed expired posting '%s' (%s)\n", job.Title, job.ID.String())
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// jobPostingDraft holds the values the new job form is pre-filled with.
+type jobPostingDraft struct {
+	Title       string
+	Description string
+	SalaryMin   string
+	SalaryMax   string
+	Headcount   int32
+	SkillIDs    map[uuid.UUID]bool
+	TemplateID  string
+	Questions   int
+}
+
+// templateQuestion is a screening question as stored in a job template.
+type templateQuestion struct {
+	Position        int32    `json:"position"`
+	Prompt          string   `json:"prompt"`
+	Kind            string   `json:"kind"`
+	Options         []string `json:"options"`
+	Required        bool     `json:"required"`
+	Knockout        bool     `json:"knockout"`
+	KnockoutAnswers []string `json:"knockout_answers"`
+	KnockoutMin     string   `json:"knockout_min,omitempty"`
+	KnockoutMax     string   `json:"knockout_max,omitempty"`
+}
+
+func numericString(n pgtype.Numeric) string {
+	if d, ok := numericToDecimal(n); ok {
+		return d.StringFixed(2)
+	}
+	return ""
+}
+
+func questionsFromJob(rows []db.ScreeningQuestion) []templateQuestion {
+	questions := make([]templateQuestion, 0, len(rows))
+	for _, row := range rows {
+		questions = append(questions, templateQuestion{
+			Position:        row.Position,
+			Prompt:          row.Prompt,
+			Kind:            row.Kind,
+			Options:         row.Options,
+			Required:        row.Required,
+			Knockout:        row.Knockout,
+			KnockoutAnswers: row.KnockoutAnswers,
+			KnockoutMin:     numericString(row.KnockoutMin),
+			KnockoutMax:     numericString(row.KnockoutMax),
+		})
+	}
+	return questions
+}
+
+func (q templateQuestion) params(jobID pgtype.UUID) (db.CreateScreeningQuestionParams, error) {
+	params := db.CreateScreeningQuestionParams{
+		JobPostingID:    jobID,
+		Position:        q.Position,
+		Prompt:          q.Prompt,
+		Kind:            q.Kind,
+		Options:         q.Options,
+		Required:        q.Required,
+		Knockout:        q.Knockout,
+		KnockoutAnswers: q.KnockoutAnswers,
+	}
+	if params.Options == nil {
+		params.Options = []string{}
+	}
+	if params.KnockoutAnswers == nil {
+		params.KnockoutAnswers = []string{}
+	}
+	if q.KnockoutMin != "" {
+		if err := params.KnockoutMin.Scan(q.KnockoutMin); err != nil {
+			return params, err
+		}
+	}
+	if q.KnockoutMax != "" {
+		if err := params.KnockoutMax.Scan(q.KnockoutMax); err != nil {
+			return params, err
+		}
+	}
+	return params, nil
+}
+
+// addJobPostingDetails attaches skills and screening questions to a newly
+// created posting. Failures are logged so the posting itself is kept.
+func (app *App) addJobPostingDetails(ctx context.Context, jobID pgtype.UUID, skillIDs []pgtype.UUID, questions []templateQuestion) {
+	for _, skillID := range skillIDs {
+		err := app.db.AddSkillToJobPosting(ctx, db.AddSkillToJobPostingParams{
+			JobPostingID: jobID,
+			SkillID:      skillID,
+		})
+		if err != nil {
+			fmt.Printf("Job Details: Failed to add skill %s to job %s: %v\n", skillID.String(), jobID.String(), err)
+		}
+	}
+	for _, question := range questions {
+		params, err := question.params(jobID)
+		if err == nil {
+			_, err = app.db.CreateScreeningQuestion(ctx, params)
+		}
+		if err != nil {
+			fmt.Printf("Job Details: Failed to copy screening question %q to job %s: %v\n", question.Prompt, jobID.String(), err)
+		}
+	}
+}
+
+func parseSkillIDs(values []string) []pgtype.UUID {
+	skillIDs := make([]pgtype.UUID, 0, len(values))
+	for _, value := range values {
+		if skillUUID, err := uuid.Parse(value); err == nil {
+			skillIDs = append(skillIDs, pgtype.UUID{Bytes: skillUUID, Valid: true})
+		}
+	}
+	return skillIDs
+}
+
+// renderJobPostingForm renders the new job form, pre-filled from draft.
+func (app *App) renderJobPostingForm(c *gin.Context, draft jobPostingDraft) {
+	allSkills, err := app.db.ListSkills(c.Request.Context())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Job Form: Failed to list skills: %v\n", err)
+	}
+	if draft.Headcount < 1 {
+		draft.Headcount = 1
+	}
+
+	app.render(c, http.StatusOK, "job_form.html", "New Job Posting", gin.H{
+		"Draft":  draft,
+		"Skills": skillOptions(allSkills, draft.SkillIDs),
+		"CSRF":   csrfToken(c),
+	})
+}
+
+// loadTemplate loads one of the recruiter's templates by its form or query
+// value, reporting false if it does not exist or belongs to someone else.
+func (app *App) loadTemplate(ctx context.Context, templateIDStr string, recruiterID pgtype.UUID) (db.JobTemplate, []templateQuestion, bool) {
+	templateUUID, err := uuid.Parse(templateIDStr)
+	if err != nil {
+		return db.JobTemplate{}, nil, false
+	}
+	template, err := app.db.GetJobTemplate(ctx, db.GetJobTemplateParams{
+		ID:          pgtype.UUID{Bytes: templateUUID, Valid: true},
+		RecruiterID: recruiterID,
+	})
+	if err != nil {
+		return db.JobTemplate{}, nil, false
+	}
+
+	var questions []templateQuestion
+	if err := json.Unmarshal(template.ScreeningQuestions, &questions); err != nil {
+		fmt.Printf("Job Templates: Ignoring malformed questions in template %s: %v\n", templateIDStr, err)
+		questions = nil
+	}
+	return template, questions, true
+}
+
+func draftFromTemplate(template db.JobTemplate, questions []templateQuestion) jobPostingDraft {
+	skillIDs := make(map[uuid.UUID]bool, len(template.SkillIds))
+	for _, skillID := range template.SkillIds {
+		skillIDs[uuid.UUID(skillID.Bytes)] = true
+	}
+	return jobPostingDraft{
+		Title:       template.Title,
+		Description: template.Description.String,
+		SalaryMin:   numericString(template.SalaryMin),
+		SalaryMax:   numericString(template.SalaryMax),
+		Headcount:   template.Headcount,
+		SkillIDs:    skillIDs,
+		TemplateID:  uuid.UUID(template.ID.Bytes).String(),
+		Questions:   len(questions),
+	}
+}
+
+func (app *App) getJobTemplatesHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+
+	templates, err := app.db.ListJobTemplatesByRecruiter(c.Request.Context(), recruiter.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Job Templates GET: DB error listing templates for %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Error loading templates.")
+		return
+	}
+
+	app.render(c, http.StatusOK, "job_templates.html", "Job Templates", gin.H{
+		"Templates": templates,
+		"CSRF":      csrfToken(c),
+	})
+}
+
+func (app *App) postSaveJobTemplateHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = job.Title
+	}
+
+	skills, err := app.db.ListJobPostingSkills(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Save Template POST: DB error fetching skills for job %s: %v\n", job.ID.String(), err)
+	}
+	skillIDs := make([]pgtype.UUID, 0, len(skills))
+	for _, skill := range skills {
+		skillIDs = append(skillIDs, skill.ID)
+	}
+
+	questions, err := app.db.ListScreeningQuestionsForJob(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Save Template POST: DB error fetching questions for job %s: %v\n", job.ID.String(), err)
+	}
+	questionsJSON, err := json.Marshal(questionsFromJob(questions))
+	if err != nil {
+		fmt.Printf("Save Template POST: Failed to encode questions for job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save template.")
+		return
+	}
+
+	_, err = app.db.CreateJobTemplate(ctx, db.CreateJobTemplateParams{
+		RecruiterID:        recruiter.ID,
+		Name:               name,
+		Title:              job.Title,
+		Description:        job.Description,
+		SalaryMin:          job.SalaryMin,
+		SalaryMax:          job.SalaryMax,
+		Headcount:          job.Headcount,
+		SkillIds:           skillIDs,
+		ScreeningQuestions: questionsJSON,
+	})
+	if err != nil {
+		fmt.Printf("Save Template POST: DB error saving template from job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to save template.")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/recruiter/templates")
+}
+
+func (app *App) deleteJobTemplateHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+
+	templateUUID, err := uuid.Parse(c.Param("templateID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid template ID.")
+		return
+	}
+	err = app.db.DeleteJobTemplate(c.Request.Context(), db.DeleteJobTemplateParams{
+		ID:          pgtype.UUID{Bytes: templateUUID, Valid: true},
+		RecruiterID: recruiter.ID,
+	})
+	if err != nil {
+		fmt.Printf("Delete Template POST: DB error deleting template %s: %v\n", templateUUID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to delete template.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/recruiter/templates")
+}
+
+// postDuplicateJobHandler copies a posting, with its skills and screening
+// questions, into a new draft.
+func (app *App) postDuplicateJobHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	created, err := app.db.CreateJobPosting(ctx, db.CreateJobPostingParams{
+		RecruiterID: recruiter.ID,
+		Title:       job.Title + " (Copy)",
+		Description: job.Description,
+		SalaryMin:   job.SalaryMin,
+		SalaryMax:   job.SalaryMax,
+		Status:      JobStatusDraft,
+		Headcount:   job.Headcount,
+		AutoClose:   job.AutoClose,
+		Slug:        newJobSlug(job.Title),
+		Location:    job.Location,
+		Remote:      job.Remote,
+	})
+	if err != nil {
+		fmt.Printf("Duplicate Job POST: DB error copying job %s: %v\n", job.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to duplicate job posting.")
+		return
+	}
+
+	skills, err := app.db.ListJobPostingSkills(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Duplicate Job POST: DB error fetching skills for job %s: %v\n", job.ID.String(), err)
+	}
+	skillIDs := make([]pgtype.UUID, 0, len(skills))
+	for _, skill := range skills {
+		skillIDs = append(skillIDs, skill.ID)
+	}
+	questions, err := app.db.ListScreeningQuestionsForJob(ctx, job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Duplicate Job POST: DB error fetching questions for job %s: %v\n", job.ID.String(), err)
+	}
+	app.addJobPostingDetails(ctx, created.ID, skillIDs, questionsFromJob(questions))
+
+	fmt.Printf("Job %s duplicated as %s by recruiter %s\n", job.ID.String(), created.ID.String(), recruiter.ID.String())
+	c.Redirect(http.StatusSeeOther, "/recruiter/jobs/"+uuid.UUID(created.ID.Bytes).String())
+}
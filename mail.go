@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+var errSMTPNotConfigured = errors.New("SMTP configuration is missing")
+
+// sendEmail delivers a plain-text email using the SMTP_* environment settings.
+func sendEmail(to, subject, body string) error {
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := os.Getenv("SMTP_PORT")
+	smtpUser := os.Getenv("SMTP_USER")
+	smtpPassword := os.Getenv("SMTP_PASSWORD")
+	smtpFrom := os.Getenv("SMTP_FROM_EMAIL")
+	if smtpHost == "" || smtpPort == "" || smtpUser == "" || smtpPassword == "" || smtpFrom == "" {
+		return errSMTPNotConfigured
+	}
+	if to == "" {
+		return errors.New("recipient email is empty")
+	}
+
+	msg := []byte("To: " + headerValue(to) + "\r\n" +
+		"From: " + headerValue(smtpFrom) + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", headerValue(subject)) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		body + "\r\n")
+
+	auth := smtp.PlainAuth("", smtpUser, smtpPassword, smtpHost)
+
+	smtpAddr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
+	return smtp.SendMail(smtpAddr, auth, smtpFrom, []string{to}, msg)
+}
+
+// headerValue keeps a value on its header line, so text such as a job title
+// cannot end the header and add others.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -7,16 +7,16 @@ import (
 	"Recruitment-GO/api/user/profile"
 	db "Recruitment-GO/internal/db"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
-	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
-	"github.com/markbates/goth/providers/google"
 )
 
 func main() {
@@ -29,16 +29,25 @@ func main() {
 		log.Fatalf("FATAL: Environment variable is required for session security.")
 	}
 
-	googleClientID := os.Getenv("GOOGLE_CLIENT_ID")
-	googleClientSecret := os.Getenv("GOOGLE_CLIENT_SECRET")
-	if googleClientID == "" || googleClientSecret == "" {
-		log.Println("WARNING: GOOGLE_CLIENT_ID or GOOGLE_CLIENT_SECRET environment variables not set.")
-
-	}
-	callbackURL := os.Getenv("CALLBACK_URL")
-	if callbackURL == "" {
+	callbackURL := os.Getenv("CALLBACK_URL") // Google's callback; other providers have their own
+	if callbackURL == "" && os.Getenv("GOOGLE_CLIENT_ID") != "" {
 		log.Println("WARNING: CALLBACK_URL environment variable not set.")
 	}
+	// Public careers site details used in job feeds and structured data
+	site := siteConfig{
+		BaseURL:     os.Getenv("PUBLIC_BASE_URL"),
+		CompanyName: os.Getenv("COMPANY_NAME"),
+		Currency:    os.Getenv("SALARY_CURRENCY"),
+	}
+	if site.BaseURL == "" {
+		log.Println("WARNING: PUBLIC_BASE_URL not set. Feed links will use the request host.")
+	}
+	if site.CompanyName == "" {
+		site.CompanyName = "Recruitment-GO"
+	}
+	if site.Currency == "" {
+		site.Currency = "USD"
+	}
 	// Database Credentials
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
@@ -67,43 +76,180 @@ func main() {
 
 	dbQueries := db.New(pool) // Create SQLC  instance
 
-	sessionStore := cookie.NewStore([]byte(sessionSecret))
+	// Sessions are kept in Postgres; the cookie only carries a signed token.
+	// They end after a week, or sooner if unused for SESSION_IDLE_TIMEOUT.
+	idleTimeout := defaultSessionIdleTimeout
+	if raw := os.Getenv("SESSION_IDLE_TIMEOUT"); raw != "" {
+		idleTimeout, err = time.ParseDuration(raw)
+		if err != nil || idleTimeout <= 0 {
+			log.Fatalf("FATAL: Invalid SESSION_IDLE_TIMEOUT %q: use a duration such as 2h", raw)
+		}
+	}
+	secureCookies := strings.HasPrefix(site.BaseURL, "https://") || os.Getenv("SESSION_COOKIE_SECURE") == "true"
+	if !secureCookies {
+		log.Println("WARNING: Session cookies are not marked Secure. Serve the site over https and set PUBLIC_BASE_URL to an https:// URL.")
+	}
+	sessionStore := newPGSessionStore(dbQueries, idleTimeout, []byte(sessionSecret))
 	sessionStore.Options(sessions.Options{
 		Path:     "/",
-		MaxAge:   86400 * 30, // 30 days
+		MaxAge:   86400 * 7, // 7 days
 		HttpOnly: true,
-		Secure:   false,
+		Secure:   secureCookies,
+		SameSite: http.SameSiteLaxMode,
 	})
 
-	goth.UseProviders(
-		google.New(googleClientID, googleClientSecret, callbackURL),
-	)
+	authProviders := configureAuthProviders(callbackURL, site.BaseURL)
 	gothic.Store = sessionStore
 
+	// Events are delivered in-process unless several instances share the
+	// database, in which case they go through Postgres LISTEN/NOTIFY.
+	var eventsPool *pgxpool.Pool
+	if os.Getenv("EVENTS_PG_NOTIFY") == "true" {
+		eventsPool = pool
+	}
+	events := newEventBroker(eventsPool)
+	if eventsPool != nil {
+		go events.Run(context.Background())
+		log.Println("Dashboard events are fanned out through Postgres LISTEN/NOTIFY")
+	}
+
+	// SAML single sign-on needs a certificate and key to sign requests with.
+	var samlKeys *samlKeyPair
+	if certFile, keyFile := os.Getenv("SAML_SP_CERT_FILE"), os.Getenv("SAML_SP_KEY_FILE"); certFile != "" && keyFile != "" {
+		samlKeys, err = loadSAMLKeyPair(certFile, keyFile)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to load SAML service provider key pair: %v", err)
+		}
+	} else {
+		log.Println("SAML_SP_CERT_FILE/SAML_SP_KEY_FILE not set; SAML single sign-on is disabled")
+	}
+
+	// Recruiters and admins can always turn on two-factor authentication;
+	// TWO_FACTOR_REQUIRED makes them set it up before they can sign in.
+	requireTwoFactor := os.Getenv("TWO_FACTOR_REQUIRED") == "true"
+	if requireTwoFactor {
+		log.Println("Two-factor authentication is required for recruiter and admin accounts")
+	}
+
+	// WEBHOOK_DEV_MODE=true lets webhooks reach http:// and private
+	// addresses, such as a receiver on localhost.
+	webhookDevMode := os.Getenv("WEBHOOK_DEV_MODE") == "true"
+	if webhookDevMode {
+		log.Println("WARNING: Webhooks may be sent to http:// and private addresses (WEBHOOK_DEV_MODE).")
+	}
+
+	// Rate limit counters are kept in memory unless several instances need
+	// to share them, in which case RATE_LIMIT_STORE=postgres.
+	var limitStore rateLimitStore = newMemoryRateLimitStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		limitStore = &pgRateLimitStore{db: dbQueries}
+		log.Println("Rate limit counters are shared through Postgres")
+	}
+	rateLimits, err := newRateLimiter(limitStore)
+	if err != nil {
+		log.Fatalf("FATAL: Invalid rate limit: %v", err)
+	}
+
 	app := &App{
-		db:           dbQueries,
-		sessionStore: sessionStore, // Pass the store
+		db:            dbQueries,
+		pool:          pool,
+		sessionStore:  sessionStore, // Pass the store
+		events:        events,
+		site:          site,
+		webhooks:      newWebhookDispatcher(webhookDevMode),
+		authProviders: authProviders,
+		saml:          samlKeys,
+		rateLimits:    rateLimits,
+
+		requireTwoFactor: requireTwoFactor,
+	}
+
+	go app.runJobScheduler(context.Background(), time.Minute)
+	go app.runWebhookWorker(context.Background(), webhookPollInterval)
+	go app.runSessionCleanup(context.Background(), sessionCleanupInterval)
+	go app.runRateLimitCleanup(context.Background(), rateLimitCleanupInterval)
+
+	templates, err := loadTemplates()
+	if err != nil {
+		log.Fatalf("FATAL: Failed to parse page templates: %v", err)
 	}
 
 	router := gin.Default()
+	router.SetHTMLTemplate(templates)
+	// Client IPs key the rate limits and the sessions page, so forwarding
+	// headers are only believed from proxies listed in TRUSTED_PROXIES, a
+	// comma-separated list of IPs or CIDRs. By default none are trusted.
+	if err := router.SetTrustedProxies(trustedProxies(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		log.Fatalf("FATAL: Invalid TRUSTED_PROXIES: %v", err)
+	}
 
-	router.Use(sessions.Sessions("mysession", app.sessionStore))
+	router.Use(clientIPMiddleware)
+	router.Use(sessions.Sessions(sessionCookieName, app.sessionStore))
+	router.Use(app.csrfMiddleware)
+	router.Use(app.rateLimit(RateLimitGlobal))
 
 	profileService := profile.NewService(dbQueries)
 	profileService.RegisterHandlers(router)
 
 	router.GET("/", app.homeHandler)
 
+	careersRoutes := router.Group("/careers")
+	{
+		careersRoutes.GET("", app.getCareersHandler)
+		careersRoutes.GET("/feed.json", app.getCareersFeedHandler)
+		careersRoutes.GET("/feed.xml", app.getXMLJobFeedHandler)
+		careersRoutes.GET("/rss.xml", app.getRSSJobFeedHandler)
+		careersRoutes.GET("/atom.xml", app.getAtomJobFeedHandler)
+		careersRoutes.GET("/:slug", app.getCareerJobHandler)
+		careersRoutes.GET("/:slug/apply", app.getCareerApplyHandler)
+	}
+
 	authRoutes := router.Group("/auth")
 	{
+		authRoutes.GET("/login", app.getLoginHandler)
+		authRoutes.POST("/login", app.rateLimit(RateLimitAuth), app.postLoginHandler)
+		authRoutes.GET("/register", app.getRegisterHandler)
+		authRoutes.POST("/register", app.rateLimit(RateLimitAuth), app.postRegisterHandler)
+		authRoutes.GET("/verify", app.getVerifyEmailHandler)
+		authRoutes.GET("/forgot", app.getForgotPasswordHandler)
+		authRoutes.POST("/forgot", app.rateLimit(RateLimitAuth), app.postForgotPasswordHandler)
+		authRoutes.GET("/reset", app.getResetPasswordHandler)
+		authRoutes.POST("/reset", app.rateLimit(RateLimitAuth), app.postResetPasswordHandler)
+		authRoutes.GET("/link-account", app.getLinkAccountHandler)
+		authRoutes.POST("/link-account/cancel", app.postCancelLinkAccountHandler)
 		authRoutes.GET("/:provider", app.authProviderHandler)
 		authRoutes.GET("/:provider/callback", app.authCallbackHandler)
 		authRoutes.GET("/choose-role", app.chooseRoleGetHandler)
 		authRoutes.POST("/choose-role", app.chooseRolePostHandler)
+		authRoutes.GET("/2fa", app.getTwoFactorHandler)
+		authRoutes.POST("/2fa", app.rateLimit(RateLimitAuth), app.postTwoFactorHandler)
+		authRoutes.GET("/2fa/setup", app.getTwoFactorSetupHandler)
+		authRoutes.POST("/2fa/setup", app.rateLimit(RateLimitAuth), app.postTwoFactorSetupHandler)
 	}
 
 	router.GET("/logout", app.logoutHandler)
 
+	ssoRoutes := router.Group("/sso")
+	{
+		ssoRoutes.GET("", app.getSSOStartHandler)
+		ssoRoutes.POST("", app.rateLimit(RateLimitAuth), app.postSSOStartHandler)
+		ssoRoutes.GET("/:slug/login", app.getSSOLoginHandler)
+		ssoRoutes.POST("/:slug/acs", app.postSSOACSHandler)
+		ssoRoutes.GET("/:slug/metadata", app.getSSOMetadataHandler)
+	}
+
+	// JSON API for scripts and integrations, authenticated by API key instead
+	// of the session cookie.
+	apiRoutes := router.Group("/api/v1")
+	apiRoutes.Use(app.apiKeyMiddleware, app.rateLimit(RateLimitAPI))
+	{
+		apiRoutes.GET("/jobs", requireAPIScope(APIScopeJobsRead), app.apiListJobsHandler)
+		apiRoutes.GET("/jobs/:jobID", requireAPIScope(APIScopeJobsRead), app.apiGetJobHandler)
+		apiRoutes.GET("/jobs/:jobID/applications", requireAPIScope(APIScopeApplicationsRead), app.apiListJobApplicationsHandler)
+		apiRoutes.GET("/applications/:applicationID", requireAPIScope(APIScopeApplicationsRead), app.apiGetApplicationHandler)
+		apiRoutes.POST("/applications/:applicationID/status", requireAPIScope(APIScopeApplicationsWrite), app.apiPostApplicationStatusHandler)
+	}
+
 	protectedRoutes := router.Group("/profile")
 	protectedRoutes.Use(app.authMiddleware)
 	{
@@ -116,23 +262,104 @@ func main() {
 		authenticated.GET("/dashboard", app.dashboardRedirectHandler)
 		authenticated.GET("/recruiter/dashboard", app.recruiterDashboardHandler)
 		authenticated.GET("/applicant/dashboard", app.applicantDashboardHandler)
+		authenticated.GET("/events", app.eventStreamHandler)
+		authenticated.GET("/account/identities", app.getIdentitiesHandler)
+		authenticated.GET("/account/identities/link/:provider", app.getLinkIdentityHandler)
+		authenticated.POST("/account/identities/:identityID/unlink", app.postUnlinkIdentityHandler)
+		authenticated.GET("/account/sessions", app.getSessionsHandler)
+		authenticated.POST("/account/sessions/revoke-others", app.postRevokeOtherSessionsHandler)
+		authenticated.POST("/account/sessions/:sessionID/revoke", app.postRevokeSessionHandler)
+		authenticated.GET("/account/2fa", app.getAccountTwoFactorHandler)
+		authenticated.POST("/account/2fa/enable", app.postEnableTwoFactorHandler)
+		authenticated.POST("/account/2fa/recovery-codes", app.postRecoveryCodesHandler)
+		authenticated.POST("/account/2fa/disable", app.postDisableTwoFactorHandler)
+
+		adminRoutes := authenticated.Group("/admin")
+		{
+			adminRoutes.GET("/sso", app.getSSOAdminHandler)
+			adminRoutes.POST("/sso", app.postSSOOrganizationHandler)
+			adminRoutes.GET("/sso/:orgID", app.getSSOOrganizationHandler)
+			adminRoutes.POST("/sso/:orgID", app.postSSOConnectionHandler)
+			adminRoutes.POST("/sso/:orgID/verify-domain", app.postVerifyDomainHandler)
+			adminRoutes.GET("/users", app.getAdminUsersHandler)
+			adminRoutes.POST("/users/:userID/role", app.postAdminUserRoleHandler)
+			adminRoutes.POST("/users/:userID/suspend", app.postAdminUserSuspendHandler)
+			adminRoutes.POST("/users/:userID/reset-2fa", app.postAdminResetTwoFactorHandler)
+		}
 
 		applicantRoutes := authenticated.Group("/applicant")
 		{
 			applicantRoutes.GET("/skills", app.getManageSkillsHandler)
 			applicantRoutes.POST("/skills", app.postManageSkillsHandler)
 			applicantRoutes.GET("/resume", app.getResumeHandler)
-			applicantRoutes.POST("/resume", app.postResumeHandler)
+			applicantRoutes.POST("/resume", app.rateLimit(RateLimitResumeParse), app.postResumeHandler)
+			applicantRoutes.GET("/applications/:applicationID", app.getApplicantApplicationHandler)
+			applicantRoutes.POST("/applications/:applicationID/withdraw", app.postWithdrawApplicationHandler)
+			applicantRoutes.POST("/applications/:applicationID/resume", app.postRefreshApplicationResumeHandler)
+			applicantRoutes.POST("/applications/:applicationID/offer/accept", app.postAcceptOfferHandler)
+			applicantRoutes.POST("/applications/:applicationID/offer/decline", app.postDeclineOfferHandler)
 		}
 
 		recruiterRoutes := authenticated.Group("/recruiter")
 		{
 			recruiterRoutes.GET("/search", app.getSkillSearchFormHandler)
-			recruiterRoutes.GET("/search/results", app.getSkillSearchResultsHandler)
+			recruiterRoutes.GET("/import", app.getImportHandler)
+			recruiterRoutes.POST("/import/jobs", app.postImportJobsHandler)
+			recruiterRoutes.POST("/import/candidates", app.postImportCandidatesHandler)
+			recruiterRoutes.GET("/api-keys", app.getAPIKeysHandler)
+			recruiterRoutes.POST("/api-keys", app.postAPIKeyHandler)
+			recruiterRoutes.POST("/api-keys/:keyID/revoke", app.postRevokeAPIKeyHandler)
+			recruiterRoutes.GET("/webhooks", app.getWebhooksHandler)
+			recruiterRoutes.POST("/webhooks", app.postWebhookHandler)
+			recruiterRoutes.GET("/webhooks/:endpointID", app.getWebhookDeliveriesHandler)
+			recruiterRoutes.POST("/webhooks/:endpointID/active", app.postWebhookActiveHandler)
+			recruiterRoutes.POST("/webhooks/:endpointID/delete", app.deleteWebhookHandler)
+			recruiterRoutes.POST("/webhooks/:endpointID/deliveries/:deliveryID/redeliver", app.postWebhookRedeliverHandler)
+			recruiterRoutes.GET("/analytics", app.getAnalyticsHandler)
+			recruiterRoutes.GET("/analytics/export.csv", app.getAnalyticsExportHandler)
+			recruiterRoutes.GET("/search/results", app.rateLimit(RateLimitSearch), app.getSkillSearchResultsHandler)
+			recruiterRoutes.GET("/search/export", app.rateLimit(RateLimitSearch), app.getSkillSearchExportHandler)
 			recruiterRoutes.GET("/applicant/:applicantID", app.getApplicantProfileByRecruiterHandler)
+			recruiterRoutes.GET("/jobs/:jobID", app.getRecruiterJobHandler)
+			recruiterRoutes.POST("/jobs/:jobID/status", app.postJobStatusHandler)
+			recruiterRoutes.POST("/jobs/:jobID/schedule", app.postJobScheduleHandler)
+			recruiterRoutes.POST("/jobs/:jobID/duplicate", app.postDuplicateJobHandler)
+			recruiterRoutes.POST("/jobs/:jobID/template", app.postSaveJobTemplateHandler)
+			recruiterRoutes.GET("/templates", app.getJobTemplatesHandler)
+			recruiterRoutes.POST("/templates/:templateID/delete", app.deleteJobTemplateHandler)
 			recruiterRoutes.GET("/jobs/:jobID/applications", app.getJobApplicationsHandler)
+			recruiterRoutes.GET("/jobs/:jobID/applications/export", app.getJobApplicationsExportHandler)
+			recruiterRoutes.GET("/jobs/:jobID/applications/:applicationID", app.getRecruiterApplicationHandler)
 			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/reject", app.rejectApplicationHandler)
 			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/interview", app.requestInterviewHandler)
+			recruiterRoutes.GET("/jobs/:jobID/applications/:applicationID/offer", app.getOfferHandler)
+			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/offer", app.postOfferHandler)
+			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/offer/send", app.postSendOfferHandler)
+			recruiterRoutes.GET("/jobs/:jobID/questions", app.getScreeningQuestionsHandler)
+			recruiterRoutes.POST("/jobs/:jobID/questions", app.postScreeningQuestionHandler)
+			recruiterRoutes.POST("/jobs/:jobID/questions/:questionID/delete", app.deleteScreeningQuestionHandler)
+			recruiterRoutes.GET("/jobs/:jobID/pipeline", app.getPipelineHandler)
+			recruiterRoutes.POST("/jobs/:jobID/pipeline/move", app.postPipelineMoveHandler)
+			recruiterRoutes.POST("/jobs/:jobID/pipeline/bulk", app.postPipelineBulkHandler)
+			recruiterRoutes.GET("/jobs/:jobID/scorecard", app.getScorecardSetupHandler)
+			recruiterRoutes.POST("/jobs/:jobID/scorecard/criteria", app.postScorecardCriterionHandler)
+			recruiterRoutes.POST("/jobs/:jobID/scorecard/criteria/:criterionID/delete", app.deleteScorecardCriterionHandler)
+			recruiterRoutes.POST("/jobs/:jobID/interviewers", app.postJobInterviewerHandler)
+			recruiterRoutes.POST("/jobs/:jobID/interviewers/:userID/delete", app.deleteJobInterviewerHandler)
+			recruiterRoutes.GET("/jobs/:jobID/evaluations", app.getJobEvaluationsHandler)
+			recruiterRoutes.GET("/jobs/:jobID/applications/:applicationID/evaluation", app.getApplicationEvaluationHandler)
+			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/evaluation/notes", app.postApplicationNoteHandler)
+			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/evaluation/rating", app.postApplicationRatingHandler)
+			recruiterRoutes.POST("/jobs/:jobID/applications/:applicationID/evaluation/scorecard", app.postScorecardHandler)
+		}
+
+		applicationsGroup := authenticated.Group("/applications")
+		{
+			applicationsGroup.GET("/:applicationID/resume", app.getApplicationResumeHandler)
+			applicationsGroup.GET("/:applicationID/attachments/:attachmentID", app.getApplicationAttachmentHandler)
+			applicationsGroup.GET("/:applicationID/messages", app.getMessagesHandler)
+			applicationsGroup.POST("/:applicationID/messages", app.postMessageHandler)
+			applicationsGroup.GET("/:applicationID/messages/attachments/:attachmentID", app.getMessageAttachmentHandler)
 		}
 
 		jobsGroup := authenticated.Group("/jobs")
@@ -141,7 +368,7 @@ func main() {
 			jobsGroup.GET("/new", app.getJobPostingFormHandler)
 			jobsGroup.POST("", app.createJobPostingHandler)
 			jobsGroup.GET("/:jobID/apply", app.getApplyFormHandler)
-			jobsGroup.POST("/:jobID/apply", app.postApplyHandler)
+			jobsGroup.POST("/:jobID/apply", app.rateLimit(RateLimitApply), app.postApplyHandler)
 
 		}
 	}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const EventMessageCreated = "message.created"
+
+const maxAttachmentsPerMessage = 5
+
+// allowedAttachmentTypes lists the content types accepted for files uploaded
+// alongside messages and applications.
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"text/plain":      true,
+}
+
+// canAccessApplication reports whether the user may see the application's
+// thread: the applicant who submitted it or the recruiter owning the job posting.
+func canAccessApplication(application db.GetApplicationByIDRow, userID pgtype.UUID) bool {
+	if application.UserID.Valid && application.UserID.Bytes == userID.Bytes {
+		return true
+	}
+	return application.RecruiterID.Valid && application.RecruiterID.Bytes == userID.Bytes
+}
+
+// loadApplicationForParticipant parses the :applicationID param and loads the
+// application, writing an error response and returning false if the current
+// user is not one of its participants.
+func (app *App) loadApplicationForParticipant(c *gin.Context, userID pgtype.UUID) (db.GetApplicationByIDRow, bool) {
+	applicationIDStr := c.Param("applicationID")
+	appUUID, err := uuid.Parse(applicationIDStr)
+	if err != nil {
+		app.renderError(c, http.StatusBadRequest, "Invalid Application ID format")
+		return db.GetApplicationByIDRow{}, false
+	}
+
+	application, err := app.db.GetApplicationByID(c.Request.Context(), pgtype.UUID{Bytes: appUUID, Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.String(http.StatusNotFound, "Application not found.")
+		} else {
+			fmt.Printf("Messages: DB error fetching application %s: %v\n", applicationIDStr, err)
+			c.String(http.StatusInternalServerError, "Error fetching application.")
+		}
+		return db.GetApplicationByIDRow{}, false
+	}
+
+	if !canAccessApplication(application, userID) {
+		app.renderError(c, http.StatusForbidden, "Forbidden: You are not part of this application")
+		return db.GetApplicationByIDRow{}, false
+	}
+
+	return application, true
+}
+
+func (app *App) getMessagesHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, pgID)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+
+	err := app.db.MarkMessagesRead(c.Request.Context(), db.MarkMessagesReadParams{
+		ApplicationID: application.ID,
+		ReaderID:      pgID,
+	})
+	if err != nil {
+		fmt.Printf("Messages GET: Failed to mark messages read for application %s: %v\n", applicationIDStr, err)
+	}
+
+	messages, err := app.db.ListMessagesForApplication(c.Request.Context(), application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Messages GET: DB error listing messages for application %s: %v\n", applicationIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Error loading messages.")
+		return
+	}
+
+	attachments, err := app.db.ListMessageAttachmentsForApplication(c.Request.Context(), application.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Messages GET: DB error listing attachments for application %s: %v\n", applicationIDStr, err)
+	}
+	attachmentsByMessage := make(map[uuid.UUID][]attachmentLink)
+	for _, attachment := range attachments {
+		messageID := uuid.UUID(attachment.MessageID.Bytes)
+		attachmentsByMessage[messageID] = append(attachmentsByMessage[messageID], attachmentLink{
+			ID:       attachment.ID,
+			FileName: attachment.FileName,
+			SizeKB:   (attachment.SizeBytes + 1023) / 1024,
+		})
+	}
+
+	thread := make([]messageView, 0, len(messages))
+	for _, message := range messages {
+		thread = append(thread, messageView{
+			SenderName:  message.SenderName,
+			Body:        message.Body,
+			CreatedAt:   message.CreatedAt,
+			ReadAt:      message.ReadAt,
+			Own:         message.SenderID.Bytes == pgID.Bytes,
+			Attachments: attachmentsByMessage[uuid.UUID(message.ID.Bytes)],
+		})
+	}
+
+	backLink := "/applicant/dashboard"
+	if application.RecruiterID.Bytes == pgID.Bytes {
+		backLink = fmt.Sprintf("/recruiter/jobs/%s/applications", uuid.UUID(application.JobPostingID.Bytes).String())
+	}
+
+	app.render(c, http.StatusOK, "messages.html", "Messages: "+application.JobTitle, gin.H{
+		"Application": application,
+		"Messages":    thread,
+		"BackURL":     backLink,
+		"CSRF":        csrfToken(c),
+	})
+}
+
+// messageView is a message as shown in the application's thread. Own messages
+// show whether the other participant has read them.
+type messageView struct {
+	SenderName  string
+	Body        string
+	CreatedAt   pgtype.Timestamptz
+	ReadAt      pgtype.Timestamptz
+	Own         bool
+	Attachments []attachmentLink
+}
+
+func (app *App) postMessageHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, pgID)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+
+	body := strings.TrimSpace(c.PostForm("body"))
+	if body == "" {
+		app.renderError(c, http.StatusBadRequest, "Message cannot be empty.")
+		return
+	}
+
+	// Validate every file before storing anything so a bad upload doesn't leave
+	// a message with half of its attachments.
+	var files []uploadedFile
+	if form, err := c.MultipartForm(); err == nil {
+		fileHeaders := form.File["attachments"]
+		if len(fileHeaders) > maxAttachmentsPerMessage {
+			app.renderError(c, http.StatusBadRequest, fmt.Sprintf("Error: Too many attachments (maximum %d).", maxAttachmentsPerMessage))
+			return
+		}
+		for _, fileHeader := range fileHeaders {
+			file, errMsg := readUploadedFile(fileHeader)
+			if errMsg != "" {
+				app.renderError(c, http.StatusBadRequest, "Error: "+errMsg)
+				return
+			}
+			files = append(files, file)
+		}
+	}
+
+	message, err := app.db.CreateMessage(c.Request.Context(), db.CreateMessageParams{
+		ApplicationID: application.ID,
+		SenderID:      pgID,
+		Body:          body,
+	})
+	if err != nil {
+		fmt.Printf("Messages POST: DB error creating message for application %s: %v\n", applicationIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Failed to send message. Please try again.")
+		return
+	}
+
+	for _, file := range files {
+		_, err := app.db.CreateMessageAttachment(c.Request.Context(), db.CreateMessageAttachmentParams{
+			MessageID:   message.ID,
+			FileName:    file.Name,
+			ContentType: file.ContentType,
+			Data:        file.Data,
+		})
+		if err != nil {
+			fmt.Printf("Messages POST: DB error storing attachment %s for application %s: %v\n", file.Name, applicationIDStr, err)
+			app.renderError(c, http.StatusInternalServerError, "Message sent, but an attachment could not be saved.")
+			return
+		}
+	}
+
+	fmt.Printf("Message %s posted on application %s by user %s\n", uuid.UUID(message.ID.Bytes).String(), applicationIDStr, pgID.String())
+	app.publishApplicationEvent(c.Request.Context(), EventMessageCreated, application.ID)
+	app.notifyNewMessage(c.Request.Context(), application, pgID)
+
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("/applications/%s/messages", applicationIDStr))
+}
+
+// sendApplicationMessage posts a message without attachments to the
+// application's thread and notifies the other participant.
+func (app *App) sendApplicationMessage(ctx context.Context, application db.GetApplicationByIDRow, senderID pgtype.UUID, body string) error {
+	_, err := app.db.CreateMessage(ctx, db.CreateMessageParams{
+		ApplicationID: application.ID,
+		SenderID:      senderID,
+		Body:          body,
+	})
+	if err != nil {
+		return err
+	}
+	app.publishApplicationEvent(ctx, EventMessageCreated, application.ID)
+	app.notifyNewMessage(ctx, application, senderID)
+	return nil
+}
+
+// notifyNewMessage emails the other participant of the thread.
+func (app *App) notifyNewMessage(ctx context.Context, application db.GetApplicationByIDRow, senderID pgtype.UUID) {
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+
+	recipientEmail := application.ApplicantEmail
+	if application.UserID.Bytes == senderID.Bytes {
+		recruiter, err := app.db.GetUser(ctx, application.RecruiterID)
+		if err != nil {
+			fmt.Printf("Messages: Failed to load recruiter for application %s: %v\n", applicationIDStr, err)
+			return
+		}
+		recipientEmail = recruiter.Email
+	}
+
+	// The link comes from the configured site URL, never the request's Host.
+	link := "/applications/" + applicationIDStr + "/messages"
+	if app.site.BaseURL != "" {
+		link = strings.TrimRight(app.site.BaseURL, "/") + link
+	}
+	subject := "New message about your application: " + application.JobTitle
+	body := fmt.Sprintf("Hello,\n\nYou have a new message about the application for %s.\nPlease log in to read and reply: %s\n\nRegards,\nRecruitment Team", application.JobTitle, link)
+	if err := sendEmail(recipientEmail, subject, body); err != nil {
+		fmt.Printf("Messages: Skipping email notification for application %s: %v\n", applicationIDStr, err)
+	}
+}
+
+func (app *App) getMessageAttachmentHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+	pgID, ok := userID.(pgtype.UUID)
+	if !ok || !pgID.Valid {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		c.Abort()
+		return
+	}
+
+	application, ok := app.loadApplicationForParticipant(c, pgID)
+	if !ok {
+		return
+	}
+
+	attachmentUUID, err := uuid.Parse(c.Param("attachmentID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid attachment ID.")
+		return
+	}
+
+	attachment, err := app.db.GetMessageAttachment(c.Request.Context(), pgtype.UUID{Bytes: attachmentUUID, Valid: true})
+	if err != nil || attachment.ApplicationID.Bytes != application.ID.Bytes {
+		c.String(http.StatusNotFound, "Attachment not found.")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.FileName))
+	c.Data(http.StatusOK, attachment.ContentType, attachment.Data)
+}
+
+type uploadedFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// readUploadedFile checks an uploaded file against allowedAttachmentTypes and
+// maxUploadSize and reads it into memory. On failure it returns a message
+// suitable for showing to the user.
+func readUploadedFile(fileHeader *multipart.FileHeader) (uploadedFile, string) {
+	contentType := fileHeader.Header.Get("Content-Type")
+	if !allowedAttachmentTypes[contentType] {
+		fmt.Printf("Upload: Invalid file type for %s: %s\n", fileHeader.Filename, contentType)
+		return uploadedFile{}, fmt.Sprintf("Invalid file type for %s. Only PDF, PNG, JPEG and plain text are allowed.", fileHeader.Filename)
+	}
+	if fileHeader.Size > maxUploadSize {
+		fmt.Printf("Upload: File too large: %s (%d bytes)\n", fileHeader.Filename, fileHeader.Size)
+		return uploadedFile{}, fmt.Sprintf("%s exceeds the size limit (5MB).", fileHeader.Filename)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		fmt.Printf("Upload: Error opening uploaded file %s: %v\n", fileHeader.Filename, err)
+		return uploadedFile{}, "Error processing uploaded file."
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		fmt.Printf("Upload: Error reading uploaded file %s: %v\n", fileHeader.Filename, err)
+		return uploadedFile{}, "Error reading uploaded file content."
+	}
+
+	return uploadedFile{Name: fileHeader.Filename, ContentType: contentType, Data: data}, ""
+}
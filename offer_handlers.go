@@ -0,0 +1,421 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OfferStatusDraft    = "draft"
+	OfferStatusSent     = "sent"
+	OfferStatusAccepted = "accepted"
+	OfferStatusDeclined = "declined"
+	OfferStatusExpired  = "expired"
+
+	offerDateLayout = "2006-01-02"
+)
+
+// loadOffer returns the application's offer, marking a sent offer as expired
+// once its expiry has passed. found is false when no offer has been drafted.
+func (app *App) loadOffer(ctx context.Context, applicationID pgtype.UUID) (db.Offer, bool, error) {
+	offer, err := app.db.GetOfferByApplicationID(ctx, applicationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Offer{}, false, nil
+		}
+		return db.Offer{}, false, err
+	}
+
+	if offer.Status == OfferStatusSent && offer.ExpiresAt.Valid && time.Now().After(offer.ExpiresAt.Time) {
+		if err := app.db.UpdateOfferStatus(ctx, db.UpdateOfferStatusParams{ID: offer.ID, Status: OfferStatusExpired}); err != nil {
+			fmt.Printf("Offers: Failed to expire offer %s: %v\n", offer.ID.String(), err)
+		}
+		offer.Status = OfferStatusExpired
+	}
+	return offer, true, nil
+}
+
+// validateOfferSalary checks the salary falls inside the posting's advertised
+// range, returning a message for the recruiter when it does not.
+func validateOfferSalary(job db.GetJobPostingByIDRow, salary decimal.Decimal) string {
+	if !salary.IsPositive() {
+		return "Salary must be greater than zero."
+	}
+	if salaryMin, ok := numericToDecimal(job.SalaryMin); ok && salary.LessThan(salaryMin) {
+		return fmt.Sprintf("Salary is below the posting's minimum of %s.", salaryMin.StringFixed(2))
+	}
+	if salaryMax, ok := numericToDecimal(job.SalaryMax); ok && salary.GreaterThan(salaryMax) {
+		return fmt.Sprintf("Salary is above the posting's maximum of %s.", salaryMax.StringFixed(2))
+	}
+	return ""
+}
+
+// offerSummary is what the offer_summary template shows about an offer.
+type offerSummary struct {
+	Status    string
+	Salary    string
+	StartDate string
+	ExpiresAt string
+	Notes     string
+}
+
+func newOfferSummary(offer db.Offer) offerSummary {
+	summary := offerSummary{Status: offer.Status, Notes: offer.Notes.String}
+	if salary, ok := numericToDecimal(offer.Salary); ok {
+		summary.Salary = salary.StringFixed(2)
+	}
+	if offer.StartDate.Valid {
+		summary.StartDate = offer.StartDate.Time.Format(offerDateLayout)
+	}
+	if offer.ExpiresAt.Valid {
+		summary.ExpiresAt = offer.ExpiresAt.Time.Format(time.RFC822)
+	}
+	return summary
+}
+
+func offerURL(jobID, applicationID pgtype.UUID) string {
+	return fmt.Sprintf("/recruiter/jobs/%s/applications/%s/offer", uuid.UUID(jobID.Bytes).String(), uuid.UUID(applicationID.Bytes).String())
+}
+
+// canDraftOffer reports whether the recruiter may create or revise the offer:
+// after an interview has been requested, and only while the offer has not
+// been sent or has expired unanswered.
+func canDraftOffer(application db.GetApplicationByIDRow, offer db.Offer, found bool) bool {
+	if !found {
+		return application.Status == ApplicationStatusAccepted
+	}
+	switch offer.Status {
+	case OfferStatusDraft:
+		return application.Status == ApplicationStatusAccepted
+	case OfferStatusExpired:
+		return application.Status == ApplicationStatusOffered
+	}
+	return false
+}
+
+func (app *App) getOfferHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, application, ok := app.loadJobApplication(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+	pageURL := offerURL(job.ID, application.ID)
+
+	offer, found, err := app.loadOffer(c.Request.Context(), application.ID)
+	if err != nil {
+		fmt.Printf("Offer GET: DB error fetching offer for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading offer.")
+		return
+	}
+
+	rangeStr := "No salary range was set on the posting."
+	salaryMin, minOK := numericToDecimal(job.SalaryMin)
+	salaryMax, maxOK := numericToDecimal(job.SalaryMax)
+	switch {
+	case minOK && maxOK:
+		rangeStr = fmt.Sprintf("Posting salary range: %s - %s", salaryMin.StringFixed(2), salaryMax.StringFixed(2))
+	case minOK:
+		rangeStr = fmt.Sprintf("Posting minimum salary: %s", salaryMin.StringFixed(2))
+	case maxOK:
+		rangeStr = fmt.Sprintf("Posting maximum salary: %s", salaryMax.StringFixed(2))
+	}
+
+	var summary offerSummary
+	var draft offerDraft
+	if found {
+		summary = newOfferSummary(offer)
+		draft = offerDraft{Salary: summary.Salary, StartDate: summary.StartDate, Notes: offer.Notes.String}
+		if offer.ExpiresAt.Valid && offer.Status == OfferStatusDraft {
+			draft.ExpiresAt = offer.ExpiresAt.Time.Local().Format(dateTimeLocalLayout)
+		}
+	}
+
+	app.render(c, http.StatusOK, "offer.html", "Offer: "+application.ApplicantName, gin.H{
+		"Job":         job,
+		"Application": application,
+		"Status":      applicationStatusLabels[application.Status],
+		"URL":         pageURL,
+		"Found":       found,
+		"Offer":       summary,
+		"CanDraft":    canDraftOffer(application, offer, found),
+		"CanSend":     found && offer.Status == OfferStatusDraft,
+		"SalaryRange": rangeStr,
+		"Draft":       draft,
+		"Error":       c.Query("error"),
+		"CSRF":        csrfToken(c),
+	})
+}
+
+// offerDraft prefills the recruiter's offer form.
+type offerDraft struct {
+	Salary    string
+	StartDate string
+	ExpiresAt string
+	Notes     string
+}
+
+func (app *App) postOfferHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, application, ok := app.loadJobApplication(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+	pageURL := offerURL(job.ID, application.ID)
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, pageURL+"?error="+url.QueryEscape(message))
+	}
+
+	offer, found, err := app.loadOffer(c.Request.Context(), application.ID)
+	if err != nil {
+		fmt.Printf("Offer POST: DB error fetching offer for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading offer.")
+		return
+	}
+	if !canDraftOffer(application, offer, found) {
+		fail("This offer can no longer be changed.")
+		return
+	}
+
+	salary, err := decimal.NewFromString(strings.TrimSpace(c.PostForm("salary")))
+	if err != nil {
+		fail("Invalid salary format.")
+		return
+	}
+	if message := validateOfferSalary(job, salary); message != "" {
+		fail(message)
+		return
+	}
+	var salaryPg pgtype.Numeric
+	if err := salaryPg.Scan(salary.StringFixed(2)); err != nil {
+		fail("Invalid salary format.")
+		return
+	}
+
+	startDate, err := time.Parse(offerDateLayout, c.PostForm("start_date"))
+	if err != nil {
+		fail("Invalid start date.")
+		return
+	}
+	expiresAt, err := time.ParseInLocation(dateTimeLocalLayout, c.PostForm("expires_at"), time.Local)
+	if err != nil {
+		fail("Invalid expiry.")
+		return
+	}
+	if !expiresAt.After(time.Now()) {
+		fail("The offer must expire in the future.")
+		return
+	}
+	expiryDate := time.Date(expiresAt.Year(), expiresAt.Month(), expiresAt.Day(), 0, 0, 0, 0, time.UTC)
+	if startDate.Before(expiryDate) {
+		fail("The start date must not be before the offer expires.")
+		return
+	}
+
+	notes := strings.TrimSpace(c.PostForm("notes"))
+	err = app.db.UpsertOfferDraft(c.Request.Context(), db.UpsertOfferDraftParams{
+		ApplicationID: application.ID,
+		CreatedBy:     recruiter.ID,
+		Salary:        salaryPg,
+		StartDate:     pgtype.Date{Time: startDate, Valid: true},
+		ExpiresAt:     pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		Notes:         pgtype.Text{String: notes, Valid: notes != ""},
+	})
+	if err != nil {
+		fmt.Printf("Offer POST: DB error saving offer for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to save offer.")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, pageURL)
+}
+
+func (app *App) postSendOfferHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, application, ok := app.loadJobApplication(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+	pageURL := offerURL(job.ID, application.ID)
+
+	offer, found, err := app.loadOffer(ctx, application.ID)
+	if err != nil {
+		fmt.Printf("Send Offer POST: DB error fetching offer for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading offer.")
+		return
+	}
+	if !found || offer.Status != OfferStatusDraft {
+		c.Redirect(http.StatusSeeOther, pageURL+"?error="+url.QueryEscape("Only a drafted offer can be sent."))
+		return
+	}
+	if !offer.ExpiresAt.Time.After(time.Now()) {
+		c.Redirect(http.StatusSeeOther, pageURL+"?error="+url.QueryEscape("The offer's expiry has passed. Update it before sending."))
+		return
+	}
+
+	if application.Status != ApplicationStatusOffered {
+		err = app.changeApplicationStatus(ctx, application, ApplicationStatusOffered, recruiter.ID, "Offer sent")
+		if errors.Is(err, errInvalidStatusTransition) {
+			c.Redirect(http.StatusSeeOther, pageURL+"?error="+url.QueryEscape("The application can no longer receive an offer."))
+			return
+		} else if err != nil {
+			fmt.Printf("Send Offer POST: DB error updating status for application %s: %v\n", applicationIDStr, err)
+			c.String(http.StatusInternalServerError, "Failed to send offer.")
+			return
+		}
+	}
+
+	if err := app.db.MarkOfferSent(ctx, offer.ID); err != nil {
+		fmt.Printf("Send Offer POST: DB error marking offer sent for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to send offer.")
+		return
+	}
+
+	body := fmt.Sprintf("Congratulations! You have received an offer for %s. Please review it and respond by %s: /applicant/applications/%s",
+		application.JobTitle, offer.ExpiresAt.Time.Format(time.RFC822), applicationIDStr)
+	if err := app.sendApplicationMessage(ctx, application, recruiter.ID, body); err != nil {
+		fmt.Printf("Send Offer POST: Failed to notify applicant for application %s: %v\n", applicationIDStr, err)
+	}
+
+	fmt.Printf("Offer for application %s sent by recruiter %s\n", applicationIDStr, recruiter.ID.String())
+	c.Redirect(http.StatusSeeOther, pageURL)
+}
+
+// applicantOffer is the offer shown on the applicant's application page,
+// with accept and decline buttons while it is open.
+type applicantOffer struct {
+	Error      bool
+	Found      bool
+	Offer      offerSummary
+	CanRespond bool
+}
+
+func (app *App) applicantOffer(ctx context.Context, application db.GetApplicationByIDRow) applicantOffer {
+	offer, found, err := app.loadOffer(ctx, application.ID)
+	if err != nil {
+		fmt.Printf("Application Detail: DB error fetching offer for application %s: %v\n", application.ID.String(), err)
+		return applicantOffer{Error: true}
+	}
+	if !found || offer.Status == OfferStatusDraft {
+		return applicantOffer{}
+	}
+	return applicantOffer{
+		Found:      true,
+		Offer:      newOfferSummary(offer),
+		CanRespond: offer.Status == OfferStatusSent && application.Status == ApplicationStatusOffered,
+	}
+}
+
+func (app *App) postAcceptOfferHandler(c *gin.Context) {
+	app.respondToOffer(c, true)
+}
+
+func (app *App) postDeclineOfferHandler(c *gin.Context) {
+	app.respondToOffer(c, false)
+}
+
+func (app *App) respondToOffer(c *gin.Context, accept bool) {
+	application, pgID, ok := app.loadOwnApplication(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	applicationIDStr := uuid.UUID(application.ID.Bytes).String()
+	detailURL := "/applicant/applications/" + applicationIDStr
+
+	offer, found, err := app.loadOffer(ctx, application.ID)
+	if err != nil {
+		fmt.Printf("Offer Response POST: DB error fetching offer for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Error loading offer.")
+		return
+	}
+	if !found || offer.Status != OfferStatusSent || application.Status != ApplicationStatusOffered {
+		app.renderErrorBack(c, http.StatusBadRequest, "This offer is no longer open.", detailURL, "Back")
+		return
+	}
+
+	offerStatus, applicationStatus, verb := OfferStatusDeclined, ApplicationStatusDeclined, "declined"
+	if accept {
+		offerStatus, applicationStatus, verb = OfferStatusAccepted, ApplicationStatusHired, "accepted"
+	}
+
+	err = app.changeApplicationStatus(ctx, application, applicationStatus, pgID, "Offer "+verb)
+	if errors.Is(err, errInvalidStatusTransition) {
+		app.renderErrorBack(c, http.StatusBadRequest, "This offer is no longer open.", detailURL, "Back")
+		return
+	} else if err != nil {
+		fmt.Printf("Offer Response POST: DB error updating status for application %s: %v\n", applicationIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to record your response.")
+		return
+	}
+	if err := app.db.UpdateOfferStatus(ctx, db.UpdateOfferStatusParams{ID: offer.ID, Status: offerStatus}); err != nil {
+		fmt.Printf("Offer Response POST: DB error updating offer for application %s: %v\n", applicationIDStr, err)
+	}
+
+	body := fmt.Sprintf("%s has %s the offer for %s.", application.ApplicantName, verb, application.JobTitle)
+	if err := app.sendApplicationMessage(ctx, application, pgID, body); err != nil {
+		fmt.Printf("Offer Response POST: Failed to notify recruiter for application %s: %v\n", applicationIDStr, err)
+	}
+
+	if accept {
+		app.closeJobIfFilled(ctx, application.JobPostingID)
+	}
+
+	fmt.Printf("Offer for application %s %s by applicant %s\n", applicationIDStr, verb, pgID.String())
+	c.Redirect(http.StatusSeeOther, detailURL)
+}
+
+// closeJobIfFilled closes a posting that has auto-close enabled once as many
+// applicants have been hired as it has positions.
+func (app *App) closeJobIfFilled(ctx context.Context, jobID pgtype.UUID) {
+	job, err := app.db.GetJobPostingByID(ctx, jobID)
+	if err != nil {
+		fmt.Printf("Offers: Failed to load job %s for auto-close: %v\n", jobID.String(), err)
+		return
+	}
+	if !job.AutoClose || !canTransitionJob(job.Status, JobStatusClosed) {
+		return
+	}
+
+	hired, err := app.db.CountApplicationsByStatus(ctx, db.CountApplicationsByStatusParams{
+		JobPostingID: jobID,
+		Status:       ApplicationStatusHired,
+	})
+	if err != nil {
+		fmt.Printf("Offers: Failed to count hires for job %s: %v\n", jobID.String(), err)
+		return
+	}
+	if hired < int64(job.Headcount) {
+		return
+	}
+
+	if err := app.db.UpdateJobPostingStatus(ctx, db.UpdateJobPostingStatusParams{ID: jobID, Status: JobStatusClosed}); err != nil {
+		fmt.Printf("Offers: Failed to close filled job %s: %v\n", jobID.String(), err)
+		return
+	}
+	fmt.Printf("Job %s closed automatically after filling %d position(s)\n", jobID.String(), job.Headcount)
+}
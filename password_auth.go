@@ -0,0 +1,308 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+
+	verifyEmailTokenTTL   = 48 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything past 72 bytes
+)
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// issueUserToken creates a one-time token for the user. Only its hash is
+// stored, so a leaked database cannot be used to reset passwords.
+func (app *App) issueUserToken(ctx context.Context, userID pgtype.UUID, purpose string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	err := app.db.CreateUserToken(ctx, db.CreateUserTokenParams{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+	})
+	return token, err
+}
+
+func (app *App) sendVerificationEmail(c *gin.Context, userID pgtype.UUID, email string) error {
+	token, err := app.issueUserToken(c.Request.Context(), userID, TokenPurposeVerifyEmail, verifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+	link := app.absoluteURL(c, "/auth/verify?token="+token)
+	body := fmt.Sprintf("Confirm your email address for %s by opening this link:\n\n%s\n\nThe link expires in 48 hours. If you did not create an account, ignore this email.",
+		app.site.CompanyName, link)
+	return sendEmail(email, "Confirm your email address", body)
+}
+
+// validatePassword returns a message describing what is wrong with the
+// password, or "" if it is acceptable.
+func validatePassword(password string) string {
+	if len(password) < minPasswordLength {
+		return fmt.Sprintf("Password must be at least %d characters.", minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Sprintf("Password must be at most %d characters.", maxPasswordLength)
+	}
+	return ""
+}
+
+// authPage renders one of the small signed-out forms. Failed submissions
+// redirect back with ?error=, which the form shows above its fields.
+func (app *App) authPage(c *gin.Context, name, title string, data gin.H) {
+	data["Error"] = c.Query("error")
+	app.render(c, http.StatusOK, name, title, data)
+}
+
+func (app *App) getLoginHandler(c *gin.Context) {
+	app.authPage(c, "login.html", "Login", gin.H{
+		"Providers": app.authProviders,
+		"SSO":       app.saml != nil,
+		"Email":     c.Query("email"),
+		"CSRF":      csrfToken(c),
+	})
+}
+
+func (app *App) postLoginHandler(c *gin.Context) {
+	email := strings.TrimSpace(c.PostForm("email"))
+	password := c.PostForm("password")
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, "/auth/login?email="+url.QueryEscape(email)+"&error="+url.QueryEscape(message))
+	}
+
+	login, err := app.db.GetPasswordLogin(c.Request.Context(), email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Login POST: DB error looking up %s: %v\n", email, err)
+		c.String(http.StatusInternalServerError, "Login failed. Please try again.")
+		return
+	}
+	// Accounts without a password (provider logins) fail the same way as a
+	// wrong password, so the form does not reveal how someone signs in.
+	if err != nil || !login.PasswordHash.Valid ||
+		bcrypt.CompareHashAndPassword([]byte(login.PasswordHash.String), []byte(password)) != nil {
+		fail("Incorrect email or password.")
+		return
+	}
+
+	if !login.EmailVerifiedAt.Valid {
+		if err := app.sendVerificationEmail(c, login.ID, login.Email); err != nil {
+			fmt.Printf("Login POST: Failed to send verification email to %s: %v\n", login.Email, err)
+		}
+		fail("Please confirm your email address first. We have sent you a new confirmation link.")
+		return
+	}
+
+	fmt.Printf("User %s logged in with a password.\n", login.ID.String())
+	app.completeLogin(c, login.ID)
+}
+
+func (app *App) getRegisterHandler(c *gin.Context) {
+	app.authPage(c, "register.html", "Create an Account", gin.H{
+		"Name":        c.Query("name"),
+		"Email":       c.Query("email"),
+		"MinPassword": minPasswordLength,
+		"MaxPassword": maxPasswordLength,
+		"CSRF":        csrfToken(c),
+	})
+}
+
+func (app *App) postRegisterHandler(c *gin.Context) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	email := strings.TrimSpace(c.PostForm("email"))
+	password := c.PostForm("password")
+	role := c.PostForm("role")
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, "/auth/register?name="+url.QueryEscape(name)+"&email="+url.QueryEscape(email)+"&error="+url.QueryEscape(message))
+	}
+
+	if name == "" {
+		fail("Please enter your name.")
+		return
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		fail("Please enter a valid email address.")
+		return
+	}
+	if message := validatePassword(password); message != "" {
+		fail(message)
+		return
+	}
+	if role != RoleApplicant && role != RoleRecruiter {
+		fail("Please choose a role.")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Printf("Register POST: Failed to hash password: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to create account.")
+		return
+	}
+	user, err := app.db.CreatePasswordUser(c.Request.Context(), db.CreatePasswordUserParams{
+		Email:        email,
+		Name:         name,
+		Role:         role,
+		PasswordHash: pgtype.Text{String: string(hash), Valid: true},
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			if strings.Contains(pgErr.ConstraintName, "email") {
+				fail("An account with this email already exists. Login, or reset your password if you have not set one.")
+				return
+			}
+			if strings.Contains(pgErr.ConstraintName, "name") {
+				fail("That name is already taken. Please add something to make it unique.")
+				return
+			}
+		}
+		fmt.Printf("Register POST: Failed to create user %s: %v\n", email, err)
+		c.String(http.StatusInternalServerError, "Failed to create account.")
+		return
+	}
+
+	fmt.Printf("Registered %s user %s (ID: %s) with a password.\n", role, user.Email, user.ID.String())
+	if err := app.sendVerificationEmail(c, user.ID, user.Email); err != nil {
+		fmt.Printf("Register POST: Failed to send verification email to %s: %v\n", user.Email, err)
+	}
+	app.authPage(c, "check_email.html", "Check Your Email", gin.H{"Email": user.Email})
+}
+
+func (app *App) getVerifyEmailHandler(c *gin.Context) {
+	userID, err := app.db.ConsumeUserToken(c.Request.Context(), db.ConsumeUserTokenParams{
+		TokenHash: hashToken(c.Query("token")),
+		Purpose:   TokenPurposeVerifyEmail,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Redirect(http.StatusSeeOther, "/auth/login?error="+url.QueryEscape("That confirmation link is invalid or has expired. Login to get a new one."))
+		return
+	} else if err != nil {
+		fmt.Printf("Verify Email: DB error consuming token: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to confirm email address.")
+		return
+	}
+
+	if err := app.db.MarkUserEmailVerified(c.Request.Context(), userID); err != nil {
+		fmt.Printf("Verify Email: Failed to mark user %s verified: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to confirm email address.")
+		return
+	}
+	fmt.Printf("User %s confirmed their email address.\n", userID.String())
+	app.completeLogin(c, userID)
+}
+
+func (app *App) getForgotPasswordHandler(c *gin.Context) {
+	app.authPage(c, "forgot_password.html", "Reset Your Password", gin.H{"CSRF": csrfToken(c)})
+}
+
+func (app *App) postForgotPasswordHandler(c *gin.Context) {
+	email := strings.TrimSpace(c.PostForm("email"))
+	ctx := c.Request.Context()
+
+	// The response is the same whether or not the account exists.
+	user, err := app.db.GetUserByEmail(ctx, email)
+	if err == nil {
+		// Only the newest link works.
+		if err := app.db.DeleteUserTokens(ctx, db.DeleteUserTokensParams{UserID: user.ID, Purpose: TokenPurposeResetPassword}); err != nil {
+			fmt.Printf("Forgot Password POST: Failed to clear old tokens for %s: %v\n", user.ID.String(), err)
+		}
+		token, err := app.issueUserToken(ctx, user.ID, TokenPurposeResetPassword, resetPasswordTokenTTL)
+		if err != nil {
+			fmt.Printf("Forgot Password POST: Failed to create token for %s: %v\n", user.ID.String(), err)
+		} else {
+			body := fmt.Sprintf("Someone asked to reset the password for your %s account. To choose a new password, open this link:\n\n%s\n\nThe link expires in 1 hour. If you did not ask for this, ignore this email.",
+				app.site.CompanyName, app.absoluteURL(c, "/auth/reset?token="+token))
+			if err := sendEmail(user.Email, "Reset your password", body); err != nil {
+				fmt.Printf("Forgot Password POST: Failed to send email to %s: %v\n", user.Email, err)
+			}
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Forgot Password POST: DB error looking up %s: %v\n", email, err)
+	}
+
+	app.authPage(c, "check_email.html", "Check Your Email", gin.H{})
+}
+
+func (app *App) getResetPasswordHandler(c *gin.Context) {
+	app.authPage(c, "reset_password.html", "Choose a New Password", gin.H{
+		"Token":       c.Query("token"),
+		"MinPassword": minPasswordLength,
+		"MaxPassword": maxPasswordLength,
+		"CSRF":        csrfToken(c),
+	})
+}
+
+func (app *App) postResetPasswordHandler(c *gin.Context) {
+	token := c.PostForm("token")
+	password := c.PostForm("password")
+	if message := validatePassword(password); message != "" {
+		c.Redirect(http.StatusSeeOther, "/auth/reset?token="+url.QueryEscape(token)+"&error="+url.QueryEscape(message))
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Printf("Reset Password POST: Failed to hash password: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to reset password.")
+		return
+	}
+
+	userID, err := app.db.ConsumeUserToken(c.Request.Context(), db.ConsumeUserTokenParams{
+		TokenHash: hashToken(token),
+		Purpose:   TokenPurposeResetPassword,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Redirect(http.StatusSeeOther, "/auth/forgot?error="+url.QueryEscape("That reset link is invalid or has expired. Please request a new one."))
+		return
+	} else if err != nil {
+		fmt.Printf("Reset Password POST: DB error consuming token: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to reset password.")
+		return
+	}
+
+	err = app.db.SetUserPassword(c.Request.Context(), db.SetUserPasswordParams{
+		ID:           userID,
+		PasswordHash: pgtype.Text{String: string(hash), Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("Reset Password POST: Failed to update password for %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to reset password.")
+		return
+	}
+	// Whoever knew the old password may still be signed in somewhere.
+	if err := app.db.DeleteAllUserSessions(c.Request.Context(), userID); err != nil {
+		fmt.Printf("Reset Password POST: Failed to sign out sessions of %s: %v\n", userID.String(), err)
+	}
+	fmt.Printf("User %s reset their password.\n", userID.String())
+	app.completeLogin(c, userID)
+}
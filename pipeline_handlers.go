@@ -0,0 +1,226 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// moveApplicationToStage changes an application's stage on behalf of a
+// recruiter. Moving it to the interview stage also opens an interview request.
+func (app *App) moveApplicationToStage(ctx context.Context, application db.GetApplicationByIDRow, stage string, recruiterID pgtype.UUID) error {
+	if stage == ApplicationStatusWithdrawn {
+		return fmt.Errorf("%w: only applicants can withdraw", errInvalidStatusTransition)
+	}
+	if offerStatuses[stage] {
+		return fmt.Errorf("%w: %s is set through the offer workflow", errInvalidStatusTransition, stage)
+	}
+	if err := app.changeApplicationStatus(ctx, application, stage, recruiterID, ""); err != nil {
+		return err
+	}
+
+	if stage == ApplicationStatusAccepted {
+		err := app.db.UpsertInterview(ctx, db.UpsertInterviewParams{
+			ApplicationID:    application.ID,
+			RequestingUserID: recruiterID,
+		})
+		if err != nil {
+			fmt.Printf("Pipeline: Failed to open interview for application %s: %v\n", application.ID.String(), err)
+		}
+		app.publishApplicationEvent(ctx, EventInterviewUpdated, application.ID)
+	}
+	return nil
+}
+
+// renderStageMessage fills in the placeholders of a bulk action message.
+func renderStageMessage(message string, application db.GetApplicationByIDRow, stage string) string {
+	return strings.NewReplacer(
+		"{{name}}", application.ApplicantName,
+		"{{job}}", application.JobTitle,
+		"{{stage}}", applicationStatusLabels[stage],
+	).Replace(message)
+}
+
+// pipelineColumn is one stage of the pipeline board.
+type pipelineColumn struct {
+	Stage   string
+	Label   string
+	Cards   []db.GetApplicationsForJobPostingRow
+	Movable bool // Whether cards in this stage can be moved on
+}
+
+type pipelineStageOption struct {
+	Value string
+	Label string
+}
+
+func (app *App) getPipelineHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+
+	applications, err := app.db.GetApplicationsForJobPosting(c.Request.Context(), job.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Pipeline GET: DB error fetching applications for job %s: %v\n", jobIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Error loading applications.")
+		return
+	}
+
+	byStage := make(map[string][]db.GetApplicationsForJobPostingRow)
+	for _, application := range applications {
+		byStage[application.ApplicationStatus] = append(byStage[application.ApplicationStatus], application)
+	}
+
+	var moveStages []pipelineStageOption
+	for _, stage := range pipelineStages {
+		if stage == ApplicationStatusWithdrawn || offerStatuses[stage] {
+			continue
+		}
+		moveStages = append(moveStages, pipelineStageOption{Value: stage, Label: applicationStatusLabels[stage]})
+	}
+
+	columns := make([]pipelineColumn, 0, len(pipelineStages))
+	for _, stage := range pipelineStages {
+		columns = append(columns, pipelineColumn{
+			Stage:   stage,
+			Label:   applicationStatusLabels[stage],
+			Cards:   byStage[stage],
+			Movable: len(applicationTransitions[stage]) > 0,
+		})
+	}
+
+	app.render(c, http.StatusOK, "pipeline.html", "Pipeline: "+job.Title, gin.H{
+		"Job":        job,
+		"Columns":    columns,
+		"MoveStages": moveStages,
+		"Moved":      c.Query("moved"),
+		"Skipped":    c.DefaultQuery("skipped", "0"),
+		"Error":      c.Query("error"),
+		"CSRF":       csrfToken(c),
+	})
+}
+
+func (app *App) postPipelineMoveHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+	boardURL := fmt.Sprintf("/recruiter/jobs/%s/pipeline", jobIDStr)
+
+	appUUID, err := uuid.Parse(c.PostForm("application_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid application ID.")
+		return
+	}
+	stage := c.PostForm("stage")
+	if _, known := applicationStatusLabels[stage]; !known {
+		c.Redirect(http.StatusSeeOther, boardURL)
+		return
+	}
+
+	application, err := app.db.GetApplicationByID(c.Request.Context(), pgtype.UUID{Bytes: appUUID, Valid: true})
+	if err != nil || application.JobPostingID.Bytes != job.ID.Bytes {
+		c.String(http.StatusNotFound, "Application not found.")
+		return
+	}
+	if application.Status == stage {
+		c.Redirect(http.StatusSeeOther, boardURL)
+		return
+	}
+
+	err = app.moveApplicationToStage(c.Request.Context(), application, stage, recruiter.ID)
+	if errors.Is(err, errInvalidStatusTransition) {
+		message := fmt.Sprintf("Cannot move %s from %s to %s.", application.ApplicantName, applicationStatusLabels[application.Status], applicationStatusLabels[stage])
+		c.Redirect(http.StatusSeeOther, boardURL+"?error="+url.QueryEscape(message))
+		return
+	} else if err != nil {
+		fmt.Printf("Pipeline Move POST: DB error moving application %s: %v\n", application.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to update application status.")
+		return
+	}
+
+	fmt.Printf("Application %s moved to '%s' by recruiter %s\n", application.ID.String(), stage, recruiter.ID.String())
+	c.Redirect(http.StatusSeeOther, boardURL)
+}
+
+func (app *App) postPipelineBulkHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+	boardURL := fmt.Sprintf("/recruiter/jobs/%s/pipeline", jobIDStr)
+
+	stage := ApplicationStatusRejected
+	if c.PostForm("action") == "move" {
+		stage = c.PostForm("stage")
+	}
+	if _, known := applicationStatusLabels[stage]; !known {
+		c.Redirect(http.StatusSeeOther, boardURL+"?error="+url.QueryEscape("Choose a stage to move the selected applications to."))
+		return
+	}
+
+	applicationIDs := c.PostFormArray("application_ids")
+	if len(applicationIDs) == 0 {
+		c.Redirect(http.StatusSeeOther, boardURL+"?error="+url.QueryEscape("Select at least one application."))
+		return
+	}
+	messageTemplate := strings.TrimSpace(c.PostForm("message"))
+
+	moved, skipped := 0, 0
+	for _, idStr := range applicationIDs {
+		appUUID, err := uuid.Parse(idStr)
+		if err != nil {
+			skipped++
+			continue
+		}
+		application, err := app.db.GetApplicationByID(c.Request.Context(), pgtype.UUID{Bytes: appUUID, Valid: true})
+		if err != nil || application.JobPostingID.Bytes != job.ID.Bytes {
+			skipped++
+			continue
+		}
+
+		if err := app.moveApplicationToStage(c.Request.Context(), application, stage, recruiter.ID); err != nil {
+			if !errors.Is(err, errInvalidStatusTransition) {
+				fmt.Printf("Pipeline Bulk POST: DB error moving application %s: %v\n", idStr, err)
+			}
+			skipped++
+			continue
+		}
+		moved++
+
+		if messageTemplate != "" {
+			body := renderStageMessage(messageTemplate, application, stage)
+			if err := app.sendApplicationMessage(c.Request.Context(), application, recruiter.ID, body); err != nil {
+				fmt.Printf("Pipeline Bulk POST: Failed to message applicant for application %s: %v\n", idStr, err)
+			}
+		}
+	}
+
+	fmt.Printf("Pipeline Bulk POST: Recruiter %s moved %d application(s) to '%s' (%d skipped)\n", recruiter.ID.String(), moved, stage, skipped)
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s?moved=%d&skipped=%d", boardURL, moved, skipped))
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Route classes share a quota across the routes they are attached to.
+const (
+	RateLimitGlobal      = "global"       // Every request, per client IP
+	RateLimitAuth        = "auth"         // Login, registration and password reset forms, per client IP
+	RateLimitResumeParse = "resume_parse" // Resume uploads, which are parsed by a paid LLM API
+	RateLimitApply       = "apply"
+	RateLimitSearch      = "search"
+	RateLimitAPI         = "api"
+
+	rateLimitCleanupInterval = 10 * time.Minute
+)
+
+// defaultRateLimits are the quotas used unless RATE_LIMIT_<CLASS> overrides
+// them, e.g. RATE_LIMIT_RESUME_PARSE="5/1h,20/24h". "off" disables a class.
+var defaultRateLimits = map[string]string{
+	RateLimitGlobal:      "600/1m",
+	RateLimitAuth:        "10/1m,50/1h",
+	RateLimitResumeParse: "5/1h,20/24h",
+	RateLimitApply:       "20/1h",
+	RateLimitSearch:      "60/1m",
+	RateLimitAPI:         "120/1m",
+}
+
+// ipRateLimits are counted per client IP even for signed-in users; the other
+// classes are counted per user once the request is authenticated.
+var ipRateLimits = map[string]bool{
+	RateLimitGlobal: true,
+	RateLimitAuth:   true,
+}
+
+type rateLimitRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// rateLimitStore counts requests in fixed windows.
+type rateLimitStore interface {
+	// Hit counts a request against key and returns the number of requests in
+	// the current window, including this one, and when the window started.
+	Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error)
+	// Cleanup forgets windows of keys starting with prefix that started
+	// before the cutoff.
+	Cleanup(ctx context.Context, prefix string, before time.Time) (int64, error)
+}
+
+type rateLimiter struct {
+	store rateLimitStore
+	rules map[string][]rateLimitRule
+}
+
+// newRateLimiter reads each class's quotas from the environment, falling back
+// to defaultRateLimits.
+func newRateLimiter(store rateLimitStore) (*rateLimiter, error) {
+	limiter := &rateLimiter{store: store, rules: make(map[string][]rateLimitRule)}
+	for class, spec := range defaultRateLimits {
+		if override := os.Getenv("RATE_LIMIT_" + strings.ToUpper(class)); override != "" {
+			spec = override
+		}
+		rules, err := parseRateLimitRules(spec)
+		if err != nil {
+			return nil, fmt.Errorf("RATE_LIMIT_%s: %w", strings.ToUpper(class), err)
+		}
+		limiter.rules[class] = rules
+	}
+	return limiter, nil
+}
+
+// parseRateLimitRules parses a comma-separated list of "<requests>/<window>"
+// quotas, such as "5/1h,20/24h".
+func parseRateLimitRules(spec string) ([]rateLimitRule, error) {
+	if strings.TrimSpace(spec) == "off" {
+		return nil, nil
+	}
+	var rules []rateLimitRule
+	for _, part := range strings.Split(spec, ",") {
+		limitStr, windowStr, found := strings.Cut(strings.TrimSpace(part), "/")
+		if !found {
+			return nil, fmt.Errorf("%q is not in the form <requests>/<window>", part)
+		}
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("%q is not a positive number of requests", limitStr)
+		}
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window < time.Second {
+			return nil, fmt.Errorf("%q is not a window of at least a second", windowStr)
+		}
+		rules = append(rules, rateLimitRule{Limit: limit, Window: window})
+	}
+	return rules, nil
+}
+
+// rateLimitKeyPrefix starts the key of every counter for one of a class's
+// quotas, so counters can be expired by that quota's window.
+func rateLimitKeyPrefix(class string, window time.Duration) string {
+	return fmt.Sprintf("%s:%s:", class, window)
+}
+
+// longestWindow is how long counters must be kept.
+func (l *rateLimiter) longestWindow() time.Duration {
+	var longest time.Duration
+	for _, rules := range l.rules {
+		for _, rule := range rules {
+			longest = max(longest, rule.Window)
+		}
+	}
+	return longest
+}
+
+// trustedProxies parses TRUSTED_PROXIES. An empty list trusts no proxy, so
+// ClientIP is the address of the connection itself.
+func trustedProxies(raw string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(raw, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// rateLimit rejects requests over the class's quotas with 429 Too Many
+// Requests and a Retry-After header. Attach it after authentication for
+// classes counted per user. If the store fails, requests are let through.
+func (app *App) rateLimit(class string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := app.rateLimits.rules[class]
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+		subject := "ip:" + c.ClientIP()
+		if !ipRateLimits[class] {
+			if userID, ok := c.Get("userID"); ok {
+				if id, ok := userID.(pgtype.UUID); ok && id.Valid {
+					subject = "user:" + id.String()
+				}
+			}
+		}
+
+		for _, rule := range rules {
+			key := rateLimitKeyPrefix(class, rule.Window) + subject
+			hits, windowStart, err := app.rateLimits.store.Hit(c.Request.Context(), key, rule.Window)
+			if err != nil {
+				fmt.Printf("Rate Limit: Failed to count request for %s: %v\n", key, err)
+				continue
+			}
+			if hits <= rule.Limit {
+				continue
+			}
+
+			retryAfter := max(int(math.Ceil(time.Until(windowStart.Add(rule.Window)).Seconds())), 1)
+			fmt.Printf("Rate Limit: %s is over the %s limit of %d per %s\n", subject, class, rule.Limit, rule.Window)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+				apiError(c, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			wait := (time.Duration(retryAfter) * time.Second).Round(time.Second)
+			app.renderError(c, http.StatusTooManyRequests, fmt.Sprintf("Too many requests. Please try again in %s.", wait))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (app *App) runRateLimitCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := app.rateLimits.cleanup(ctx, time.Now()); err != nil {
+			fmt.Printf("Rate Limit: Failed to delete expired counters: %v\n", err)
+		}
+	}
+}
+
+// cleanup deletes each quota's counters once its own window has passed, then
+// any left over from quotas that have since been reconfigured.
+func (l *rateLimiter) cleanup(ctx context.Context, now time.Time) (int64, error) {
+	var removed int64
+	for class, rules := range l.rules {
+		for _, rule := range rules {
+			n, err := l.store.Cleanup(ctx, rateLimitKeyPrefix(class, rule.Window), now.Add(-rule.Window))
+			if err != nil {
+				return removed, err
+			}
+			removed += n
+		}
+	}
+	n, err := l.store.Cleanup(ctx, "", now.Add(-l.longestWindow()))
+	return removed + n, err
+}
+
+// memoryRateLimitStore keeps counters in this process, so each instance
+// enforces its own quotas.
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	windows map[string]memoryRateWindow
+	now     func() time.Time
+}
+
+type memoryRateWindow struct {
+	start time.Time
+	hits  int
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{windows: make(map[string]memoryRateWindow), now: time.Now}
+}
+
+func (s *memoryRateLimitStore) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.windows[key]
+	if !ok || !current.start.After(now.Add(-window)) {
+		current = memoryRateWindow{start: now}
+	}
+	current.hits++
+	s.windows[key] = current
+	return current.hits, current.start, nil
+}
+
+func (s *memoryRateLimitStore) Cleanup(ctx context.Context, prefix string, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var removed int64
+	for key, window := range s.windows {
+		if strings.HasPrefix(key, prefix) && window.start.Before(before) {
+			delete(s.windows, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// pgRateLimitStore keeps counters in the rate_limits table, shared by every
+// instance.
+type pgRateLimitStore struct {
+	db *db.Queries
+}
+
+func (s *pgRateLimitStore) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	row, err := s.db.HitRateLimit(ctx, db.HitRateLimitParams{
+		Key:          key,
+		WindowCutoff: pgtype.Timestamptz{Time: time.Now().Add(-window), Valid: true},
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return int(row.Hits), row.WindowStart.Time, nil
+}
+
+func (s *pgRateLimitStore) Cleanup(ctx context.Context, prefix string, before time.Time) (int64, error) {
+	return s.db.DeleteExpiredRateLimits(ctx, db.DeleteExpiredRateLimitsParams{
+		KeyPrefix: prefix,
+		Before:    pgtype.Timestamptz{Time: before, Valid: true},
+	})
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock lets tests move the memory store's windows forward.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestRateLimitStore() (*memoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	store := newMemoryRateLimitStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+// newTestRateLimitRouter serves GET /api/ping behind the class's quotas.
+func newTestRateLimitRouter(limiter *rateLimiter, class string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{rateLimits: limiter}
+	router := gin.New()
+	router.GET("/api/ping", app.rateLimit(class), func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+func ping(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestParseRateLimitRules(t *testing.T) {
+	rules, err := parseRateLimitRules("5/1h, 20/24h")
+	if err != nil {
+		t.Fatalf("parseRateLimitRules: %v", err)
+	}
+	want := []rateLimitRule{{Limit: 5, Window: time.Hour}, {Limit: 20, Window: 24 * time.Hour}}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Errorf("rules = %v, want %v", rules, want)
+	}
+
+	if rules, err := parseRateLimitRules("off"); err != nil || rules != nil {
+		t.Errorf(`parseRateLimitRules("off") = %v, %v; want no rules`, rules, err)
+	}
+	for _, spec := range []string{"5", "0/1m", "x/1m", "5/500ms", "5/forever"} {
+		if _, err := parseRateLimitRules(spec); err == nil {
+			t.Errorf("parseRateLimitRules(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestRateLimitClassOverride(t *testing.T) {
+	t.Setenv("RATE_LIMIT_RESUME_PARSE", "2/10m")
+	t.Setenv("RATE_LIMIT_SEARCH", "off")
+
+	limiter, err := newRateLimiter(newMemoryRateLimitStore())
+	if err != nil {
+		t.Fatalf("newRateLimiter: %v", err)
+	}
+	if got := limiter.rules[RateLimitResumeParse]; len(got) != 1 || got[0] != (rateLimitRule{Limit: 2, Window: 10 * time.Minute}) {
+		t.Errorf("resume_parse rules = %v, want the override 2/10m", got)
+	}
+	if got := limiter.rules[RateLimitSearch]; len(got) != 0 {
+		t.Errorf("search rules = %v, want none when turned off", got)
+	}
+	defaults, _ := parseRateLimitRules(defaultRateLimits[RateLimitApply])
+	if got := limiter.rules[RateLimitApply]; len(got) != len(defaults) || got[0] != defaults[0] {
+		t.Errorf("apply rules = %v, want the default %v", got, defaults)
+	}
+
+	t.Setenv("RATE_LIMIT_AUTH", "lots")
+	if _, err := newRateLimiter(newMemoryRateLimitStore()); err == nil {
+		t.Error("newRateLimiter accepted an invalid RATE_LIMIT_AUTH")
+	}
+}
+
+func TestRateLimitRejectsWithRetryAfter(t *testing.T) {
+	store, _ := newTestRateLimitStore()
+	limiter := &rateLimiter{store: store, rules: map[string][]rateLimitRule{
+		RateLimitAPI: {{Limit: 2, Window: time.Minute}},
+	}}
+	router := newTestRateLimitRouter(limiter, RateLimitAPI)
+
+	for i := 0; i < 2; i++ {
+		if rec := ping(router, "192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := ping(router, "192.0.2.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: status %d, want 429", rec.Code)
+	}
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("Retry-After = %q, want 1-60 seconds", rec.Header().Get("Retry-After"))
+	}
+
+	// Other clients have their own quota.
+	if rec := ping(router, "192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("another client: status %d, want 200", rec.Code)
+	}
+}
+
+func TestRateLimitWindowRollover(t *testing.T) {
+	store, clock := newTestRateLimitStore()
+	limiter := &rateLimiter{store: store, rules: map[string][]rateLimitRule{
+		RateLimitAPI: {{Limit: 1, Window: time.Minute}},
+	}}
+	router := newTestRateLimitRouter(limiter, RateLimitAPI)
+
+	if rec := ping(router, "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", rec.Code)
+	}
+	clock.Advance(59 * time.Second)
+	if rec := ping(router, "192.0.2.1:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("within the window: status %d, want 429", rec.Code)
+	}
+	clock.Advance(time.Second)
+	if rec := ping(router, "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("after the window: status %d, want 200", rec.Code)
+	}
+}
+
+func TestRateLimitCleanupUsesEachWindow(t *testing.T) {
+	store, clock := newTestRateLimitStore()
+	limiter := &rateLimiter{store: store, rules: map[string][]rateLimitRule{
+		RateLimitSearch:      {{Limit: 10, Window: time.Minute}},
+		RateLimitResumeParse: {{Limit: 10, Window: 24 * time.Hour}},
+	}}
+	ctx := context.Background()
+	searchKey := rateLimitKeyPrefix(RateLimitSearch, time.Minute) + "ip:192.0.2.1"
+	parseKey := rateLimitKeyPrefix(RateLimitResumeParse, 24*time.Hour) + "user:1"
+	staleKey := rateLimitKeyPrefix(RateLimitApply, time.Hour) + "user:1" // No longer configured
+	for _, key := range []string{searchKey, parseKey, staleKey} {
+		if _, _, err := store.Hit(ctx, key, time.Hour); err != nil {
+			t.Fatalf("Hit(%s): %v", key, err)
+		}
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, err := limiter.cleanup(ctx, clock.Now()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if _, ok := store.windows[searchKey]; ok {
+		t.Error("search counter outlived its one-minute window")
+	}
+	if _, ok := store.windows[parseKey]; !ok {
+		t.Error("resume_parse counter was deleted inside its 24-hour window")
+	}
+
+	clock.Advance(24 * time.Hour)
+	if _, err := limiter.cleanup(ctx, clock.Now()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if len(store.windows) != 0 {
+		t.Errorf("counters left after every window passed: %v", store.windows)
+	}
+}
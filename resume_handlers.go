@@ -44,49 +44,26 @@ func (app *App) getResumeHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleApplicant {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only applicants can manage resumes</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only applicants can manage resumes")
 		c.Abort()
 		return
 	}
 
 	resumeBytes, err := app.db.GetUserResume(c.Request.Context(), pgID)
-	resumeStatus := ""
+	statusError := ""
 	if err != nil && err != sql.ErrNoRows {
 		fmt.Printf("Get Resume Handler: DB error fetching resume status for %s: %v\n", pgID.String(), err)
-		resumeStatus = `<p style="color:red;">Error checking current resume status.</p>`
+		statusError = "Error checking current resume status."
 	} else if err == sql.ErrNoRows {
 		fmt.Printf("Get Resume Handler: User %s not found when checking resume status.\n", pgID.String())
-		resumeStatus = `<p style="color:red;">Error checking user status.</p>`
-	} else if len(resumeBytes) > 0 {
-		resumeStatus = `<p>A resume is currently uploaded. Uploading a new file will replace it.</p>`
-	} else {
-		resumeStatus = `<p>No resume currently uploaded.</p>`
+		statusError = "Error checking user status."
 	}
 
-	formHTML := fmt.Sprintf(`
-		<h2>Manage Resume</h2>
-		%s 
-		<form method="POST" action="/applicant/resume" enctype="multipart/form-data">
-			<div>
-				<label for="resumeFile">Upload New Resume (PDF only):</label><br><br>
-				<input type="file" id="resumeFile" name="resumeFile" accept=".pdf" required>
-			</div>
-			<br>
-			<button type="submit">Upload Resume</button>
-		</form>
-		<br>
-		<p><a href="/applicant/dashboard">Back to Dashboard</a></p>
-	`, resumeStatus)
-
-	fullHTML := fmt.Sprintf(`
-		<!DOCTYPE html><html><head><title>Manage Resume</title></head><body>
-		<nav>...</nav><hr>
-		%s
-		<hr><footer>...</footer></body></html>`, formHTML)
-
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, fullHTML)
+	app.render(c, http.StatusOK, "resume.html", "Manage Resume", gin.H{
+		"StatusError": statusError,
+		"HasResume":   len(resumeBytes) > 0,
+		"CSRF":        csrfToken(c),
+	})
 }
 
 func (app *App) postResumeHandler(c *gin.Context) {
@@ -115,8 +92,7 @@ func (app *App) postResumeHandler(c *gin.Context) {
 	}
 
 	if user.Role != RoleApplicant {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusForbidden, "<html><body>Forbidden: Only applicants can manage resumes</body></html>")
+		app.renderError(c, http.StatusForbidden, "Forbidden: Only applicants can manage resumes")
 		c.Abort()
 		return
 	}
@@ -124,26 +100,26 @@ func (app *App) postResumeHandler(c *gin.Context) {
 	fileHeader, err := c.FormFile("resumeFile")
 	if err != nil {
 		fmt.Printf("Post Resume Handler: Error getting form file: %v\n", err)
-		c.String(http.StatusBadRequest, "<html><body>Error: No resume file uploaded or invalid field name.</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Error: No resume file uploaded or invalid field name.")
 		return
 	}
 
 	if fileHeader.Header.Get("Content-Type") != "application/pdf" {
 		fmt.Printf("Post Resume Handler: Invalid file type: %s\n", fileHeader.Header.Get("Content-Type"))
-		c.String(http.StatusBadRequest, "<html><body>Error: Invalid file type. Only PDF is allowed.</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Error: Invalid file type. Only PDF is allowed.")
 		return
 	}
 
 	if fileHeader.Size > maxUploadSize {
 		fmt.Printf("Post Resume Handler: File too large: %d bytes\n", fileHeader.Size)
-		c.String(http.StatusBadRequest, "<html><body>Error: File size exceeds limit (5MB).</body></html>")
+		app.renderError(c, http.StatusBadRequest, "Error: File size exceeds limit (5MB).")
 		return
 	}
 
 	file, err := fileHeader.Open()
 	if err != nil {
 		fmt.Printf("Post Resume Handler: Error opening uploaded file: %v\n", err)
-		c.String(http.StatusInternalServerError, "<html><body>Error processing uploaded file.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error processing uploaded file.")
 		return
 	}
 	defer file.Close()
@@ -151,7 +127,7 @@ func (app *App) postResumeHandler(c *gin.Context) {
 	resumeBytes, err := io.ReadAll(file)
 	if err != nil {
 		fmt.Printf("Post Resume Handler: Error reading uploaded file: %v\n", err)
-		c.String(http.StatusInternalServerError, "<html><body>Error reading uploaded file content.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error reading uploaded file content.")
 		return
 	}
 
@@ -163,7 +139,7 @@ func (app *App) postResumeHandler(c *gin.Context) {
 	err = app.db.UpdateUserResume(c.Request.Context(), params)
 	if err != nil {
 		fmt.Printf("Post Resume Handler: DB error updating resume for user %s: %v\n", pgID.String(), err)
-		c.String(http.StatusInternalServerError, "<html><body>Error saving resume to database. Please try again.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error saving resume to database. Please try again.")
 		return
 	}
 
@@ -179,7 +155,7 @@ func (app *App) postResumeHandler(c *gin.Context) {
 	jsonBytes, err = json.Marshal(parsedResume)
 	if err != nil {
 		fmt.Printf("Post Resume Handler: Error marshalling parsed resume to JSON: %v\n", err)
-		c.String(http.StatusInternalServerError, "<html><body>Error processing parsed resume data.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error processing parsed resume data.")
 		c.Abort()
 		return
 	}
@@ -193,7 +169,7 @@ func (app *App) postResumeHandler(c *gin.Context) {
 
 	if err != nil {
 		fmt.Printf("Post Resume Handler: DB error updating parsed resume for user %s: %v\n", pgID.String(), err)
-		c.String(http.StatusInternalServerError, "<html><body>Error saving parsed resume to database. Please try again.</body></html>")
+		app.renderError(c, http.StatusInternalServerError, "Error saving parsed resume to database. Please try again.")
 		return
 	}
 
@@ -0,0 +1,320 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	QuestionKindText   = "text"
+	QuestionKindYesNo  = "yes_no"
+	QuestionKindChoice = "choice"
+	QuestionKindNumber = "number"
+)
+
+var questionKindLabels = map[string]string{
+	QuestionKindText:   "Free text",
+	QuestionKindYesNo:  "Yes / No",
+	QuestionKindChoice: "Multiple choice",
+	QuestionKindNumber: "Number (e.g. expected salary)",
+}
+
+// normalizeScreeningAnswer validates a submitted answer against the question
+// and returns it in the form it is stored in. An empty result with a nil error
+// means an optional question was left blank.
+func normalizeScreeningAnswer(q db.ScreeningQuestion, raw string) (string, error) {
+	answer := strings.TrimSpace(raw)
+	if answer == "" {
+		if q.Required {
+			return "", fmt.Errorf("%q is required", q.Prompt)
+		}
+		return "", nil
+	}
+
+	switch q.Kind {
+	case QuestionKindYesNo:
+		answer = strings.ToLower(answer)
+		if answer != "yes" && answer != "no" {
+			return "", fmt.Errorf("%q must be answered yes or no", q.Prompt)
+		}
+	case QuestionKindChoice:
+		for _, option := range q.Options {
+			if option == answer {
+				return answer, nil
+			}
+		}
+		return "", fmt.Errorf("%q has an invalid choice", q.Prompt)
+	case QuestionKindNumber:
+		value, err := decimal.NewFromString(answer)
+		if err != nil {
+			return "", fmt.Errorf("%q must be a number", q.Prompt)
+		}
+		answer = value.String()
+	}
+	return answer, nil
+}
+
+// isKnockedOut reports whether a normalized answer fails the question's
+// knockout criteria.
+func isKnockedOut(q db.ScreeningQuestion, answer string) bool {
+	if !q.Knockout || answer == "" {
+		return false
+	}
+
+	switch q.Kind {
+	case QuestionKindYesNo, QuestionKindChoice:
+		for _, disqualifying := range q.KnockoutAnswers {
+			if strings.EqualFold(disqualifying, answer) {
+				return true
+			}
+		}
+	case QuestionKindNumber:
+		value, err := decimal.NewFromString(answer)
+		if err != nil {
+			return false
+		}
+		if lower, ok := numericToDecimal(q.KnockoutMin); ok && value.LessThan(lower) {
+			return true
+		}
+		if upper, ok := numericToDecimal(q.KnockoutMax); ok && value.GreaterThan(upper) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitOptions(raw string) []string {
+	options := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(raw, ",", "\n"), "\n") {
+		if option := strings.TrimSpace(line); option != "" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// screeningQuestionRow is a question as listed on the recruiter's page.
+type screeningQuestionRow struct {
+	ID       pgtype.UUID
+	Position int32
+	Prompt   string
+	Kind     string
+	Options  string
+	Required bool
+	Knockout string
+}
+
+type screeningQuestionKind struct {
+	Value string
+	Label string
+}
+
+func (app *App) getScreeningQuestionsHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+
+	questions, err := app.db.ListScreeningQuestionsForJob(c.Request.Context(), job.ID)
+	if err != nil {
+		fmt.Printf("Screening Questions GET: DB error listing questions for job %s: %v\n", jobIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Error loading screening questions.")
+		return
+	}
+
+	rows := make([]screeningQuestionRow, 0, len(questions))
+	for _, q := range questions {
+		knockout := "No"
+		if q.Knockout {
+			switch q.Kind {
+			case QuestionKindNumber:
+				var bounds []string
+				if lower, ok := numericToDecimal(q.KnockoutMin); ok {
+					bounds = append(bounds, "below "+lower.String())
+				}
+				if upper, ok := numericToDecimal(q.KnockoutMax); ok {
+					bounds = append(bounds, "above "+upper.String())
+				}
+				knockout = "Rejects " + strings.Join(bounds, " or ")
+			default:
+				knockout = "Rejects: " + strings.Join(q.KnockoutAnswers, ", ")
+			}
+		}
+		rows = append(rows, screeningQuestionRow{
+			ID:       q.ID,
+			Position: q.Position,
+			Prompt:   q.Prompt,
+			Kind:     questionKindLabels[q.Kind],
+			Options:  strings.Join(q.Options, ", "),
+			Required: q.Required,
+			Knockout: knockout,
+		})
+	}
+
+	var kinds []screeningQuestionKind
+	for _, kind := range []string{QuestionKindText, QuestionKindYesNo, QuestionKindChoice, QuestionKindNumber} {
+		kinds = append(kinds, screeningQuestionKind{Value: kind, Label: questionKindLabels[kind]})
+	}
+
+	app.render(c, http.StatusOK, "screening_questions.html", "Screening Questions", gin.H{
+		"Job":          job,
+		"Questions":    rows,
+		"Kinds":        kinds,
+		"NextPosition": len(questions) + 1,
+		"CSRF":         csrfToken(c),
+	})
+}
+
+func (app *App) postScreeningQuestionHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+
+	params, err := screeningQuestionParamsFromForm(c)
+	if err != nil {
+		app.renderErrorBack(c, http.StatusBadRequest, err.Error(), "/recruiter/jobs/"+jobIDStr+"/questions", "Go back")
+		return
+	}
+	params.JobPostingID = job.ID
+
+	if _, err := app.db.CreateScreeningQuestion(c.Request.Context(), params); err != nil {
+		fmt.Printf("Screening Questions POST: DB error creating question for job %s: %v\n", jobIDStr, err)
+		app.renderError(c, http.StatusInternalServerError, "Failed to save screening question. Please try again.")
+		return
+	}
+
+	fmt.Printf("Screening question added to job %s by recruiter %s\n", jobIDStr, recruiter.ID.String())
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("/recruiter/jobs/%s/questions", jobIDStr))
+}
+
+// screeningQuestionParamsFromForm builds a question from the add-question form,
+// without the job posting ID.
+func screeningQuestionParamsFromForm(c *gin.Context) (db.CreateScreeningQuestionParams, error) {
+	params := db.CreateScreeningQuestionParams{
+		Prompt:          strings.TrimSpace(c.PostForm("prompt")),
+		Kind:            c.PostForm("kind"),
+		Options:         []string{},
+		Required:        c.PostForm("required") == "true",
+		Knockout:        c.PostForm("knockout") == "true",
+		KnockoutAnswers: []string{},
+	}
+	if params.Prompt == "" {
+		return params, errors.New("The question text is required.")
+	}
+	if _, ok := questionKindLabels[params.Kind]; !ok {
+		return params, errors.New("Invalid answer type.")
+	}
+	if position, err := strconv.Atoi(c.PostForm("position")); err == nil && position >= 0 {
+		params.Position = int32(position)
+	}
+
+	if params.Kind == QuestionKindChoice {
+		params.Options = splitOptions(c.PostForm("options"))
+		if len(params.Options) < 2 {
+			return params, errors.New("Multiple choice questions need at least two choices.")
+		}
+	}
+
+	if !params.Knockout {
+		return params, nil
+	}
+	switch params.Kind {
+	case QuestionKindYesNo:
+		for _, answer := range splitOptions(c.PostForm("knockout_answers")) {
+			answer = strings.ToLower(answer)
+			if answer != "yes" && answer != "no" {
+				return params, errors.New("Knockout answers for yes/no questions must be yes or no.")
+			}
+			params.KnockoutAnswers = append(params.KnockoutAnswers, answer)
+		}
+	case QuestionKindChoice:
+		for _, answer := range splitOptions(c.PostForm("knockout_answers")) {
+			found := false
+			for _, option := range params.Options {
+				if option == answer {
+					found = true
+				}
+			}
+			if !found {
+				return params, fmt.Errorf("Knockout answer %q is not one of the choices.", answer)
+			}
+			params.KnockoutAnswers = append(params.KnockoutAnswers, answer)
+		}
+	case QuestionKindNumber:
+		var lower, upper pgtype.Numeric
+		var decMin, decMax decimal.Decimal
+		var err error
+		if raw := c.PostForm("knockout_min"); raw != "" {
+			if decMin, err = decimal.NewFromString(raw); err != nil || lower.Scan(decMin.String()) != nil {
+				return params, errors.New("Invalid knockout minimum.")
+			}
+		}
+		if raw := c.PostForm("knockout_max"); raw != "" {
+			if decMax, err = decimal.NewFromString(raw); err != nil || upper.Scan(decMax.String()) != nil {
+				return params, errors.New("Invalid knockout maximum.")
+			}
+		}
+		if lower.Valid && upper.Valid && decMin.GreaterThan(decMax) {
+			return params, errors.New("Knockout minimum cannot be greater than the maximum.")
+		}
+		params.KnockoutMin = lower
+		params.KnockoutMax = upper
+	default:
+		return params, errors.New("Free text questions cannot be knockout questions.")
+	}
+
+	if len(params.KnockoutAnswers) == 0 && !params.KnockoutMin.Valid && !params.KnockoutMax.Valid {
+		return params, errors.New("A knockout question needs at least one disqualifying answer or bound.")
+	}
+	return params, nil
+}
+
+func (app *App) deleteScreeningQuestionHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	job, ok := app.loadOwnedJob(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	jobIDStr := uuid.UUID(job.ID.Bytes).String()
+
+	questionUUID, err := uuid.Parse(c.Param("questionID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid question ID.")
+		return
+	}
+
+	err = app.db.DeleteScreeningQuestion(c.Request.Context(), db.DeleteScreeningQuestionParams{
+		ID:           pgtype.UUID{Bytes: questionUUID, Valid: true},
+		JobPostingID: job.ID,
+	})
+	if err != nil {
+		fmt.Printf("Screening Questions DELETE: DB error deleting question for job %s: %v\n", jobIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to delete screening question.")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("/recruiter/jobs/%s/questions", jobIDStr))
+}
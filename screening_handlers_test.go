@@ -0,0 +1,54 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func testNumeric(value int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(value), Valid: true}
+}
+
+func TestIsKnockedOut(t *testing.T) {
+	needsVisa := db.ScreeningQuestion{Kind: QuestionKindYesNo, Knockout: true, KnockoutAnswers: []string{"Yes"}}
+	shift := db.ScreeningQuestion{Kind: QuestionKindChoice, Options: []string{"Day", "Night", "Weekend"}, Knockout: true, KnockoutAnswers: []string{"Night", "Weekend"}}
+	experience := db.ScreeningQuestion{Kind: QuestionKindNumber, Knockout: true, KnockoutMin: testNumeric(2), KnockoutMax: testNumeric(30)}
+	minimumOnly := db.ScreeningQuestion{Kind: QuestionKindNumber, Knockout: true, KnockoutMin: testNumeric(18)}
+	notKnockout := db.ScreeningQuestion{Kind: QuestionKindYesNo, KnockoutAnswers: []string{"Yes"}}
+	text := db.ScreeningQuestion{Kind: QuestionKindText, Knockout: true, KnockoutAnswers: []string{"anything"}}
+
+	tests := []struct {
+		name     string
+		question db.ScreeningQuestion
+		answer   string
+		want     bool
+	}{
+		{"yes/no matching answer", needsVisa, "Yes", true},
+		{"yes/no matching answer in another case", needsVisa, "yes", true},
+		{"yes/no other answer", needsVisa, "No", false},
+		{"yes/no unanswered", needsVisa, "", false},
+		{"choice matching answer", shift, "Weekend", true},
+		{"choice other answer", shift, "Day", false},
+		{"choice unanswered", shift, "", false},
+		{"number below the minimum", experience, "1", true},
+		{"number at the minimum", experience, "2", false},
+		{"number in range", experience, "10.5", false},
+		{"number at the maximum", experience, "30", false},
+		{"number above the maximum", experience, "31", true},
+		{"number not a number", experience, "lots", false},
+		{"number unanswered", experience, "", false},
+		{"number with only a minimum", minimumOnly, "1000", false},
+		{"not a knockout question", notKnockout, "Yes", false},
+		{"text question", text, "anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isKnockedOut(tt.question, tt.answer); got != tt.want {
+				t.Errorf("isKnockedOut(%q) = %v, want %v", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultSessionIdleTimeout = 12 * time.Hour
+	sessionCleanupInterval    = time.Hour
+	maxSessionUserAgentLength = 512
+)
+
+// pgSessionStore keeps sessions in the user_sessions table. The cookie holds
+// only a signed random token, so logging out or revoking a session on the
+// server ends it for good, and sessions nobody has used within idleTimeout
+// expire.
+type pgSessionStore struct {
+	db          *db.Queries
+	codecs      []securecookie.Codec
+	options     *gsessions.Options
+	idleTimeout time.Duration
+}
+
+func newPGSessionStore(queries *db.Queries, idleTimeout time.Duration, keyPairs ...[]byte) *pgSessionStore {
+	return &pgSessionStore{
+		db:          queries,
+		codecs:      securecookie.CodecsFromPairs(keyPairs...),
+		options:     &gsessions.Options{Path: "/", MaxAge: 86400, HttpOnly: true},
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Options implements sessions.Store.
+func (s *pgSessionStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+	for _, codec := range s.codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(options.MaxAge)
+		}
+	}
+}
+
+// Get implements gorilla's sessions.Store, caching the session per request.
+func (s *pgSessionStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New loads the session named by the request's cookie, or starts an empty
+// one when there is no cookie or the session has ended.
+func (s *pgSessionStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	token, ok := s.cookieToken(r, name)
+	if !ok {
+		return session, nil
+	}
+	row, err := s.db.GetUserSession(r.Context(), db.GetUserSessionParams{
+		TokenHash: hashToken(token),
+		SeenAfter: s.idleCutoff(),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return session, nil
+	} else if err != nil {
+		return session, err
+	}
+	if err := (securecookie.GobEncoder{}).Deserialize(row.Data, &session.Values); err != nil {
+		return session, err
+	}
+	session.ID = token
+	session.IsNew = false
+
+	err = s.db.TouchUserSession(r.Context(), db.TouchUserSessionParams{
+		TokenHash: hashToken(token),
+		IpAddress: requestIP(r),
+		UserAgent: requestUserAgent(r),
+	})
+	if err != nil {
+		fmt.Printf("Sessions: Failed to update last seen time: %v\n", err)
+	}
+	return session, nil
+}
+
+// Save writes the session and its cookie. A session whose user changes, by
+// signing in or out, gets a new token so an old cookie cannot be reused.
+func (s *pgSessionStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	ctx := r.Context()
+	if session.Options.Path == "" {
+		session.Options.Path = s.options.Path
+	}
+
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.db.DeleteUserSessionByToken(ctx, hashToken(session.ID)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	data, err := (securecookie.GobEncoder{}).Serialize(session.Values)
+	if err != nil {
+		return err
+	}
+	userID, _ := session.Values[sessionUserKey].(pgtype.UUID)
+	lifetime := time.Duration(session.Options.MaxAge) * time.Second
+	if lifetime <= 0 {
+		lifetime = s.idleTimeout
+	}
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(lifetime), Valid: true}
+
+	saved := false
+	if session.ID != "" {
+		updated, err := s.db.UpdateUserSession(ctx, db.UpdateUserSessionParams{
+			Data:      data,
+			ExpiresAt: expiresAt,
+			IpAddress: requestIP(r),
+			UserAgent: requestUserAgent(r),
+			TokenHash: hashToken(session.ID),
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+		saved = updated > 0
+		if !saved {
+			if err := s.db.DeleteUserSessionByToken(ctx, hashToken(session.ID)); err != nil {
+				return err
+			}
+		}
+	}
+	if !saved {
+		token, err := newSessionToken()
+		if err != nil {
+			return err
+		}
+		err = s.db.CreateUserSession(ctx, db.CreateUserSessionParams{
+			TokenHash: hashToken(token),
+			UserID:    userID,
+			Data:      data,
+			IpAddress: requestIP(r),
+			UserAgent: requestUserAgent(r),
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		session.ID = token
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+func (s *pgSessionStore) cookieToken(r *http.Request, name string) (string, bool) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return "", false
+	}
+	return token, token != ""
+}
+
+// currentTokenHash identifies the request's own login session among the
+// user's sessions.
+func (s *pgSessionStore) currentTokenHash(r *http.Request) []byte {
+	token, ok := s.cookieToken(r, sessionCookieName)
+	if !ok {
+		return nil
+	}
+	return hashToken(token)
+}
+
+func (s *pgSessionStore) idleCutoff() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().Add(-s.idleTimeout), Valid: true}
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type clientIPContextKey struct{}
+
+// clientIPMiddleware records gin's ClientIP, which only believes forwarding
+// headers from TRUSTED_PROXIES, on the request for the session store. It must
+// run before the sessions middleware, which keeps the request it was given.
+func clientIPMiddleware(c *gin.Context) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), clientIPContextKey{}, c.ClientIP()))
+	c.Next()
+}
+
+// requestIP returns the IP clientIPMiddleware recorded, or the connection's
+// address when it did not run.
+func requestIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func requestUserAgent(r *http.Request) string {
+	agent := r.UserAgent()
+	if len(agent) > maxSessionUserAgentLength {
+		agent = agent[:maxSessionUserAgentLength]
+	}
+	return agent
+}
+
+func (app *App) runSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		removed, err := app.db.DeleteExpiredUserSessions(ctx, app.sessionStore.idleCutoff())
+		if err != nil {
+			fmt.Printf("Sessions: Failed to delete expired sessions: %v\n", err)
+		} else if removed > 0 {
+			fmt.Printf("Sessions: Deleted %d expired sessions\n", removed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const samlRequestCookie = "saml_request"
+
+// Attribute names IdPs commonly use, tried when a connection does not name
+// its own.
+var (
+	samlEmailAttributes = []string{
+		"email", "mail", "emailaddress",
+		"urn:oid:0.9.2342.19200300.100.1.3",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+	}
+	samlNameAttributes = []string{
+		"displayname", "name", "cn",
+		"urn:oid:2.16.840.1.113730.3.1.241",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name",
+	}
+)
+
+// samlKeyPair is the certificate and key this app signs SAML requests with.
+// The same pair is used for every organization.
+type samlKeyPair struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+}
+
+func loadSAMLKeyPair(certFile, keyFile string) (*samlKeyPair, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("SAML key cannot sign")
+	}
+	return &samlKeyPair{Key: key, Certificate: cert}, nil
+}
+
+// samlServiceProvider describes this app to one organization's IdP. Each
+// organization gets its own entity ID and ACS URL under /sso/:slug.
+// idpMetadata may be empty when only the SP metadata is needed.
+func (app *App) samlServiceProvider(c *gin.Context, slug, idpMetadata string) (*saml.ServiceProvider, error) {
+	metadataURL, err := url.Parse(app.absoluteURL(c, "/sso/"+slug+"/metadata"))
+	if err != nil {
+		return nil, err
+	}
+	acsURL, err := url.Parse(app.absoluteURL(c, "/sso/"+slug+"/acs"))
+	if err != nil {
+		return nil, err
+	}
+	sp := &saml.ServiceProvider{
+		EntityID:          metadataURL.String(),
+		Key:               app.saml.Key,
+		Certificate:       app.saml.Certificate,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}
+	if idpMetadata != "" {
+		sp.IDPMetadata, err = samlsp.ParseMetadata([]byte(idpMetadata))
+		if err != nil {
+			return nil, fmt.Errorf("parse IdP metadata: %w", err)
+		}
+	}
+	return sp, nil
+}
+
+// samlAttribute returns the first value of the attribute named configured, or
+// of the first of fallbacks present when configured is empty. Names are
+// matched against both Name and FriendlyName, ignoring case.
+func samlAttribute(assertion *saml.Assertion, configured string, fallbacks []string) string {
+	names := fallbacks
+	if configured != "" {
+		names = []string{configured}
+	}
+	for _, name := range names {
+		for _, statement := range assertion.AttributeStatements {
+			for _, attribute := range statement.Attributes {
+				if !strings.EqualFold(attribute.Name, name) && !strings.EqualFold(attribute.FriendlyName, name) {
+					continue
+				}
+				for _, value := range attribute.Values {
+					if v := strings.TrimSpace(value.Value); v != "" {
+						return v
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// emailDomain returns the lower-cased part after the @, or "".
+func emailDomain(email string) string {
+	_, domain, found := strings.Cut(email, "@")
+	if !found {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(domain))
+}
+
+// requireSAML responds 404 when SAML is not configured on this server.
+func (app *App) requireSAML(c *gin.Context) bool {
+	if app.saml == nil {
+		c.String(http.StatusNotFound, "Single sign-on is not enabled on this server.")
+		return false
+	}
+	return true
+}
+
+func (app *App) getSSOStartHandler(c *gin.Context) {
+	if !app.requireSAML(c) {
+		return
+	}
+	app.authPage(c, "sso_start.html", "Single Sign-On", gin.H{
+		"Email": c.Query("email"),
+		"CSRF":  csrfToken(c),
+	})
+}
+
+func (app *App) postSSOStartHandler(c *gin.Context) {
+	if !app.requireSAML(c) {
+		return
+	}
+	email := strings.TrimSpace(c.PostForm("email"))
+	domain := emailDomain(email)
+	slug, err := app.db.GetSSOOrganizationSlugByDomain(c.Request.Context(), domain)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("SSO Start POST: DB error looking up domain %s: %v\n", domain, err)
+		}
+		c.Redirect(http.StatusSeeOther, "/sso?email="+url.QueryEscape(email)+"&error="+url.QueryEscape("Single sign-on is not set up for "+domain+"."))
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/sso/"+slug+"/login")
+}
+
+// getSSOLoginHandler sends the browser to the organization's IdP with a
+// signed AuthnRequest.
+func (app *App) getSSOLoginHandler(c *gin.Context) {
+	if !app.requireSAML(c) {
+		return
+	}
+	connection, err := app.db.GetEnabledSAMLConnectionBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("SSO Login: DB error loading connection %s: %v\n", c.Param("slug"), err)
+		}
+		c.String(http.StatusNotFound, "Single sign-on is not set up for this organization.")
+		return
+	}
+	sp, err := app.samlServiceProvider(c, connection.Slug, connection.IdpMetadata)
+	if err != nil {
+		fmt.Printf("SSO Login: Bad configuration for %s: %v\n", connection.Slug, err)
+		c.String(http.StatusInternalServerError, "Single sign-on is misconfigured for this organization.")
+		return
+	}
+
+	request, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		fmt.Printf("SSO Login: Failed to build request for %s: %v\n", connection.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to start single sign-on.")
+		return
+	}
+	redirectURL, err := request.Redirect("", sp)
+	if err != nil {
+		fmt.Printf("SSO Login: Failed to encode request for %s: %v\n", connection.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to start single sign-on.")
+		return
+	}
+
+	// The IdP posts the response back cross-site, where the session cookie is
+	// not sent, so the request ID travels in its own cookie. Browsers only
+	// send SameSite=None cookies that are Secure, so SSO needs https (or
+	// localhost).
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(samlRequestCookie, request.ID, 600, "/sso/"+connection.Slug, "", true, true)
+	c.Redirect(http.StatusSeeOther, redirectURL.String())
+}
+
+// postSSOACSHandler receives the IdP's response, signs the user in and
+// provisions a recruiter account on first login.
+func (app *App) postSSOACSHandler(c *gin.Context) {
+	if !app.requireSAML(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	connection, err := app.db.GetEnabledSAMLConnectionBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		c.String(http.StatusNotFound, "Single sign-on is not set up for this organization.")
+		return
+	}
+	sp, err := app.samlServiceProvider(c, connection.Slug, connection.IdpMetadata)
+	if err != nil {
+		fmt.Printf("SSO ACS: Bad configuration for %s: %v\n", connection.Slug, err)
+		c.String(http.StatusInternalServerError, "Single sign-on is misconfigured for this organization.")
+		return
+	}
+
+	requestID, _ := c.Cookie(samlRequestCookie)
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(samlRequestCookie, "", -1, "/sso/"+connection.Slug, "", true, true)
+	assertion, err := parseSAMLResponse(sp, c.Request, connection.AllowIdpInitiated, requestID)
+	if err != nil {
+		fmt.Printf("SSO ACS: Rejected response for %s: %v\n", connection.Slug, err)
+		c.String(http.StatusForbidden, "Single sign-on failed. Please start again from the login page.")
+		return
+	}
+
+	userID, message, err := app.provisionSSOUser(ctx, connection, assertion)
+	if err != nil {
+		fmt.Printf("SSO ACS: Failed to sign in user for %s: %v\n", connection.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to sign you in.")
+		return
+	}
+	if message != "" {
+		c.String(http.StatusForbidden, message)
+		return
+	}
+	app.completeLogin(c, userID)
+}
+
+var errSAMLUnsolicited = errors.New("response without a login request, and IdP-initiated login is off")
+
+// parseSAMLResponse checks the response the IdP posted to the ACS URL and
+// returns its assertion. requestID is the login request from our cookie; a
+// response without one is IdP-initiated, which is only accepted when the
+// connection allows it.
+func parseSAMLResponse(sp *saml.ServiceProvider, r *http.Request, allowIdPInitiated bool, requestID string) (*saml.Assertion, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	possibleRequestIDs := []string{requestID}
+	if requestID == "" {
+		if !allowIdPInitiated {
+			return nil, errSAMLUnsolicited
+		}
+		sp.AllowIDPInitiated = true
+		possibleRequestIDs = nil
+	}
+	assertion, err := sp.ParseResponse(r, possibleRequestIDs)
+	var invalid *saml.InvalidResponseError
+	if errors.As(err, &invalid) {
+		err = invalid.PrivateErr
+	}
+	return assertion, err
+}
+
+// ssoIdentity reads who an assertion is about. A non-empty message means the
+// assertion was valid but cannot be used to sign in, and explains why.
+func ssoIdentity(connection db.GetEnabledSAMLConnectionBySlugRow, assertion *saml.Assertion) (pendingIdentity, string) {
+	// The IdP is only trusted for its own organization's addresses, once an
+	// admin has verified the organization owns the domain.
+	if !connection.DomainVerifiedAt.Valid {
+		return pendingIdentity{}, fmt.Sprintf("Single sign-on for %s is waiting for its domain to be verified.", connection.EmailDomain)
+	}
+	email := samlAttribute(assertion, connection.EmailAttribute, samlEmailAttributes)
+	var nameID *saml.NameID
+	if assertion.Subject != nil {
+		nameID = assertion.Subject.NameID
+	}
+	if email == "" && nameID != nil && strings.Contains(nameID.Value, "@") {
+		email = nameID.Value
+	}
+	if email == "" {
+		return pendingIdentity{}, "Your identity provider did not send an email address."
+	}
+	if emailDomain(email) != connection.EmailDomain {
+		return pendingIdentity{}, fmt.Sprintf("%s is not an address at %s.", email, connection.EmailDomain)
+	}
+
+	// Transient IDs change every login, so fall back to the email to
+	// recognise the person.
+	subject := strings.ToLower(email)
+	if nameID != nil && nameID.Value != "" && nameID.Format != string(saml.TransientNameIDFormat) {
+		subject = nameID.Value
+	}
+	return pendingIdentity{Provider: "saml:" + connection.Slug, Subject: subject, Email: email}, ""
+}
+
+// ssoLinkRefusal explains why the existing account with an SSO login's
+// address cannot be signed in to through the organization's IdP, or returns
+// "" if it can. Only the organization's own recruiters are linked; anyone
+// else keeps signing in the way they did before.
+func ssoLinkRefusal(connection db.GetEnabledSAMLConnectionBySlugRow, existing db.GetSSOLinkCandidateRow) string {
+	if existing.Role != RoleRecruiter || existing.OrganizationID != connection.ID {
+		return fmt.Sprintf("An account that is not part of %s already uses this address. Sign in the way you usually do.", connection.Name)
+	}
+	return ""
+}
+
+// provisionSSOUser finds or creates the user an assertion is about. A
+// non-empty message means the assertion was valid but cannot be used to sign
+// in, and explains why.
+func (app *App) provisionSSOUser(ctx context.Context, connection db.GetEnabledSAMLConnectionBySlugRow, assertion *saml.Assertion) (pgtype.UUID, string, error) {
+	identity, message := ssoIdentity(connection, assertion)
+	if message != "" {
+		return pgtype.UUID{}, message, nil
+	}
+
+	user, err := app.db.GetUserByIdentity(ctx, db.GetUserByIdentityParams{Provider: identity.Provider, Subject: identity.Subject})
+	if err == nil {
+		return user.ID, "", nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, "", err
+	}
+
+	// A recruiter already in the organization gets the login linked: the
+	// organization's IdP is authoritative for its own people.
+	if existing, err := app.db.GetSSOLinkCandidate(ctx, identity.Email); err == nil {
+		if message := ssoLinkRefusal(connection, existing); message != "" {
+			fmt.Printf("SSO: Refused %s login for existing user %s.\n", connection.Slug, existing.ID.String())
+			return pgtype.UUID{}, message, nil
+		}
+		if err := app.createIdentity(ctx, existing.ID, identity); err != nil && !isUniqueViolation(err) {
+			return pgtype.UUID{}, "", err
+		}
+		fmt.Printf("SSO: Linked %s login to existing user %s.\n", connection.Slug, existing.ID.String())
+		return existing.ID, "", nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, "", err
+	}
+
+	email := identity.Email
+	name := samlAttribute(assertion, connection.NameAttribute, samlNameAttributes)
+	if name == "" {
+		name = email
+	}
+	params := db.CreateSSOUserParams{Email: email, Name: name, OrganizationID: connection.ID}
+	userID, err := app.db.CreateSSOUser(ctx, params)
+	if isUniqueViolation(err) && name != email {
+		// users.name is unique; disambiguate rather than refuse the login.
+		params.Name = fmt.Sprintf("%s (%s)", name, email)
+		userID, err = app.db.CreateSSOUser(ctx, params)
+	}
+	if err != nil {
+		return pgtype.UUID{}, "", err
+	}
+	if err := app.createIdentity(ctx, userID, identity); err != nil {
+		return pgtype.UUID{}, "", err
+	}
+	fmt.Printf("SSO: Provisioned recruiter %s (ID: %s) for %s.\n", email, userID.String(), connection.Slug)
+	return userID, "", nil
+}
+
+// getSSOMetadataHandler serves the SP metadata to load into the IdP.
+func (app *App) getSSOMetadataHandler(c *gin.Context) {
+	if !app.requireSAML(c) {
+		return
+	}
+	org, err := app.db.GetOrganizationBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		c.String(http.StatusNotFound, "Unknown organization.")
+		return
+	}
+	sp, err := app.samlServiceProvider(c, org.Slug, "")
+	if err != nil {
+		fmt.Printf("SSO Metadata: Failed to build SP for %s: %v\n", org.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to build metadata.")
+		return
+	}
+	metadata, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		fmt.Printf("SSO Metadata: Failed to encode metadata for %s: %v\n", org.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to build metadata.")
+		return
+	}
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
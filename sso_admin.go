@@ -0,0 +1,231 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/crewjam/saml/samlsp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const maxIdPMetadataSize = 1 << 20 // 1MB
+
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,40}$`)
+
+// domainVerificationRecord is the DNS name whose TXT record must hold an
+// organization's verification token before SSO trusts its domain.
+func domainVerificationRecord(domain string) string {
+	return "_recruitment-verification." + domain
+}
+
+func (app *App) getSSOAdminHandler(c *gin.Context) {
+	if _, ok := app.requireRole(c, RoleAdmin); !ok {
+		return
+	}
+
+	organizations, err := app.db.ListOrganizations(c.Request.Context())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("SSO Admin GET: DB error listing organizations: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to load organizations.")
+		return
+	}
+
+	app.render(c, http.StatusOK, "sso_admin.html", "Single Sign-On", gin.H{
+		"Organizations": organizations,
+		"SAMLEnabled":   app.saml != nil,
+		"Error":         c.Query("error"),
+		"CSRF":          csrfToken(c),
+	})
+}
+
+func (app *App) postSSOOrganizationHandler(c *gin.Context) {
+	if _, ok := app.requireRole(c, RoleAdmin); !ok {
+		return
+	}
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, "/admin/sso?error="+url.QueryEscape(message))
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	slug := strings.TrimSpace(c.PostForm("slug"))
+	domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(c.PostForm("email_domain")), "@"))
+	if name == "" {
+		fail("Enter the organization's name.")
+		return
+	}
+	if !organizationSlugPattern.MatchString(slug) {
+		fail("The URL name may only use lowercase letters, digits and dashes.")
+		return
+	}
+	if !strings.Contains(domain, ".") || strings.ContainsAny(domain, " @/") {
+		fail("Enter an email domain such as example.com.")
+		return
+	}
+
+	orgID, err := app.db.CreateOrganization(c.Request.Context(), db.CreateOrganizationParams{
+		Name:        name,
+		Slug:        slug,
+		EmailDomain: domain,
+	})
+	if isUniqueViolation(err) {
+		fail("Another organization already uses that URL name or email domain.")
+		return
+	} else if err != nil {
+		fmt.Printf("SSO Admin POST: DB error creating organization: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to create organization.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/admin/sso/"+uuid.UUID(orgID.Bytes).String())
+}
+
+func (app *App) loadOrganization(c *gin.Context) (db.Organization, bool) {
+	orgUUID, err := uuid.Parse(c.Param("orgID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid organization ID.")
+		return db.Organization{}, false
+	}
+	org, err := app.db.GetOrganization(c.Request.Context(), pgtype.UUID{Bytes: orgUUID, Valid: true})
+	if err != nil {
+		c.String(http.StatusNotFound, "Organization not found.")
+		return db.Organization{}, false
+	}
+	return org, true
+}
+
+func (app *App) getSSOOrganizationHandler(c *gin.Context) {
+	if _, ok := app.requireRole(c, RoleAdmin); !ok {
+		return
+	}
+	org, ok := app.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	connection, err := app.db.GetSAMLConnection(c.Request.Context(), org.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("SSO Admin GET: DB error loading connection for %s: %v\n", org.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to load SSO settings.")
+		return
+	}
+	app.render(c, http.StatusOK, "sso_organization.html", "SSO: "+org.Name, gin.H{
+		"Organization": org,
+		"Connection":   connection,
+		"MetadataURL":  app.absoluteURL(c, "/sso/"+org.Slug+"/metadata"),
+		"ACSURL":       app.absoluteURL(c, "/sso/"+org.Slug+"/acs"),
+		"LoginURL":     app.absoluteURL(c, "/sso/"+org.Slug+"/login"),
+		"Error":        c.Query("error"),
+		"CSRF":         csrfToken(c),
+
+		"VerificationRecord": domainVerificationRecord(org.EmailDomain),
+	})
+}
+
+// postVerifyDomainHandler marks the organization's email domain verified once
+// its DNS has the verification TXT record, proving whoever set up the
+// organization controls the domain its IdP will vouch for.
+func (app *App) postVerifyDomainHandler(c *gin.Context) {
+	if _, ok := app.requireRole(c, RoleAdmin); !ok {
+		return
+	}
+	org, ok := app.loadOrganization(c)
+	if !ok {
+		return
+	}
+	orgURL := "/admin/sso/" + uuid.UUID(org.ID.Bytes).String()
+	record := domainVerificationRecord(org.EmailDomain)
+
+	records, err := net.DefaultResolver.LookupTXT(c.Request.Context(), record)
+	if err != nil {
+		fmt.Printf("SSO Admin: TXT lookup for %s failed: %v\n", record, err)
+		c.Redirect(http.StatusSeeOther, orgURL+"?error="+url.QueryEscape("No TXT record was found at "+record+"."))
+		return
+	}
+	for _, value := range records {
+		if strings.TrimSpace(value) != org.DomainVerificationToken {
+			continue
+		}
+		if err := app.db.MarkOrganizationDomainVerified(c.Request.Context(), org.ID); err != nil {
+			fmt.Printf("SSO Admin: DB error verifying domain for %s: %v\n", org.Slug, err)
+			c.String(http.StatusInternalServerError, "Failed to verify the domain.")
+			return
+		}
+		fmt.Printf("SSO: Verified domain %s for %s.\n", org.EmailDomain, org.Slug)
+		c.Redirect(http.StatusSeeOther, orgURL)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, orgURL+"?error="+url.QueryEscape("The TXT record at "+record+" does not contain the verification token."))
+}
+
+func (app *App) postSSOConnectionHandler(c *gin.Context) {
+	if _, ok := app.requireRole(c, RoleAdmin); !ok {
+		return
+	}
+	org, ok := app.loadOrganization(c)
+	if !ok {
+		return
+	}
+	orgURL := "/admin/sso/" + uuid.UUID(org.ID.Bytes).String()
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, orgURL+"?error="+url.QueryEscape(message))
+	}
+
+	metadata := strings.TrimSpace(c.PostForm("idp_metadata"))
+	if fileHeader, err := c.FormFile("metadata_file"); err == nil {
+		if fileHeader.Size > maxIdPMetadataSize {
+			fail("The metadata file is too large.")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			fail("Could not read the metadata file.")
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxIdPMetadataSize))
+		if err != nil {
+			fail("Could not read the metadata file.")
+			return
+		}
+		metadata = strings.TrimSpace(string(data))
+	}
+	if metadata == "" {
+		fail("Upload or paste the IdP metadata.")
+		return
+	}
+	idp, err := samlsp.ParseMetadata([]byte(metadata))
+	if err != nil {
+		fail("The IdP metadata could not be parsed: " + err.Error())
+		return
+	}
+	if idp.IDPSSODescriptors == nil {
+		fail("The metadata does not describe an identity provider.")
+		return
+	}
+
+	err = app.db.UpsertSAMLConnection(c.Request.Context(), db.UpsertSAMLConnectionParams{
+		OrganizationID: org.ID,
+		IdpMetadata:    metadata,
+		EmailAttribute: strings.TrimSpace(c.PostForm("email_attribute")),
+		NameAttribute:  strings.TrimSpace(c.PostForm("name_attribute")),
+		Enabled:        c.PostForm("enabled") == "true",
+
+		AllowIdpInitiated: c.PostForm("allow_idp_initiated") == "true",
+	})
+	if err != nil {
+		fmt.Printf("SSO Admin POST: DB error saving connection for %s: %v\n", org.Slug, err)
+		c.String(http.StatusInternalServerError, "Failed to save SSO settings.")
+		return
+	}
+	fmt.Printf("SSO settings for %s updated (IdP %s).\n", org.Slug, idp.EntityID)
+	c.Redirect(http.StatusSeeOther, orgURL)
+}
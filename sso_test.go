@@ -0,0 +1,224 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/xml"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// newTestCertificate returns a key and self-signed certificate for SAML
+// signing in tests.
+func newTestCertificate(t *testing.T, commonName string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return key, cert
+}
+
+// testSAML is an organization's IdP and this app's SP for it, set up the way
+// the ACS handler builds it.
+type testSAML struct {
+	idp *saml.IdentityProvider
+	sp  *saml.ServiceProvider
+}
+
+func newTestSAML(t *testing.T) *testSAML {
+	t.Helper()
+	idpKey, idpCert := newTestCertificate(t, "idp.acme.example")
+	idp := &saml.IdentityProvider{
+		Key:         idpKey,
+		Certificate: idpCert,
+		MetadataURL: url.URL{Scheme: "https", Host: "idp.acme.example", Path: "/metadata"},
+		SSOURL:      url.URL{Scheme: "https", Host: "idp.acme.example", Path: "/sso"},
+	}
+	idpMetadata, err := xml.Marshal(idp.Metadata())
+	if err != nil {
+		t.Fatalf("marshal IdP metadata: %v", err)
+	}
+
+	spKey, spCert := newTestCertificate(t, "jobs.example.com")
+	app := &App{
+		site: siteConfig{BaseURL: "https://jobs.example.com"},
+		saml: &samlKeyPair{Key: spKey, Certificate: spCert},
+	}
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	sp, err := app.samlServiceProvider(c, "acme", string(idpMetadata))
+	if err != nil {
+		t.Fatalf("samlServiceProvider: %v", err)
+	}
+	return &testSAML{idp: idp, sp: sp}
+}
+
+// acsRequest has the IdP answer requestID ("" for an IdP-initiated login) for
+// the user in session, and returns the browser's POST to the ACS URL.
+func (s *testSAML) acsRequest(t *testing.T, requestID string, session *saml.Session) *http.Request {
+	t.Helper()
+	spMetadata := s.sp.Metadata()
+	descriptor := &spMetadata.SPSSODescriptors[0]
+	var acs *saml.IndexedEndpoint
+	for i := range descriptor.AssertionConsumerServices {
+		if descriptor.AssertionConsumerServices[i].Binding == saml.HTTPPostBinding {
+			acs = &descriptor.AssertionConsumerServices[i]
+		}
+	}
+	if acs == nil {
+		t.Fatal("SP metadata has no HTTP-POST ACS")
+	}
+
+	req := &saml.IdpAuthnRequest{
+		IDP:                     s.idp,
+		HTTPRequest:             httptest.NewRequest(http.MethodGet, s.idp.SSOURL.String(), nil),
+		Request:                 saml.AuthnRequest{ID: requestID},
+		ServiceProviderMetadata: spMetadata,
+		SPSSODescriptor:         descriptor,
+		ACSEndpoint:             acs,
+		Now:                     saml.TimeNow(),
+	}
+	if err := (saml.DefaultAssertionMaker{}).MakeAssertion(req, session); err != nil {
+		t.Fatalf("MakeAssertion: %v", err)
+	}
+	form, err := req.PostBinding()
+	if err != nil {
+		t.Fatalf("PostBinding: %v", err)
+	}
+
+	body := url.Values{"SAMLResponse": {form.SAMLResponse}}
+	r := httptest.NewRequest(http.MethodPost, form.URL, strings.NewReader(body.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// parse runs the ACS handler's checks on a response and returns the assertion.
+func (s *testSAML) parse(r *http.Request, allowIdPInitiated bool, requestID string) (*saml.Assertion, error) {
+	return parseSAMLResponse(s.sp, r, allowIdPInitiated, requestID)
+}
+
+func testSSOConnection(verified bool) db.GetEnabledSAMLConnectionBySlugRow {
+	connection := db.GetEnabledSAMLConnectionBySlugRow{
+		ID:          pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Name:        "Acme",
+		Slug:        "acme",
+		EmailDomain: "acme.example",
+	}
+	if verified {
+		connection.DomainVerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	return connection
+}
+
+func TestSAMLResponseMustAnswerOurRequest(t *testing.T) {
+	s := newTestSAML(t)
+	session := &saml.Session{NameID: "u-1", UserEmail: "ada@acme.example", UserCommonName: "Ada Lovelace"}
+
+	if _, err := s.parse(s.acsRequest(t, "id-ours", session), false, "id-ours"); err != nil {
+		t.Errorf("response to our request was rejected: %v", err)
+	}
+	if _, err := s.parse(s.acsRequest(t, "id-someone-elses", session), false, "id-ours"); err == nil {
+		t.Error("response to another request was accepted")
+	}
+	if _, err := s.parse(s.acsRequest(t, "", session), false, ""); !errors.Is(err, errSAMLUnsolicited) {
+		t.Errorf("IdP-initiated response while IdP-initiated login is off: got %v, want errSAMLUnsolicited", err)
+	}
+	if _, err := s.parse(s.acsRequest(t, "", session), true, ""); err != nil {
+		t.Errorf("IdP-initiated response was rejected while IdP-initiated login is on: %v", err)
+	}
+}
+
+func TestSSOIdentity(t *testing.T) {
+	s := newTestSAML(t)
+	assertionFor := func(session *saml.Session) *saml.Assertion {
+		t.Helper()
+		assertion, err := s.parse(s.acsRequest(t, "id-1", session), false, "id-1")
+		if err != nil {
+			t.Fatalf("ParseResponse: %v", err)
+		}
+		return assertion
+	}
+
+	assertion := assertionFor(&saml.Session{NameID: "u-1", NameIDFormat: string(saml.PersistentNameIDFormat), UserEmail: "Ada@acme.example", UserCommonName: "Ada Lovelace"})
+	identity, message := ssoIdentity(testSSOConnection(true), assertion)
+	if message != "" {
+		t.Fatalf("refused: %s", message)
+	}
+	if identity.Provider != "saml:acme" || identity.Subject != "u-1" || identity.Email != "Ada@acme.example" {
+		t.Errorf("identity = %+v, want saml:acme u-1 Ada@acme.example", identity)
+	}
+	if name := samlAttribute(assertion, "", samlNameAttributes); name != "Ada Lovelace" {
+		t.Errorf("name = %q, want Ada Lovelace", name)
+	}
+
+	transient := assertionFor(&saml.Session{NameID: "random-123", NameIDFormat: string(saml.TransientNameIDFormat), UserEmail: "Ada@acme.example"})
+	if identity, _ := ssoIdentity(testSSOConnection(true), transient); identity.Subject != "ada@acme.example" {
+		t.Errorf("transient subject = %q, want the lower-cased email", identity.Subject)
+	}
+
+	if _, message := ssoIdentity(testSSOConnection(false), assertion); message == "" {
+		t.Error("login accepted before the organization's domain was verified")
+	}
+	other := assertionFor(&saml.Session{NameID: "u-2", UserEmail: "eve@evil.example"})
+	if _, message := ssoIdentity(testSSOConnection(true), other); message == "" {
+		t.Error("login accepted for an address outside the organization's domain")
+	}
+	noEmail := assertionFor(&saml.Session{NameID: "u-3"})
+	if _, message := ssoIdentity(testSSOConnection(true), noEmail); message == "" {
+		t.Error("login accepted without an email address")
+	}
+}
+
+func TestSSOLinkRefusal(t *testing.T) {
+	connection := testSSOConnection(true)
+	otherOrg := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+
+	tests := []struct {
+		name     string
+		existing db.GetSSOLinkCandidateRow
+		linked   bool
+	}{
+		{"recruiter in the organization", db.GetSSOLinkCandidateRow{Role: RoleRecruiter, OrganizationID: connection.ID}, true},
+		{"recruiter in another organization", db.GetSSOLinkCandidateRow{Role: RoleRecruiter, OrganizationID: otherOrg}, false},
+		{"recruiter without an organization", db.GetSSOLinkCandidateRow{Role: RoleRecruiter}, false},
+		{"applicant", db.GetSSOLinkCandidateRow{Role: RoleApplicant}, false},
+		{"admin in the organization", db.GetSSOLinkCandidateRow{Role: RoleAdmin, OrganizationID: connection.ID}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := ssoLinkRefusal(connection, tt.existing)
+			if linked := message == ""; linked != tt.linked {
+				t.Errorf("linked = %v (%q), want %v", linked, message, tt.linked)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// currentUserKey caches the nav's view of the logged-in user in the request
+// context, so it is looked up at most once per request.
+const currentUserKey = "currentUser"
+
+var templateFuncs = template.FuncMap{
+	"uuid": func(id pgtype.UUID) string {
+		if !id.Valid {
+			return ""
+		}
+		return uuid.UUID(id.Bytes).String()
+	},
+	"timestamp":      formatTimestamp,
+	"dateTimeLocal":  formatDateTimeLocal,
+	"jobStatusLabel": func(status string) string { return jobStatusLabels[status] },
+	"jobSchedule":    jobScheduleText,
+	"careersURL":     careersURL,
+	"salaryRange":    salaryRangeText,
+	"lines":          func(s string) []string { return strings.Split(s, "\n") },
+	"percent":        percent,
+	"hoursText":      hoursText,
+	"daysText":       daysText,
+	"bar":            bar,
+	"stars":          stars,
+	"salary": func(n pgtype.Numeric) string {
+		if amount, ok := numericToDecimal(n); ok {
+			return amount.String()
+		}
+		return ""
+	},
+}
+
+// loadTemplates parses the embedded page templates. Pages share the layout,
+// nav and partials defined in templates/layout.html.
+func loadTemplates() (*template.Template, error) {
+	return template.New("").Funcs(templateFuncs).ParseFS(templateFS, "templates/*.html")
+}
+
+// navData is what the layout needs to show the links for the visitor's role.
+type navData struct {
+	LoggedIn bool
+	Name     string
+	Role     string
+}
+
+// page is the data every template is executed with. Data holds the page's own
+// values, including the CSRF token when the page has a form.
+type page struct {
+	Title string
+	Head  pageHead
+	Nav   navData
+	Data  any
+}
+
+// pageHead holds the optional metadata public pages put in <head>.
+type pageHead struct {
+	Description string
+	Canonical   string
+	Feeds       bool // Link the open positions feeds
+	JSONLD      any  // Encoded into an application/ld+json script
+}
+
+type errorPage struct {
+	Message   string
+	BackURL   string // Where to go from here; the home page if empty
+	BackLabel string
+}
+
+func (app *App) navFor(c *gin.Context) navData {
+	if cached, ok := c.Get(currentUserKey); ok {
+		if user, ok := cached.(navData); ok {
+			return user
+		}
+	}
+	userID, ok := sessions.Default(c).Get(sessionUserKey).(pgtype.UUID)
+	if !ok || !userID.Valid {
+		return navData{}
+	}
+	user, err := app.db.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Printf("Nav: Failed to get user %s: %v\n", userID.String(), err)
+		return navData{}
+	}
+	nav := navData{LoggedIn: true, Name: user.Name, Role: user.Role}
+	c.Set(currentUserKey, nav)
+	return nav
+}
+
+// render executes the named page template inside the shared layout.
+func (app *App) render(c *gin.Context, status int, name, title string, data any) {
+	app.renderPage(c, status, name, page{Title: title, Data: data})
+}
+
+// renderPage is render for pages that also set p.Head.
+func (app *App) renderPage(c *gin.Context, status int, name string, p page) {
+	p.Nav = app.navFor(c)
+	c.HTML(status, name, p)
+}
+
+func (app *App) renderError(c *gin.Context, status int, message string) {
+	app.renderErrorPage(c, status, errorPage{Message: message})
+}
+
+// renderErrorBack is renderError with a link back to where the user can fix
+// the problem.
+func (app *App) renderErrorBack(c *gin.Context, status int, message, backURL, backLabel string) {
+	app.renderErrorPage(c, status, errorPage{Message: message, BackURL: backURL, BackLabel: backLabel})
+}
+
+func (app *App) renderErrorPage(c *gin.Context, status int, data errorPage) {
+	c.HTML(status, "error.html", page{
+		Title: http.StatusText(status),
+		Nav:   app.navFor(c),
+		Data:  data,
+	})
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod                = 30 * time.Second
+	twoFactorChallengeTimeout = 10 * time.Minute
+	maxTwoFactorAttempts      = 5 // Incorrect codes in a row before the account is locked
+	twoFactorLockout          = 15 * time.Minute
+	recoveryCodeCount         = 10
+)
+
+// pendingTwoFactor is kept in the session between the first sign-in step and
+// the authenticator code. The user is not signed in until the code checks out.
+type pendingTwoFactor struct {
+	UserID  pgtype.UUID
+	Started time.Time
+	Enroll  bool // Two-factor authentication is required but not yet set up
+}
+
+// twoFactorRole reports whether accounts with the role can use two-factor
+// authentication; these are the roles that see applicants' personal data.
+func twoFactorRole(role string) bool {
+	return role == RoleRecruiter || role == RoleAdmin
+}
+
+// startTwoFactor holds the login at the second step for accounts that have
+// two-factor authentication, or must set it up first.
+func (app *App) startTwoFactor(c *gin.Context, userID pgtype.UUID, enroll bool) {
+	session := sessions.Default(c)
+	session.Set(sessionPendingTwoFactorKey, pendingTwoFactor{UserID: userID, Started: time.Now(), Enroll: enroll})
+	session.Delete(sessionTOTPSetupKey)
+	if err := session.Save(); err != nil {
+		fmt.Printf("Two-Factor: Failed to save pending login for user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to log in.")
+		return
+	}
+	if enroll {
+		c.Redirect(http.StatusSeeOther, "/auth/2fa/setup")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/auth/2fa")
+}
+
+// pendingTwoFactorLogin returns the login waiting for its second step. It
+// sends the user back to the login page when there is none or it has expired.
+func pendingTwoFactorLogin(c *gin.Context, enroll bool) (pendingTwoFactor, bool) {
+	session := sessions.Default(c)
+	pending, ok := session.Get(sessionPendingTwoFactorKey).(pendingTwoFactor)
+	if !ok || !pending.UserID.Valid || pending.Enroll != enroll {
+		c.Redirect(http.StatusSeeOther, "/auth/login")
+		return pendingTwoFactor{}, false
+	}
+	if time.Since(pending.Started) > twoFactorChallengeTimeout {
+		session.Delete(sessionPendingTwoFactorKey)
+		session.Delete(sessionTOTPSetupKey)
+		if err := session.Save(); err != nil {
+			fmt.Printf("Two-Factor: Failed to clear expired login: %v\n", err)
+		}
+		c.Redirect(http.StatusSeeOther, "/auth/login?error="+url.QueryEscape("That sign-in took too long. Please sign in again."))
+		return pendingTwoFactor{}, false
+	}
+	return pending, true
+}
+
+// matchTOTP checks a code against the secret, allowing one period of clock
+// drift either way, and returns the time step it belongs to.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	if len(code) != 6 {
+		return 0, false
+	}
+	for _, drift := range []time.Duration{0, -totpPeriod, totpPeriod} {
+		at := now.Add(drift)
+		expected, err := totp.GenerateCode(secret, at)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return at.Unix() / int64(totpPeriod/time.Second), true
+		}
+	}
+	return 0, false
+}
+
+// verifyTOTP accepts a code for the user's enabled authenticator. Each code is
+// accepted once.
+func (app *App) verifyTOTP(ctx context.Context, userID pgtype.UUID, secret, code string) bool {
+	step, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	used, err := app.db.UseTOTPStep(ctx, db.UseTOTPStepParams{ID: userID, Step: step})
+	if err != nil {
+		fmt.Printf("Two-Factor: Failed to record code use for user %s: %v\n", userID.String(), err)
+		return false
+	}
+	return used > 0
+}
+
+// normalizeRecoveryCode lets people type recovery codes without the dash and
+// in either case.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func (app *App) useRecoveryCode(ctx context.Context, userID pgtype.UUID, code string) bool {
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false
+	}
+	used, err := app.db.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{UserID: userID, CodeHash: hashToken(normalized)})
+	if err != nil {
+		fmt.Printf("Two-Factor: Failed to use recovery code for user %s: %v\n", userID.String(), err)
+		return false
+	}
+	if used > 0 {
+		fmt.Printf("Two-Factor: User %s signed in with a recovery code.\n", userID.String())
+	}
+	return used > 0
+}
+
+// errTwoFactorLocked means the account had too many incorrect codes and is not
+// accepting any until its lockout ends.
+var errTwoFactorLocked = errors.New("two-factor authentication is locked")
+
+// checkTwoFactorCode accepts the user's authenticator code, or with
+// allowRecovery a recovery code. Incorrect codes are counted on the account
+// rather than the session, so signing in again does not reset the count; after
+// maxTwoFactorAttempts in a row no code is accepted for twoFactorLockout.
+func (app *App) checkTwoFactorCode(ctx context.Context, user db.GetUserTwoFactorRow, code string, allowRecovery bool) (bool, error) {
+	if user.TotpLockedUntil.Valid && time.Now().Before(user.TotpLockedUntil.Time) {
+		return false, errTwoFactorLocked
+	}
+	if user.TotpEnabledAt.Valid && (app.verifyTOTP(ctx, user.ID, user.TotpSecret.String, code) || (allowRecovery && app.useRecoveryCode(ctx, user.ID, code))) {
+		if err := app.db.ResetTwoFactorFailures(ctx, user.ID); err != nil {
+			fmt.Printf("Two-Factor: Failed to reset incorrect code count for user %s: %v\n", user.ID.String(), err)
+		}
+		return true, nil
+	}
+
+	lockedUntil, err := app.db.RecordTwoFactorFailure(ctx, db.RecordTwoFactorFailureParams{
+		ID:          user.ID,
+		MaxAttempts: maxTwoFactorAttempts,
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(twoFactorLockout), Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+	if lockedUntil.Valid && time.Now().Before(lockedUntil.Time) {
+		fmt.Printf("Two-Factor: Too many incorrect codes for user %s; locked until %s.\n", user.ID.String(), lockedUntil.Time.Format(time.RFC3339))
+		return false, errTwoFactorLocked
+	}
+	return false, nil
+}
+
+// twoFactorLockedMessage is shown while the account is not accepting codes.
+var twoFactorLockedMessage = fmt.Sprintf("Too many incorrect codes. Please try again in %d minutes.", int(twoFactorLockout.Minutes()))
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// enableTwoFactor turns on the authenticator whose code was just confirmed at
+// step and replaces the user's recovery codes, returning the new ones. Passing
+// an empty secret only replaces the recovery codes.
+func (app *App) enableTwoFactor(ctx context.Context, userID pgtype.UUID, secret string, step int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	tx, err := app.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	q := app.db.WithTx(tx)
+
+	if secret != "" {
+		if err := q.EnableUserTOTP(ctx, db.EnableUserTOTPParams{ID: userID, TotpSecret: secret, TotpLastStep: step}); err != nil {
+			return nil, err
+		}
+	}
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if err := q.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))}); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// setupKey returns the authenticator key being set up in this session,
+// creating one on first use. It is only saved to the account once the user
+// confirms it with a code.
+func (app *App) setupKey(c *gin.Context, email string) (*otp.Key, error) {
+	session := sessions.Default(c)
+	if keyURL, ok := session.Get(sessionTOTPSetupKey).(string); ok && keyURL != "" {
+		return otp.NewKeyFromURL(keyURL)
+	}
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: app.site.CompanyName, AccountName: email})
+	if err != nil {
+		return nil, err
+	}
+	session.Set(sessionTOTPSetupKey, key.URL())
+	if err := session.Save(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// confirmSetupKey checks a code against the key being set up and returns the
+// secret and time step to enable.
+func confirmSetupKey(c *gin.Context, code string) (string, int64, bool) {
+	keyURL, _ := sessions.Default(c).Get(sessionTOTPSetupKey).(string)
+	key, err := otp.NewKeyFromURL(keyURL)
+	if keyURL == "" || err != nil {
+		return "", 0, false
+	}
+	step, ok := matchTOTP(key.Secret(), code, time.Now())
+	return key.Secret(), step, ok
+}
+
+// totpSetupData is what the setup form needs: a QR code for the key, the
+// secret for typing in by hand, and where to post the confirmation code.
+func (app *App) totpSetupData(c *gin.Context, email, action string) (gin.H, error) {
+	key, err := app.setupKey(c, email)
+	if err != nil {
+		return nil, err
+	}
+	qrImage, err := key.Image(200, 200)
+	if err != nil {
+		return nil, err
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, qrImage); err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"QRCode": template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(qr.Bytes())),
+		"Secret": key.Secret(),
+		"Action": action,
+		"CSRF":   csrfToken(c),
+		"Error":  c.Query("error"),
+	}, nil
+}
+
+func (app *App) getTwoFactorHandler(c *gin.Context) {
+	if _, ok := pendingTwoFactorLogin(c, false); !ok {
+		return
+	}
+	app.render(c, http.StatusOK, "two_factor.html", "Two-Factor Authentication", gin.H{
+		"CSRF":  csrfToken(c),
+		"Error": c.Query("error"),
+	})
+}
+
+func (app *App) postTwoFactorHandler(c *gin.Context) {
+	pending, ok := pendingTwoFactorLogin(c, false)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	user, err := app.db.GetUserTwoFactor(ctx, pending.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Redirect(http.StatusSeeOther, "/auth/login?error="+url.QueryEscape("Please sign in again."))
+		return
+	}
+	if err != nil {
+		fmt.Printf("Two-Factor: DB error loading user %s: %v\n", pending.UserID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to log in.")
+		return
+	}
+
+	verified, err := app.checkTwoFactorCode(ctx, user, strings.TrimSpace(c.PostForm("code")), true)
+	if errors.Is(err, errTwoFactorLocked) {
+		fmt.Printf("Two-Factor: Refused code for locked user %s from %s.\n", pending.UserID.String(), c.ClientIP())
+		session := sessions.Default(c)
+		session.Delete(sessionPendingTwoFactorKey)
+		if err := session.Save(); err != nil {
+			fmt.Printf("Two-Factor: Failed to clear pending login: %v\n", err)
+		}
+		c.Redirect(http.StatusSeeOther, "/auth/login?error="+url.QueryEscape(twoFactorLockedMessage))
+		return
+	}
+	if err != nil {
+		fmt.Printf("Two-Factor: Failed to record incorrect code for user %s: %v\n", pending.UserID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to log in.")
+		return
+	}
+	if !verified {
+		c.Redirect(http.StatusSeeOther, "/auth/2fa?error="+url.QueryEscape("That code is not correct."))
+		return
+	}
+	if returnTo, ok := app.signIn(c, pending.UserID); ok {
+		c.Redirect(http.StatusSeeOther, returnTo)
+	}
+}
+
+func (app *App) getTwoFactorSetupHandler(c *gin.Context) {
+	pending, ok := pendingTwoFactorLogin(c, true)
+	if !ok {
+		return
+	}
+	user, err := app.db.GetUserTwoFactor(c.Request.Context(), pending.UserID)
+	if err != nil {
+		fmt.Printf("Two-Factor Setup: DB error loading user %s: %v\n", pending.UserID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load two-factor setup.")
+		return
+	}
+	setup, err := app.totpSetupData(c, user.Email, "/auth/2fa/setup")
+	if err != nil {
+		fmt.Printf("Two-Factor Setup: Failed to create key for user %s: %v\n", pending.UserID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load two-factor setup.")
+		return
+	}
+	setup["Required"] = true
+	app.render(c, http.StatusOK, "two_factor_setup.html", "Set Up Two-Factor Authentication", setup)
+}
+
+func (app *App) postTwoFactorSetupHandler(c *gin.Context) {
+	pending, ok := pendingTwoFactorLogin(c, true)
+	if !ok {
+		return
+	}
+	secret, step, ok := confirmSetupKey(c, strings.TrimSpace(c.PostForm("code")))
+	if !ok {
+		c.Redirect(http.StatusSeeOther, "/auth/2fa/setup?error="+url.QueryEscape("That code is not correct. Check the time on your device and try again."))
+		return
+	}
+	codes, err := app.enableTwoFactor(c.Request.Context(), pending.UserID, secret, step)
+	if err != nil {
+		fmt.Printf("Two-Factor Setup: Failed to enable for user %s: %v\n", pending.UserID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to enable two-factor authentication.")
+		return
+	}
+	fmt.Printf("Two-Factor: User %s set up an authenticator at sign-in.\n", pending.UserID.String())
+
+	returnTo, ok := app.signIn(c, pending.UserID)
+	if !ok {
+		return
+	}
+	app.render(c, http.StatusOK, "recovery_codes.html", "Recovery Codes", gin.H{
+		"Codes":       codes,
+		"ContinueURL": returnTo,
+	})
+}
+
+// loadTwoFactorUser loads the signed-in user for the account 2FA pages, which
+// only recruiters and admins have.
+func (app *App) loadTwoFactorUser(c *gin.Context) (db.GetUserTwoFactorRow, bool) {
+	userID, ok := app.signedInUserID(c)
+	if !ok {
+		return db.GetUserTwoFactorRow{}, false
+	}
+	user, err := app.db.GetUserTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Printf("Two-Factor: DB error loading user %s: %v\n", userID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load two-factor settings.")
+		return db.GetUserTwoFactorRow{}, false
+	}
+	if !twoFactorRole(user.Role) {
+		app.renderError(c, http.StatusForbidden, "Two-factor authentication is available to recruiter and admin accounts.")
+		return db.GetUserTwoFactorRow{}, false
+	}
+	return user, true
+}
+
+func (app *App) getAccountTwoFactorHandler(c *gin.Context) {
+	user, ok := app.loadTwoFactorUser(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	data := gin.H{
+		"Enabled":   user.TotpEnabledAt.Valid,
+		"EnabledAt": formatTimestamp(user.TotpEnabledAt, ""),
+		"Required":  app.requireTwoFactor,
+		"CSRF":      csrfToken(c),
+		"Error":     c.Query("error"),
+	}
+	if user.TotpEnabledAt.Valid {
+		remaining, err := app.db.CountUnusedRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			fmt.Printf("Two-Factor: Failed to count recovery codes for user %s: %v\n", user.ID.String(), err)
+		}
+		data["RecoveryCodesLeft"] = remaining
+	} else {
+		setup, err := app.totpSetupData(c, user.Email, "/account/2fa/enable")
+		if err != nil {
+			fmt.Printf("Two-Factor: Failed to create key for user %s: %v\n", user.ID.String(), err)
+			c.String(http.StatusInternalServerError, "Failed to load two-factor setup.")
+			return
+		}
+		data["Setup"] = setup
+	}
+	app.render(c, http.StatusOK, "account_two_factor.html", "Two-Factor Authentication", data)
+}
+
+func (app *App) postEnableTwoFactorHandler(c *gin.Context) {
+	user, ok := app.loadTwoFactorUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		c.Redirect(http.StatusSeeOther, "/account/2fa")
+		return
+	}
+	secret, step, ok := confirmSetupKey(c, strings.TrimSpace(c.PostForm("code")))
+	if !ok {
+		c.Redirect(http.StatusSeeOther, "/account/2fa?error="+url.QueryEscape("That code is not correct. Check the time on your device and try again."))
+		return
+	}
+	ctx := c.Request.Context()
+	codes, err := app.enableTwoFactor(ctx, user.ID, secret, step)
+	if err != nil {
+		fmt.Printf("Two-Factor: Failed to enable for user %s: %v\n", user.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to enable two-factor authentication.")
+		return
+	}
+	session := sessions.Default(c)
+	session.Delete(sessionTOTPSetupKey)
+	if err := session.Save(); err != nil {
+		fmt.Printf("Two-Factor: Failed to clear setup key: %v\n", err)
+	}
+	// Sessions signed in with only the first factor should not outlive this.
+	if current := app.sessionStore.currentTokenHash(c.Request); current != nil {
+		if _, err := app.db.DeleteOtherUserSessions(ctx, db.DeleteOtherUserSessionsParams{UserID: user.ID, TokenHash: current}); err != nil {
+			fmt.Printf("Two-Factor: Failed to sign out other sessions of user %s: %v\n", user.ID.String(), err)
+		}
+	}
+	fmt.Printf("Two-Factor: User %s enabled two-factor authentication.\n", user.ID.String())
+
+	app.render(c, http.StatusOK, "recovery_codes.html", "Recovery Codes", gin.H{
+		"Codes":       codes,
+		"ContinueURL": "/account/2fa",
+	})
+}
+
+// confirmAccountTwoFactor checks the authenticator code that account changes
+// ask for, redirecting back to the settings page when it is not accepted.
+func (app *App) confirmAccountTwoFactor(c *gin.Context, user db.GetUserTwoFactorRow) bool {
+	verified, err := app.checkTwoFactorCode(c.Request.Context(), user, strings.TrimSpace(c.PostForm("code")), false)
+	switch {
+	case errors.Is(err, errTwoFactorLocked):
+		c.Redirect(http.StatusSeeOther, "/account/2fa?error="+url.QueryEscape(twoFactorLockedMessage))
+		return false
+	case err != nil:
+		fmt.Printf("Two-Factor: Failed to record incorrect code for user %s: %v\n", user.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to check the code.")
+		return false
+	case !verified:
+		c.Redirect(http.StatusSeeOther, "/account/2fa?error="+url.QueryEscape("That code is not correct."))
+		return false
+	}
+	return true
+}
+
+func (app *App) postRecoveryCodesHandler(c *gin.Context) {
+	user, ok := app.loadTwoFactorUser(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if !app.confirmAccountTwoFactor(c, user) {
+		return
+	}
+	codes, err := app.enableTwoFactor(ctx, user.ID, "", 0)
+	if err != nil {
+		fmt.Printf("Two-Factor: Failed to replace recovery codes for user %s: %v\n", user.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to create recovery codes.")
+		return
+	}
+	fmt.Printf("Two-Factor: User %s replaced their recovery codes.\n", user.ID.String())
+
+	app.render(c, http.StatusOK, "recovery_codes.html", "Recovery Codes", gin.H{
+		"Codes":       codes,
+		"ContinueURL": "/account/2fa",
+	})
+}
+
+func (app *App) postDisableTwoFactorHandler(c *gin.Context) {
+	user, ok := app.loadTwoFactorUser(c)
+	if !ok {
+		return
+	}
+	if app.requireTwoFactor {
+		c.Redirect(http.StatusSeeOther, "/account/2fa?error="+url.QueryEscape("Two-factor authentication is required for your account."))
+		return
+	}
+	ctx := c.Request.Context()
+	if !app.confirmAccountTwoFactor(c, user) {
+		return
+	}
+	if err := app.disableTwoFactor(ctx, user.ID); err != nil {
+		fmt.Printf("Two-Factor: Failed to disable for user %s: %v\n", user.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to disable two-factor authentication.")
+		return
+	}
+	fmt.Printf("Two-Factor: User %s disabled two-factor authentication.\n", user.ID.String())
+	c.Redirect(http.StatusSeeOther, "/account/2fa")
+}
+
+func (app *App) disableTwoFactor(ctx context.Context, userID pgtype.UUID) error {
+	if err := app.db.DisableUserTOTP(ctx, userID); err != nil {
+		return err
+	}
+	return app.db.DeleteRecoveryCodes(ctx, userID)
+}
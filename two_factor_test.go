@@ -0,0 +1,242 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// fakeTwoFactorDB answers the queries the two-factor checks run for a single
+// user, the way the SQL in db/query/two_factor.sql does.
+type fakeTwoFactorDB struct {
+	lastStep      *int64
+	failures      int32
+	lockedUntil   pgtype.Timestamptz
+	recoveryCodes map[string]bool // Code hash to whether it has been used
+}
+
+func queryName(sql string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(sql, "-- name: "), " ")
+	return name
+}
+
+func updated(rows int) pgconn.CommandTag {
+	return pgconn.NewCommandTag(fmt.Sprintf("UPDATE %d", rows))
+}
+
+func (f *fakeTwoFactorDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	switch queryName(sql) {
+	case "UseTOTPStep":
+		step := args[0].(int64)
+		if f.lastStep != nil && *f.lastStep >= step {
+			return updated(0), nil
+		}
+		f.lastStep = &step
+		return updated(1), nil
+	case "UseRecoveryCode":
+		hash := string(args[1].([]byte))
+		if used, ok := f.recoveryCodes[hash]; !ok || used {
+			return updated(0), nil
+		}
+		f.recoveryCodes[hash] = true
+		return updated(1), nil
+	case "ResetTwoFactorFailures":
+		f.failures = 0
+		f.lockedUntil = pgtype.Timestamptz{}
+		return updated(1), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected query %s", queryName(sql))
+}
+
+func (f *fakeTwoFactorDB) Query(_ context.Context, sql string, _ ...interface{}) (pgx.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", queryName(sql))
+}
+
+func (f *fakeTwoFactorDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	if queryName(sql) != "RecordTwoFactorFailure" {
+		return fakeRow{err: fmt.Errorf("unexpected query %s", queryName(sql))}
+	}
+	f.failures++
+	if f.failures >= args[0].(int32) {
+		f.failures = 0
+		f.lockedUntil = args[1].(pgtype.Timestamptz)
+	}
+	return fakeRow{lockedUntil: f.lockedUntil}
+}
+
+type fakeRow struct {
+	lockedUntil pgtype.Timestamptz
+	err         error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*pgtype.Timestamptz) = r.lockedUntil
+	return nil
+}
+
+// newTestTwoFactorApp returns an app backed by a fake database and a user with
+// an enabled authenticator.
+func newTestTwoFactorApp() (*App, *fakeTwoFactorDB, db.GetUserTwoFactorRow) {
+	fake := &fakeTwoFactorDB{recoveryCodes: map[string]bool{}}
+	user := db.GetUserTwoFactorRow{
+		ID:            pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Role:          RoleRecruiter,
+		TotpSecret:    pgtype.Text{String: testTOTPSecret, Valid: true},
+		TotpEnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	return &App{db: db.New(fake)}, fake, user
+}
+
+func totpCode(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCode(testTOTPSecret, at)
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	return code
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 10, 0, time.UTC)
+	step := now.Unix() / 30
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current period", totpCode(t, now), step, true},
+		{"previous period", totpCode(t, now.Add(-totpPeriod)), step - 1, true},
+		{"next period", totpCode(t, now.Add(totpPeriod)), step + 1, true},
+		{"two periods ago", totpCode(t, now.Add(-2*totpPeriod)), 0, false},
+		{"two periods ahead", totpCode(t, now.Add(2*totpPeriod)), 0, false},
+		{"too short", totpCode(t, now)[:5], 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := matchTOTP(testTOTPSecret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("matchTOTP(%q) = %d, %v; want %d, %v", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTPRejectsReplay(t *testing.T) {
+	app, _, user := newTestTwoFactorApp()
+	ctx := context.Background()
+	now := time.Now()
+
+	if !app.verifyTOTP(ctx, user.ID, testTOTPSecret, totpCode(t, now)) {
+		t.Fatal("current code was rejected")
+	}
+	if app.verifyTOTP(ctx, user.ID, testTOTPSecret, totpCode(t, now)) {
+		t.Error("current code was accepted a second time")
+	}
+	if app.verifyTOTP(ctx, user.ID, testTOTPSecret, totpCode(t, now.Add(-totpPeriod))) {
+		t.Error("code from before the last used one was accepted")
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"abcde-fghij", "abcdefghij"},
+		{"ABCDE-FGHIJ", "abcdefghij"},
+		{"abcdefghij", "abcdefghij"},
+		{" abcde fghij ", "abcdefghij"},
+		{"-", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeRecoveryCode(tt.code); got != tt.want {
+			t.Errorf("normalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	app, fake, user := newTestTwoFactorApp()
+	ctx := context.Background()
+	fake.recoveryCodes[string(hashToken("abcdefghij"))] = false
+
+	if app.useRecoveryCode(ctx, user.ID, "-") {
+		t.Error("empty recovery code was accepted")
+	}
+	if app.useRecoveryCode(ctx, user.ID, "zzzzz-zzzzz") {
+		t.Error("unknown recovery code was accepted")
+	}
+	if !app.useRecoveryCode(ctx, user.ID, "ABCDE-FGHIJ") {
+		t.Fatal("recovery code was rejected")
+	}
+	if app.useRecoveryCode(ctx, user.ID, "abcde-fghij") {
+		t.Error("recovery code was accepted a second time")
+	}
+}
+
+func TestCheckTwoFactorCodeLocksAfterMaxAttempts(t *testing.T) {
+	app, fake, user := newTestTwoFactorApp()
+	ctx := context.Background()
+
+	for i := 1; i < maxTwoFactorAttempts; i++ {
+		if ok, err := app.checkTwoFactorCode(ctx, user, "000000", false); ok || err != nil {
+			t.Fatalf("incorrect code %d: got %v, %v; want false, nil", i, ok, err)
+		}
+	}
+	if _, err := app.checkTwoFactorCode(ctx, user, "000000", false); !errors.Is(err, errTwoFactorLocked) {
+		t.Fatalf("incorrect code %d: got %v, want errTwoFactorLocked", maxTwoFactorAttempts, err)
+	}
+	if !fake.lockedUntil.Valid || time.Until(fake.lockedUntil.Time) < twoFactorLockout-time.Minute {
+		t.Errorf("locked until %v, want about %v from now", fake.lockedUntil, twoFactorLockout)
+	}
+
+	user.TotpLockedUntil = fake.lockedUntil
+	if ok, err := app.checkTwoFactorCode(ctx, user, totpCode(t, time.Now()), false); ok || !errors.Is(err, errTwoFactorLocked) {
+		t.Errorf("correct code while locked: got %v, %v; want false, errTwoFactorLocked", ok, err)
+	}
+}
+
+func TestCheckTwoFactorCodeResetsOnSuccess(t *testing.T) {
+	app, fake, user := newTestTwoFactorApp()
+	ctx := context.Background()
+	fake.recoveryCodes[string(hashToken("abcdefghij"))] = false
+
+	for i := 1; i < maxTwoFactorAttempts; i++ {
+		app.checkTwoFactorCode(ctx, user, "000000", false)
+	}
+	if ok, err := app.checkTwoFactorCode(ctx, user, totpCode(t, time.Now()), false); !ok || err != nil {
+		t.Fatalf("correct code: got %v, %v; want true, nil", ok, err)
+	}
+	if fake.failures != 0 {
+		t.Errorf("incorrect code count after success = %d, want 0", fake.failures)
+	}
+
+	for i := 1; i < maxTwoFactorAttempts; i++ {
+		app.checkTwoFactorCode(ctx, user, "000000", false)
+	}
+	if ok, err := app.checkTwoFactorCode(ctx, user, "abcde-fghij", true); !ok || err != nil {
+		t.Fatalf("recovery code: got %v, %v; want true, nil", ok, err)
+	}
+	if fake.failures != 0 || fake.lockedUntil.Valid {
+		t.Errorf("after a recovery code: %d incorrect codes, locked until %v; want none", fake.failures, fake.lockedUntil)
+	}
+}
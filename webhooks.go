@@ -0,0 +1,571 @@
+package main
+
+import (
+	db "Recruitment-GO/internal/db"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	WebhookEventApplicationCreated       = "application.created"
+	WebhookEventApplicationStatusChanged = "application.status_changed"
+	WebhookEventInterviewScheduled       = "interview.scheduled"
+	WebhookEventJobPublished             = "job.published"
+
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+
+	webhookMaxAttempts  = 10
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+	webhookBatchSize    = 20
+	webhookTimeout      = 10 * time.Second
+	webhookPollInterval = 15 * time.Second
+)
+
+// webhookEvents lists the events endpoints can subscribe to, in display order.
+var webhookEvents = []struct{ Name, Description string }{
+	{WebhookEventApplicationCreated, "A candidate applies to one of your jobs"},
+	{WebhookEventApplicationStatusChanged, "An application moves to a new status, including hires"},
+	{WebhookEventInterviewScheduled, "An interview is requested"},
+	{WebhookEventJobPublished, "A job posting goes live"},
+}
+
+// webhookEventForAppEvent maps dashboard events to the webhook event sent for
+// them. Events without an entry are not sent to webhooks.
+var webhookEventForAppEvent = map[string]string{
+	EventApplicationCreated:       WebhookEventApplicationCreated,
+	EventApplicationStatusChanged: WebhookEventApplicationStatusChanged,
+	EventInterviewUpdated:         WebhookEventInterviewScheduled,
+}
+
+// webhookDispatcher sends queued deliveries. Deliveries live in the database,
+// so nothing is lost on restart and several instances can share the work.
+type webhookDispatcher struct {
+	client  *http.Client
+	wake    chan struct{}
+	devMode bool // Allow http:// endpoints and private addresses, for local testing
+}
+
+// newWebhookDispatcher returns a dispatcher whose client only connects to
+// public addresses and does not follow redirects, so recruiters cannot point
+// webhooks at services inside the network. The address is checked when the
+// connection is made, after DNS resolution, so a hostname cannot be switched
+// to a private address once the endpoint is saved.
+func newWebhookDispatcher(devMode bool) *webhookDispatcher {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !devMode {
+		dialer.Control = rejectNonPublicAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would make the connection on our behalf, past the address check
+	transport.DialContext = dialer.DialContext
+	return &webhookDispatcher{
+		client: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		wake:    make(chan struct{}, 1),
+		devMode: devMode,
+	}
+}
+
+// rejectNonPublicAddress is a net.Dialer Control function that refuses
+// loopback, private, link-local and other non-public addresses.
+func rejectNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("webhook address %s is not public", addr)
+	}
+	return nil
+}
+
+// checkWebhookURL reports why an endpoint URL cannot be used, or nil.
+// Endpoints must use https unless webhooks run in development mode.
+func (d *webhookDispatcher) checkWebhookURL(endpointURL string) error {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Host == "" {
+		return errors.New("webhook URL is not a full URL")
+	}
+	if parsed.Scheme == "https" || (d.devMode && parsed.Scheme == "http") {
+		return nil
+	}
+	return errors.New("webhook URL must use https://")
+}
+
+// notify wakes the worker so new deliveries go out without waiting for the
+// next poll.
+func (d *webhookDispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+type webhookPayload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type webhookApplicant struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type webhookApplicationData struct {
+	ApplicationID string            `json:"application_id"`
+	JobPostingID  string            `json:"job_posting_id"`
+	JobTitle      string            `json:"job_title"`
+	Status        string            `json:"status"`
+	AppliedAt     time.Time         `json:"applied_at"`
+	Applicant     webhookApplicant  `json:"applicant"`
+	Interview     *webhookInterview `json:"interview,omitempty"`
+}
+
+type webhookInterview struct {
+	Status      string `json:"status"`
+	Details     string `json:"details,omitempty"`
+	RequestedBy string `json:"requested_by"`
+}
+
+type webhookJobData struct {
+	JobPostingID string     `json:"job_posting_id"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	Location     string     `json:"location"`
+	SalaryMin    *string    `json:"salary_min"`
+	SalaryMax    *string    `json:"salary_max"`
+	Headcount    int32      `json:"headcount"`
+	PublishedAt  *time.Time `json:"published_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+// enqueueWebhook queues event for every active endpoint subscribed to it that
+// belongs to the recruiter's organization, or to the recruiter when they have
+// no organization.
+func (app *App) enqueueWebhook(ctx context.Context, recruiterID pgtype.UUID, event string, data any) {
+	payload, err := json.Marshal(webhookPayload{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		fmt.Printf("Webhooks: Failed to marshal %s payload: %v\n", event, err)
+		return
+	}
+	ids, err := app.db.EnqueueWebhookDeliveries(ctx, db.EnqueueWebhookDeliveriesParams{
+		RecruiterID: recruiterID,
+		Event:       event,
+		Payload:     payload,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Webhooks: Failed to queue %s for recruiter %s: %v\n", event, recruiterID.String(), err)
+		return
+	}
+	if len(ids) > 0 {
+		app.webhooks.notify()
+	}
+}
+
+// enqueueApplicationWebhook sends the webhook matching a dashboard event about
+// an application, if there is one.
+func (app *App) enqueueApplicationWebhook(ctx context.Context, eventType string, application db.GetApplicationByIDRow) {
+	event, ok := webhookEventForAppEvent[eventType]
+	if !ok {
+		return
+	}
+	data := webhookApplicationData{
+		ApplicationID: uuid.UUID(application.ID.Bytes).String(),
+		JobPostingID:  uuid.UUID(application.JobPostingID.Bytes).String(),
+		JobTitle:      application.JobTitle,
+		Status:        application.Status,
+		AppliedAt:     application.AppliedAt.Time.UTC(),
+		Applicant: webhookApplicant{
+			ID:    uuid.UUID(application.UserID.Bytes).String(),
+			Name:  application.ApplicantName,
+			Email: application.ApplicantEmail,
+		},
+	}
+	if event == WebhookEventInterviewScheduled {
+		interview, err := app.db.GetInterviewByApplicationID(ctx, application.ID)
+		if err == nil {
+			data.Interview = &webhookInterview{
+				Status:      interview.Status,
+				Details:     interview.ProposedDetails.String,
+				RequestedBy: interview.RequestedByName,
+			}
+		}
+	}
+	app.enqueueWebhook(ctx, application.RecruiterID, event, data)
+}
+
+// enqueueJobPublishedWebhook sends job.published for a posting that just went
+// live.
+func (app *App) enqueueJobPublishedWebhook(ctx context.Context, jobID pgtype.UUID) {
+	job, err := app.db.GetJobPostingByID(ctx, jobID)
+	if err != nil {
+		fmt.Printf("Webhooks: Failed to load job %s for %s: %v\n", jobID.String(), WebhookEventJobPublished, err)
+		return
+	}
+	jobURL := careersURL(job.Slug)
+	if app.site.BaseURL != "" {
+		jobURL = strings.TrimRight(app.site.BaseURL, "/") + jobURL
+	}
+	app.enqueueWebhook(ctx, job.RecruiterID, WebhookEventJobPublished, webhookJobData{
+		JobPostingID: uuid.UUID(job.ID.Bytes).String(),
+		Title:        job.Title,
+		URL:          jobURL,
+		Location:     jobLocationText(job.Location, job.Remote),
+		SalaryMin:    optionalNumeric(job.SalaryMin),
+		SalaryMax:    optionalNumeric(job.SalaryMax),
+		Headcount:    job.Headcount,
+		PublishedAt:  optionalTime(job.PublishedAt),
+		ExpiresAt:    optionalTime(job.ExpiresAt),
+	})
+}
+
+// webhookSignature signs "<timestamp>.<body>" with the endpoint secret.
+// Receivers recompute it to check the payload came from us and is fresh.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait before retrying after the given number of failed
+// attempts: 30s, 1m, 2m, ... capped at 6h.
+func webhookBackoff(attempts int32) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := int32(1); i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// runWebhookWorker sends due deliveries until ctx is cancelled, polling every
+// interval and whenever new deliveries are queued.
+func (app *App) runWebhookWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for app.deliverDueWebhooks(ctx) == webhookBatchSize {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-app.webhooks.wake:
+		}
+	}
+}
+
+// deliverDueWebhooks sends one batch of due deliveries and returns its size.
+func (app *App) deliverDueWebhooks(ctx context.Context) int {
+	deliveries, err := app.db.ClaimDueWebhookDeliveries(ctx, webhookBatchSize)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Webhooks: Failed to claim deliveries: %v\n", err)
+		return 0
+	}
+	for _, delivery := range deliveries {
+		app.deliverWebhook(ctx, delivery)
+	}
+	return len(deliveries)
+}
+
+func (app *App) deliverWebhook(ctx context.Context, delivery db.ClaimDueWebhookDeliveriesRow) {
+	deliveryID := uuid.UUID(delivery.ID.Bytes).String()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	statusCode, err := func() (int, error) {
+		if err := app.webhooks.checkWebhookURL(delivery.Url); err != nil {
+			return 0, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Recruitment-GO-Webhooks/1.0")
+		req.Header.Set("X-Webhook-Id", deliveryID)
+		req.Header.Set("X-Webhook-Event", delivery.Event)
+		req.Header.Set("X-Webhook-Timestamp", timestamp)
+		req.Header.Set("X-Webhook-Signature", webhookSignature(delivery.Secret, timestamp, delivery.Payload))
+
+		resp, err := app.webhooks.client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+		}
+		return resp.StatusCode, nil
+	}()
+
+	if err == nil {
+		if err := app.db.MarkWebhookDeliverySucceeded(ctx, db.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: pgtype.Int4{Int32: int32(statusCode), Valid: true},
+		}); err != nil {
+			fmt.Printf("Webhooks: Delivered %s but failed to record it: %v\n", deliveryID, err)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	status := WebhookDeliveryPending
+	if attempts >= webhookMaxAttempts {
+		status = WebhookDeliveryFailed
+	}
+	message := err.Error()
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	fmt.Printf("Webhooks: Delivery %s of %s failed (attempt %d/%d): %s\n", deliveryID, delivery.Event, attempts, webhookMaxAttempts, message)
+
+	if err := app.db.MarkWebhookDeliveryFailed(ctx, db.MarkWebhookDeliveryFailedParams{
+		ID:             delivery.ID,
+		Status:         status,
+		LastStatusCode: pgtype.Int4{Int32: int32(statusCode), Valid: statusCode != 0},
+		LastError:      pgtype.Text{String: message, Valid: true},
+		NextAttemptAt:  pgtype.Timestamptz{Time: time.Now().Add(webhookBackoff(attempts)), Valid: true},
+	}); err != nil {
+		fmt.Printf("Webhooks: Failed to record failed delivery %s: %v\n", deliveryID, err)
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// loadWebhookEndpoint loads the :endpointID endpoint if the recruiter manages
+// it. When it returns false the response has already been written.
+func (app *App) loadWebhookEndpoint(c *gin.Context, recruiterID pgtype.UUID) (db.WebhookEndpoint, bool) {
+	endpointUUID, err := uuid.Parse(c.Param("endpointID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid webhook ID.")
+		return db.WebhookEndpoint{}, false
+	}
+	endpoint, err := app.db.GetWebhookEndpoint(c.Request.Context(), db.GetWebhookEndpointParams{
+		ID:          pgtype.UUID{Bytes: endpointUUID, Valid: true},
+		RecruiterID: recruiterID,
+	})
+	if err != nil {
+		c.String(http.StatusNotFound, "Webhook not found.")
+		return db.WebhookEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+func (app *App) getWebhooksHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+
+	endpoints, err := app.db.ListWebhookEndpoints(c.Request.Context(), recruiter.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Webhooks GET: DB error listing endpoints for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to load webhooks.")
+		return
+	}
+
+	app.render(c, http.StatusOK, "webhooks.html", "Webhooks", gin.H{
+		"Endpoints": endpoints,
+		"Events":    webhookEvents,
+		"Error":     c.Query("error"),
+		"CSRF":      csrfToken(c),
+	})
+}
+
+func (app *App) postWebhookHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	fail := func(message string) {
+		c.Redirect(http.StatusSeeOther, "/recruiter/webhooks?error="+url.QueryEscape(message))
+	}
+
+	endpointURL := strings.TrimSpace(c.PostForm("url"))
+	if err := app.webhooks.checkWebhookURL(endpointURL); err != nil {
+		fail("Enter a full https:// URL.")
+		return
+	}
+
+	known := make(map[string]bool, len(webhookEvents))
+	for _, event := range webhookEvents {
+		known[event.Name] = true
+	}
+	var events []string
+	for _, event := range c.PostFormArray("events") {
+		if known[event] {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		fail("Choose at least one event.")
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		fmt.Printf("Webhooks POST: Failed to generate secret: %v\n", err)
+		c.String(http.StatusInternalServerError, "Failed to create webhook.")
+		return
+	}
+	endpointID, err := app.db.CreateWebhookEndpoint(c.Request.Context(), db.CreateWebhookEndpointParams{
+		RecruiterID: recruiter.ID,
+		Url:         endpointURL,
+		Secret:      secret,
+		Events:      events,
+	})
+	if err != nil {
+		fmt.Printf("Webhooks POST: DB error creating endpoint for recruiter %s: %v\n", recruiter.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to create webhook.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/recruiter/webhooks/"+uuid.UUID(endpointID.Bytes).String())
+}
+
+func (app *App) postWebhookActiveHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	endpoint, ok := app.loadWebhookEndpoint(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	err := app.db.SetWebhookEndpointActive(c.Request.Context(), db.SetWebhookEndpointActiveParams{
+		ID:          endpoint.ID,
+		RecruiterID: recruiter.ID,
+		Active:      c.PostForm("active") == "true",
+	})
+	if err != nil {
+		fmt.Printf("Webhooks POST: DB error updating endpoint %s: %v\n", endpoint.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to update webhook.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/recruiter/webhooks")
+}
+
+func (app *App) deleteWebhookHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	endpoint, ok := app.loadWebhookEndpoint(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	err := app.db.DeleteWebhookEndpoint(c.Request.Context(), db.DeleteWebhookEndpointParams{
+		ID:          endpoint.ID,
+		RecruiterID: recruiter.ID,
+	})
+	if err != nil {
+		fmt.Printf("Webhooks POST: DB error deleting endpoint %s: %v\n", endpoint.ID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to delete webhook.")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/recruiter/webhooks")
+}
+
+func (app *App) getWebhookDeliveriesHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	endpoint, ok := app.loadWebhookEndpoint(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	endpointIDStr := uuid.UUID(endpoint.ID.Bytes).String()
+
+	deliveries, err := app.db.ListWebhookDeliveries(c.Request.Context(), endpoint.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("Webhook Deliveries GET: DB error for endpoint %s: %v\n", endpointIDStr, err)
+		c.String(http.StatusInternalServerError, "Failed to load deliveries.")
+		return
+	}
+
+	app.render(c, http.StatusOK, "webhook_deliveries.html", "Webhook Deliveries", gin.H{
+		"Endpoint":    endpoint,
+		"Deliveries":  deliveries,
+		"MaxAttempts": webhookMaxAttempts,
+		"CSRF":        csrfToken(c),
+	})
+}
+
+// postWebhookRedeliverHandler queues a fresh copy of a delivery, keeping the
+// original in the log.
+func (app *App) postWebhookRedeliverHandler(c *gin.Context) {
+	recruiter, ok := app.requireRole(c, RoleRecruiter)
+	if !ok {
+		return
+	}
+	endpoint, ok := app.loadWebhookEndpoint(c, recruiter.ID)
+	if !ok {
+		return
+	}
+	endpointURL := "/recruiter/webhooks/" + uuid.UUID(endpoint.ID.Bytes).String()
+
+	deliveryUUID, err := uuid.Parse(c.Param("deliveryID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid delivery ID.")
+		return
+	}
+	_, err = app.db.CreateWebhookRedelivery(c.Request.Context(), db.CreateWebhookRedeliveryParams{
+		ID:         pgtype.UUID{Bytes: deliveryUUID, Valid: true},
+		EndpointID: endpoint.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.String(http.StatusNotFound, "Delivery not found.")
+		return
+	} else if err != nil {
+		fmt.Printf("Webhook Redeliver POST: DB error for delivery %s: %v\n", deliveryUUID.String(), err)
+		c.String(http.StatusInternalServerError, "Failed to queue redelivery.")
+		return
+	}
+	app.webhooks.notify()
+	c.Redirect(http.StatusSeeOther, endpointURL)
+}
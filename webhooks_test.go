@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRejectNonPublicAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		allowed bool
+	}{
+		{"IPv4 loopback", "127.0.0.1", false},
+		{"IPv4 private 10/8", "10.1.2.3", false},
+		{"IPv4 private 172.16/12", "172.16.0.1", false},
+		{"IPv4 private 192.168/16", "192.168.1.1", false},
+		{"cloud metadata", "169.254.169.254", false},
+		{"IPv4 unspecified", "0.0.0.0", false},
+		{"IPv4 multicast", "224.0.0.1", false},
+		{"IPv4 broadcast", "255.255.255.255", false},
+		{"IPv6 loopback", "::1", false},
+		{"IPv6 unspecified", "::", false},
+		{"IPv6 link-local", "fe80::1", false},
+		{"IPv6 unique local", "fd00:ec2::254", false},
+		{"IPv4-mapped loopback", "::ffff:127.0.0.1", false},
+		{"IPv4-mapped private", "::ffff:10.0.0.1", false},
+		{"IPv4-mapped metadata", "::ffff:169.254.169.254", false},
+		{"public IPv4", "93.184.216.34", true},
+		{"public IPv6", "2606:2800:220:1:248:1893:25c8:1946", true},
+		{"IPv4-mapped public", "::ffff:93.184.216.34", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rejectNonPublicAddress("tcp", net.JoinHostPort(tt.address, "443"), nil)
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Errorf("rejectNonPublicAddress(%s) = %v, want allowed %v", tt.address, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesLocalServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if resp, err := newWebhookDispatcher(false).client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("webhook client connected to a loopback server")
+	}
+	resp, err := newWebhookDispatcher(true).client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("webhook client in development mode: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	production, development := newWebhookDispatcher(false), newWebhookDispatcher(true)
+	tests := []struct {
+		url             string
+		production, dev bool
+	}{
+		{"https://hooks.example.com/recruiting", true, true},
+		{"http://hooks.example.com/recruiting", false, true},
+		{"ftp://hooks.example.com/recruiting", false, false},
+		{"hooks.example.com/recruiting", false, false},
+		{"https://", false, false},
+	}
+	for _, tt := range tests {
+		if ok := production.checkWebhookURL(tt.url) == nil; ok != tt.production {
+			t.Errorf("checkWebhookURL(%q) accepted = %v, want %v", tt.url, ok, tt.production)
+		}
+		if ok := development.checkWebhookURL(tt.url) == nil; ok != tt.dev {
+			t.Errorf("checkWebhookURL(%q) in development mode accepted = %v, want %v", tt.url, ok, tt.dev)
+		}
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	got := webhookSignature("whsec_test", "1700000000", []byte(`{"event":"job.published"}`))
+	want := "sha256=6676edb5b64bf38a7399774bd5d6f17dbe869fe806d1bf7ea90bde9e67773079"
+	if got != want {
+		t.Errorf("webhookSignature = %s, want %s", got, want)
+	}
+	if other := webhookSignature("whsec_test", "1700000001", []byte(`{"event":"job.published"}`)); other == want {
+		t.Error("signature does not cover the timestamp")
+	}
+}